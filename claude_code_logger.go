@@ -12,9 +12,8 @@ import (
 
 // ClaudeCodeLogger はClaude Code専用のロガー設定
 type ClaudeCodeLogger struct {
-	logger     logger.Logger
-	fileWriter logger.Writer
-	logFile    string
+	logger  logger.Logger
+	logFile string
 }
 
 // NewClaudeCodeLogger は新しいClaude Code用ロガーを作成する
@@ -39,23 +38,17 @@ func NewClaudeCodeLogger() (*ClaudeCodeLogger, error) {
 	// テキストフォーマッターを設定
 	textFormatter := logger.NewTextFormatter()
 
-	// ファイルライターにフォーマッターを設定
-	if fwImpl, ok := fileWriter.(interface{ SetFormatter(logger.Formatter) }); ok {
-		fwImpl.SetFormatter(textFormatter)
-	}
-
-	// ロガーを作成
+	// ロガーを作成（フォーマッターは追加したすべてのライターに適用される）
 	vibeLogger := logger.New(logger.DEBUG)
-	vibeLogger.AddWriter(fileWriter)
 	vibeLogger.SetFormatter(textFormatter)
+	vibeLogger.AddWriter(fileWriter)
 
 	// コンソール出力も有効にする場合は以下をアンコメント
 	// vibeLogger.AddWriter(logger.NewConsoleWriter())
 
 	ccLogger := &ClaudeCodeLogger{
-		logger:     vibeLogger,
-		fileWriter: fileWriter,
-		logFile:    logFilePath,
+		logger:  vibeLogger,
+		logFile: logFilePath,
 	}
 
 	// 初期ログを出力
@@ -166,10 +159,7 @@ func (c *ClaudeCodeLogger) Close() error {
 		logger.String("end_time", time.Now().Format("2006-01-02 15:04:05")),
 	)
 
-	if c.fileWriter != nil {
-		return c.fileWriter.Close()
-	}
-	return nil
+	return c.logger.Close()
 }
 
 // 使用例を示すmain関数
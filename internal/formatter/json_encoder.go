@@ -0,0 +1,454 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+	"vibe-coding-logger/internal"
+)
+
+// maxPooledBufferSize はプールに戻すバッファの最大サイズ（巨大なエントリのバッファは保持しない）
+const maxPooledBufferSize = 64 * 1024
+
+// jsonBuffer はJSONエンコード用のバッファ
+type jsonBuffer struct {
+	b []byte
+}
+
+// bufferPool はエンコード用バッファのプール
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return &jsonBuffer{b: make([]byte, 0, 1024)}
+	},
+}
+
+// getBuffer はプールからバッファを取得する
+func getBuffer() *jsonBuffer {
+	buf := bufferPool.Get().(*jsonBuffer)
+	buf.b = buf.b[:0]
+	return buf
+}
+
+// putBuffer はバッファをプールに戻す
+func putBuffer(buf *jsonBuffer) {
+	if cap(buf.b) > maxPooledBufferSize {
+		return
+	}
+	bufferPool.Put(buf)
+}
+
+// finishBuffer はバッファの内容をコピーして返し、バッファをプールに戻す
+// prettyがtrueの場合はインデントを付けて整形する
+func finishBuffer(buf *jsonBuffer, pretty bool) ([]byte, error) {
+	defer putBuffer(buf)
+
+	if pretty {
+		out := getBuffer()
+		defer putBuffer(out)
+
+		indented, err := appendIndent(out.b, buf.b)
+		if err != nil {
+			return nil, err
+		}
+		out.b = append(indented, '\n')
+		result := make([]byte, len(out.b))
+		copy(result, out.b)
+		return result, nil
+	}
+
+	buf.b = append(buf.b, '\n')
+	result := make([]byte, len(buf.b))
+	copy(result, buf.b)
+	return result, nil
+}
+
+// appendIndent はJSONをインデント付きで追加する
+func appendIndent(dst, src []byte) ([]byte, error) {
+	w := bytes.NewBuffer(dst)
+	if err := json.Indent(w, src, "", "  "); err != nil {
+		return dst, err
+	}
+	return w.Bytes(), nil
+}
+
+// jsonObject はJSONオブジェクトのキーと値を順に書き込むためのヘルパー
+type jsonObject struct {
+	buf   *jsonBuffer
+	first bool
+}
+
+// beginObject はオブジェクトの書き込みを開始する
+func beginObject(buf *jsonBuffer) jsonObject {
+	buf.b = append(buf.b, '{')
+	return jsonObject{buf: buf, first: true}
+}
+
+// key はキーを書き込む
+func (o *jsonObject) key(k string) {
+	if !o.first {
+		o.buf.b = append(o.buf.b, ',')
+	}
+	o.first = false
+	o.buf.b = appendJSONString(o.buf.b, k)
+	o.buf.b = append(o.buf.b, ':')
+}
+
+// str は文字列の値を持つキーを書き込む
+func (o *jsonObject) str(k, v string) {
+	o.key(k)
+	o.buf.b = appendJSONString(o.buf.b, v)
+}
+
+// int は整数の値を持つキーを書き込む
+func (o *jsonObject) int(k string, v int64) {
+	o.key(k)
+	o.buf.b = strconv.AppendInt(o.buf.b, v, 10)
+}
+
+// bool は真偽値を持つキーを書き込む
+func (o *jsonObject) bool(k string, v bool) {
+	o.key(k)
+	o.buf.b = strconv.AppendBool(o.buf.b, v)
+}
+
+// time は時刻を指定したレイアウトの文字列として書き込む
+func (o *jsonObject) time(k string, t time.Time, layout string) {
+	o.key(k)
+	o.buf.b = append(o.buf.b, '"')
+	o.buf.b = t.AppendFormat(o.buf.b, layout)
+	o.buf.b = append(o.buf.b, '"')
+}
+
+// value は任意の値を持つキーを書き込む
+func (o *jsonObject) value(k string, v interface{}) {
+	o.key(k)
+	o.buf.b = appendJSONValue(o.buf.b, v, 0)
+}
+
+// strs は文字列スライスを持つキーを書き込む
+func (o *jsonObject) strs(k string, v []string) {
+	o.key(k)
+	o.buf.b = appendJSONStrings(o.buf.b, v)
+}
+
+// end はオブジェクトの書き込みを終了する
+func (o *jsonObject) end() {
+	o.buf.b = append(o.buf.b, '}')
+}
+
+// appendEntryFields はエントリのフィールドを定められた順序で書き込む
+//
+// 出力順序は次のとおり（値が空のフィールドはid〜operationを除き省略される）:
+//
+//	id, timestamp, level, action, operation,
+//	duration, duration_ms, trace_id, span_id, parent_id, tags,
+//	input, output, error, context, metadata, system_info, runtime_info
+//
+// errorの内部は message, type, code, stack, retryable, resolution, context の順
+// マップ（input・output・context・metadata・system_info・runtime_info）の内部はキーの昇順
+func appendEntryFields(o *jsonObject, entry *internal.Entry, timestampFormat string) {
+	o.str("id", entry.ID)
+	o.time("timestamp", entry.Timestamp, timestampFormat)
+	o.str("level", entry.Level.String())
+	o.str("action", string(entry.Action))
+	o.str("operation", entry.Operation)
+
+	if entry.Duration > 0 {
+		o.str("duration", entry.Duration.String())
+		o.int("duration_ms", entry.Duration.Milliseconds())
+	}
+	if entry.TraceID != "" {
+		o.str("trace_id", entry.TraceID)
+	}
+	if entry.SpanID != "" {
+		o.str("span_id", entry.SpanID)
+	}
+	if entry.ParentID != "" {
+		o.str("parent_id", entry.ParentID)
+	}
+	if len(entry.Tags) > 0 {
+		o.strs("tags", entry.Tags)
+	}
+	if len(entry.Input) > 0 {
+		o.value("input", entry.Input)
+	}
+	if len(entry.Output) > 0 {
+		o.value("output", entry.Output)
+	}
+	if entry.Error != nil {
+		o.key("error")
+		appendErrorInfo(o.buf, entry.Error)
+	}
+	if len(entry.Context) > 0 {
+		o.value("context", entry.Context)
+	}
+	if len(entry.Metadata) > 0 {
+		o.value("metadata", entry.Metadata)
+	}
+	if len(entry.SystemInfo) > 0 {
+		o.value("system_info", entry.SystemInfo)
+	}
+	if len(entry.RuntimeInfo) > 0 {
+		o.value("runtime_info", entry.RuntimeInfo)
+	}
+}
+
+// appendErrorInfo はエラー情報を書き込む
+func appendErrorInfo(buf *jsonBuffer, e *internal.ErrorInfo) {
+	o := beginObject(buf)
+	o.str("message", e.Message)
+	o.str("type", e.Type)
+	if e.Code != "" {
+		o.str("code", e.Code)
+	}
+	if e.Stack != "" {
+		o.str("stack", e.Stack)
+	}
+	o.bool("retryable", e.Retryable)
+	if e.Resolution != "" {
+		o.str("resolution", e.Resolution)
+	}
+	if len(e.Context) > 0 {
+		o.value("context", e.Context)
+	}
+	o.end()
+}
+
+// maxJSONDepth はネストした値を書き込む深さの上限（循環参照対策）
+const maxJSONDepth = 32
+
+// appendJSONValue は値をJSONとして追加する
+// よく使われる型はリフレクションを使わずに書き込み、それ以外の型はencoding/jsonに委ねる
+func appendJSONValue(b []byte, v interface{}, depth int) []byte {
+	if depth > maxJSONDepth {
+		return appendJSONString(b, "<max depth exceeded>")
+	}
+
+	switch t := v.(type) {
+	case nil:
+		return append(b, "null"...)
+	case string:
+		return appendJSONString(b, t)
+	case bool:
+		return strconv.AppendBool(b, t)
+	case int:
+		return strconv.AppendInt(b, int64(t), 10)
+	case int8:
+		return strconv.AppendInt(b, int64(t), 10)
+	case int16:
+		return strconv.AppendInt(b, int64(t), 10)
+	case int32:
+		return strconv.AppendInt(b, int64(t), 10)
+	case int64:
+		return strconv.AppendInt(b, t, 10)
+	case uint:
+		return strconv.AppendUint(b, uint64(t), 10)
+	case uint8:
+		return strconv.AppendUint(b, uint64(t), 10)
+	case uint16:
+		return strconv.AppendUint(b, uint64(t), 10)
+	case uint32:
+		return strconv.AppendUint(b, uint64(t), 10)
+	case uint64:
+		return strconv.AppendUint(b, t, 10)
+	case float32:
+		return appendJSONFloat(b, float64(t), 32)
+	case float64:
+		return appendJSONFloat(b, t, 64)
+	case time.Time:
+		b = append(b, '"')
+		b = t.AppendFormat(b, time.RFC3339Nano)
+		return append(b, '"')
+	case time.Duration:
+		return strconv.AppendInt(b, int64(t), 10)
+	case []byte:
+		b = append(b, '"')
+		b = base64.StdEncoding.AppendEncode(b, t)
+		return append(b, '"')
+	case []string:
+		return appendJSONStrings(b, t)
+	case []interface{}:
+		b = append(b, '[')
+		for i, item := range t {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendJSONValue(b, item, depth+1)
+		}
+		return append(b, ']')
+	case map[string]interface{}:
+		return appendJSONMap(b, t, depth)
+	case map[string]string:
+		// キーの並べ替えは小さなマップであればスタック上の配列で行う
+		var arr [16]string
+		keys := arr[:0]
+		for k := range t {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		b = append(b, '{')
+		for i, k := range keys {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendJSONString(b, k)
+			b = append(b, ':')
+			b = appendJSONString(b, t[k])
+		}
+		return append(b, '}')
+	case *internal.ErrorInfo:
+		if t == nil {
+			return append(b, "null"...)
+		}
+		buf := jsonBuffer{b: b}
+		appendErrorInfo(&buf, t)
+		return buf.b
+	case json.Marshaler:
+		data, err := t.MarshalJSON()
+		if err != nil {
+			return appendJSONString(b, fmt.Sprintf("<json error: %v>", err))
+		}
+		return appendCompactJSON(b, data)
+	case error:
+		return appendJSONString(b, t.Error())
+	default:
+		data, err := json.Marshal(t)
+		if err != nil {
+			return appendJSONString(b, fmt.Sprintf("%v", t))
+		}
+		return append(b, data...)
+	}
+}
+
+// appendJSONMap はマップをキーの昇順で追加する
+func appendJSONMap(b []byte, m map[string]interface{}, depth int) []byte {
+	// キーの並べ替えは小さなマップであればスタック上の配列で行う
+	var arr [16]string
+	keys := arr[:0]
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	b = append(b, '{')
+	for i, k := range keys {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendJSONString(b, k)
+		b = append(b, ':')
+		b = appendJSONValue(b, m[k], depth+1)
+	}
+	return append(b, '}')
+}
+
+// appendJSONStrings は文字列スライスを追加する
+func appendJSONStrings(b []byte, values []string) []byte {
+	if values == nil {
+		return append(b, "null"...)
+	}
+	b = append(b, '[')
+	for i, s := range values {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendJSONString(b, s)
+	}
+	return append(b, ']')
+}
+
+// appendCompactJSON はJSONの空白を取り除いて追加する
+func appendCompactJSON(b []byte, data []byte) []byte {
+	w := bytes.NewBuffer(b)
+	if err := json.Compact(w, data); err != nil {
+		return appendJSONString(b, string(data))
+	}
+	return w.Bytes()
+}
+
+// appendJSONFloat は浮動小数点数をencoding/jsonと同じ形式で追加する
+// NaNと無限大はJSONで表現できないため文字列として書き込む
+func appendJSONFloat(b []byte, f float64, bits int) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return appendJSONString(b, strconv.FormatFloat(f, 'g', -1, bits))
+	}
+
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	b = strconv.AppendFloat(b, f, format, -1, bits)
+	if format == 'e' {
+		// 1e-07 を 1e-7 に整える
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+	return b
+}
+
+// hexDigits は制御文字のエスケープに使う16進数
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString は文字列をJSONの文字列としてエスケープして追加する
+// 不正なUTF-8はU+FFFDに置き換え、U+2028・U+2029はエスケープする
+func appendJSONString(b []byte, s string) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, `\ufffd`...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
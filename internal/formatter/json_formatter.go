@@ -1,7 +1,6 @@
 package formatter
 
 import (
-	"encoding/json"
 	"time"
 	"vibe-coding-logger/internal"
 )
@@ -29,64 +28,13 @@ func NewPrettyJSONFormatter() *JSONFormatter {
 }
 
 // Format はエントリをJSON形式にフォーマットする
+// フィールドはappendEntryFieldsに記載の順序で書き込まれ、末尾に改行が付く
 func (f *JSONFormatter) Format(entry *internal.Entry) ([]byte, error) {
-	// タイムスタンプを文字列に変換
-	data := map[string]interface{}{
-		"id":        entry.ID,
-		"timestamp": entry.Timestamp.Format(f.TimestampFormat),
-		"level":     entry.Level.String(),
-		"action":    string(entry.Action),
-		"operation": entry.Operation,
-	}
-
-	// 追加フィールドを設定
-	if len(entry.Input) > 0 {
-		data["input"] = entry.Input
-	}
-
-	if len(entry.Output) > 0 {
-		data["output"] = entry.Output
-	}
-
-	if entry.Error != nil {
-		data["error"] = entry.Error
-	}
-
-	if entry.Duration > 0 {
-		data["duration"] = entry.Duration.String()
-		data["duration_ms"] = entry.Duration.Milliseconds()
-	}
-
-	if len(entry.Context) > 0 {
-		data["context"] = entry.Context
-	}
-
-	if len(entry.Tags) > 0 {
-		data["tags"] = entry.Tags
-	}
-
-	if entry.TraceID != "" {
-		data["trace_id"] = entry.TraceID
-	}
-
-	if entry.SpanID != "" {
-		data["span_id"] = entry.SpanID
-	}
-
-	if entry.ParentID != "" {
-		data["parent_id"] = entry.ParentID
-	}
-
-	if len(entry.Metadata) > 0 {
-		data["metadata"] = entry.Metadata
-	}
-
-	// JSON形式でエンコード
-	if f.PrettyPrint {
-		return json.MarshalIndent(data, "", "  ")
-	}
-
-	return json.Marshal(data)
+	buf := getBuffer()
+	o := beginObject(buf)
+	appendEntryFields(&o, entry, f.TimestampFormat)
+	o.end()
+	return finishBuffer(buf, f.PrettyPrint)
 }
 
 // VibeJSONFormatter はバイブコーディング専用のJSONフォーマッター
@@ -106,53 +54,39 @@ func NewVibeJSONFormatter() *VibeJSONFormatter {
 }
 
 // Format はエントリをバイブコーディング用JSON形式にフォーマットする
+// 基本のフィールドの後に session_id, problem_domain, programming_step, metrics の順で書き込む
 func (f *VibeJSONFormatter) Format(entry *internal.Entry) ([]byte, error) {
-	// 基本のJSONフォーマットを取得
-	baseData := make(map[string]interface{})
-	baseBytes, err := f.JSONFormatter.Format(entry)
-	if err != nil {
-		return nil, err
-	}
-
-	if err := json.Unmarshal(baseBytes, &baseData); err != nil {
-		return nil, err
-	}
+	buf := getBuffer()
+	o := beginObject(buf)
+	appendEntryFields(&o, entry, f.TimestampFormat)
 
 	// バイブコーディング専用フィールドを追加
 	if f.IncludeSessionInfo {
 		if sessionID, ok := getFromContext(entry.Context, "session_id"); ok {
-			baseData["session_id"] = sessionID
+			o.value("session_id", sessionID)
 		}
 		if problemDomain, ok := getFromContext(entry.Context, "problem_domain"); ok {
-			baseData["problem_domain"] = problemDomain
+			o.value("problem_domain", problemDomain)
 		}
 		if programmingStep, ok := getFromContext(entry.Context, "programming_step"); ok {
-			baseData["programming_step"] = programmingStep
+			o.value("programming_step", programmingStep)
 		}
 	}
 
-	if f.IncludeMetrics {
-		// メトリクス情報を追加
-		metrics := make(map[string]interface{})
-
-		if entry.Duration > 0 {
-			metrics["performance"] = map[string]interface{}{
-				"duration_ms":     entry.Duration.Milliseconds(),
-				"duration_string": entry.Duration.String(),
-			}
-		}
-
-		if len(metrics) > 0 {
-			baseData["metrics"] = metrics
-		}
-	}
-
-	// 再エンコード
-	if f.PrettyPrint {
-		return json.MarshalIndent(baseData, "", "  ")
+	// メトリクス情報を追加
+	if f.IncludeMetrics && entry.Duration > 0 {
+		o.key("metrics")
+		metrics := beginObject(buf)
+		metrics.key("performance")
+		performance := beginObject(buf)
+		performance.int("duration_ms", entry.Duration.Milliseconds())
+		performance.str("duration_string", entry.Duration.String())
+		performance.end()
+		metrics.end()
 	}
 
-	return json.Marshal(baseData)
+	o.end()
+	return finishBuffer(buf, f.PrettyPrint)
 }
 
 // getFromContext はコンテキストから値を取得する
@@ -179,32 +113,43 @@ func NewCompactJSONFormatter() *CompactJSONFormatter {
 }
 
 // Format はエントリをコンパクトなJSON形式にフォーマットする
+// フィールドは ts, lvl, op, err, dur, tid の順で書き込まれる
 func (f *CompactJSONFormatter) Format(entry *internal.Entry) ([]byte, error) {
-	data := map[string]interface{}{
-		"ts":  entry.Timestamp.Format(f.TimestampFormat),
-		"lvl": entry.Level.String(),
-		"op":  entry.Operation,
-	}
+	buf := getBuffer()
+	o := beginObject(buf)
 
-	// 必要なフィールドのみを含める
-	if entry.Error != nil {
-		data["err"] = entry.Error.Message
+	// 必要なフィールドのみを含める（除外フィールドは書き込まない）
+	if f.included("ts") {
+		o.time("ts", entry.Timestamp, f.TimestampFormat)
 	}
-
-	if entry.Duration > 0 {
-		data["dur"] = entry.Duration.Milliseconds()
+	if f.included("lvl") {
+		o.str("lvl", entry.Level.String())
 	}
-
-	if entry.TraceID != "" {
-		data["tid"] = entry.TraceID
+	if f.included("op") {
+		o.str("op", entry.Operation)
+	}
+	if entry.Error != nil && f.included("err") {
+		o.str("err", entry.Error.Message)
+	}
+	if entry.Duration > 0 && f.included("dur") {
+		o.int("dur", entry.Duration.Milliseconds())
+	}
+	if entry.TraceID != "" && f.included("tid") {
+		o.str("tid", entry.TraceID)
 	}
 
-	// 除外フィールドをチェック
+	o.end()
+	return finishBuffer(buf, false)
+}
+
+// included はフィールドが除外フィールドに含まれていないかを判定する
+func (f *CompactJSONFormatter) included(field string) bool {
 	for _, excludeField := range f.ExcludeFields {
-		delete(data, excludeField)
+		if excludeField == field {
+			return false
+		}
 	}
-
-	return json.Marshal(data)
+	return true
 }
 
 // StructuredJSONFormatter は構造化されたJSON形式でログを出力する
@@ -222,83 +167,90 @@ func NewStructuredJSONFormatter() *StructuredJSONFormatter {
 }
 
 // Format はエントリを構造化JSON形式にフォーマットする
+// フィールドは log, action, input, output, error, performance, tracing, metadata, context, tags の順で書き込まれる
 func (f *StructuredJSONFormatter) Format(entry *internal.Entry) ([]byte, error) {
-	data := map[string]interface{}{
-		"log": map[string]interface{}{
-			"id":        entry.ID,
-			"timestamp": entry.Timestamp.Format(f.TimestampFormat),
-			"level":     entry.Level.String(),
-			"message":   entry.Operation,
-		},
-	}
+	buf := getBuffer()
+	o := beginObject(buf)
+
+	o.key("log")
+	log := beginObject(buf)
+	log.str("id", entry.ID)
+	log.time("timestamp", entry.Timestamp, f.TimestampFormat)
+	log.str("level", entry.Level.String())
+	log.str("message", entry.Operation)
+	log.end()
 
 	// アクション情報
 	if entry.Action != "" {
-		data["action"] = map[string]interface{}{
-			"type": string(entry.Action),
-		}
+		o.key("action")
+		action := beginObject(buf)
+		action.str("type", string(entry.Action))
+		action.end()
 	}
 
 	// 入出力情報
 	if len(entry.Input) > 0 {
-		data["input"] = entry.Input
+		o.value("input", entry.Input)
 	}
-
 	if len(entry.Output) > 0 {
-		data["output"] = entry.Output
+		o.value("output", entry.Output)
 	}
 
 	// エラー情報
 	if entry.Error != nil {
-		data["error"] = map[string]interface{}{
-			"message":    entry.Error.Message,
-			"type":       entry.Error.Type,
-			"retryable":  entry.Error.Retryable,
-			"code":       entry.Error.Code,
-			"resolution": entry.Error.Resolution,
-		}
+		o.key("error")
+		errObj := beginObject(buf)
+		errObj.str("message", entry.Error.Message)
+		errObj.str("type", entry.Error.Type)
+		errObj.bool("retryable", entry.Error.Retryable)
+		errObj.str("code", entry.Error.Code)
+		errObj.str("resolution", entry.Error.Resolution)
 		if entry.Error.Stack != "" {
-			data["error"].(map[string]interface{})["stack"] = entry.Error.Stack
+			errObj.str("stack", entry.Error.Stack)
 		}
+		errObj.end()
 	}
 
 	// パフォーマンス情報
 	if entry.Duration > 0 {
-		data["performance"] = map[string]interface{}{
-			"duration_ms":     entry.Duration.Milliseconds(),
-			"duration_string": entry.Duration.String(),
-		}
+		o.key("performance")
+		performance := beginObject(buf)
+		performance.int("duration_ms", entry.Duration.Milliseconds())
+		performance.str("duration_string", entry.Duration.String())
+		performance.end()
 	}
 
 	// トレーシング情報
 	if entry.TraceID != "" || entry.SpanID != "" || entry.ParentID != "" {
-		tracing := make(map[string]interface{})
+		o.key("tracing")
+		tracing := beginObject(buf)
 		if entry.TraceID != "" {
-			tracing["trace_id"] = entry.TraceID
+			tracing.str("trace_id", entry.TraceID)
 		}
 		if entry.SpanID != "" {
-			tracing["span_id"] = entry.SpanID
+			tracing.str("span_id", entry.SpanID)
 		}
 		if entry.ParentID != "" {
-			tracing["parent_id"] = entry.ParentID
+			tracing.str("parent_id", entry.ParentID)
 		}
-		data["tracing"] = tracing
+		tracing.end()
 	}
 
 	// メタデータ
 	if len(entry.Metadata) > 0 {
-		data["metadata"] = entry.Metadata
+		o.value("metadata", entry.Metadata)
 	}
 
 	// コンテキスト
 	if len(entry.Context) > 0 {
-		data["context"] = entry.Context
+		o.value("context", entry.Context)
 	}
 
 	// タグ
 	if len(entry.Tags) > 0 {
-		data["tags"] = entry.Tags
+		o.strs("tags", entry.Tags)
 	}
 
-	return json.Marshal(data)
+	o.end()
+	return finishBuffer(buf, false)
 }
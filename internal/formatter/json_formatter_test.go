@@ -0,0 +1,77 @@
+package formatter
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+	"vibe-coding-logger/internal"
+)
+
+// newTestEntry はベンチマークとアロケーション計測に使う典型的なエントリを作成する
+func newTestEntry() *internal.Entry {
+	return &internal.Entry{
+		ID:        "0f8fad5b-d9cb-469f-a165-70867728950e",
+		Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Level:     internal.INFO,
+		Action:    internal.ActionComplete,
+		Operation: "fetch_user",
+		Input:     map[string]interface{}{"user_id": 42, "cache": true},
+		Output:    map[string]interface{}{"name": "alice", "roles": []string{"admin", "dev"}},
+		Duration:  1500 * time.Millisecond,
+		Context:   map[string]interface{}{"request_id": "req-1", "attempt": 1, "ratio": 0.5},
+		Tags:      []string{"api", "user"},
+		TraceID:   "0123456789abcdef0123456789abcdef",
+		SpanID:    "0123456789abcdef",
+		Metadata:  map[string]interface{}{"caller": "main.go:10"},
+	}
+}
+
+func TestJSONFormatterFormat(t *testing.T) {
+	out, err := NewJSONFormatter().Format(newTestEntry())
+	if err != nil {
+		t.Fatalf("Format: %v", err)
+	}
+	if len(out) == 0 || out[len(out)-1] != '\n' {
+		t.Fatalf("出力の末尾が改行ではありません: %q", out)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatalf("出力が正しいJSONではありません: %v\n%s", err, out)
+	}
+	if decoded["operation"] != "fetch_user" || decoded["trace_id"] != "0123456789abcdef0123456789abcdef" {
+		t.Errorf("出力 = %s", out)
+	}
+	if input, _ := decoded["input"].(map[string]interface{}); input["user_id"] != float64(42) {
+		t.Errorf("input = %v", decoded["input"])
+	}
+}
+
+func TestJSONFormatterFormatAllocs(t *testing.T) {
+	f := NewJSONFormatter()
+	entry := newTestEntry()
+
+	// バッファはプールから再利用されるため、返却用のコピー1回のみとなる
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := f.Format(entry); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs > 1 {
+		t.Errorf("Formatあたりのアロケーション数 = %v, want <= 1", allocs)
+	}
+}
+
+func BenchmarkJSONFormatter_Format(b *testing.B) {
+	f := NewJSONFormatter()
+	entry := newTestEntry()
+	entry.Error = &internal.ErrorInfo{Message: "timeout", Type: "*errors.errorString"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := f.Format(entry); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
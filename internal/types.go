@@ -62,6 +62,12 @@ const (
 	ActionError    ActionType = "ERROR"
 	ActionRetry    ActionType = "RETRY"
 	ActionSkip     ActionType = "SKIP"
+	ActionRecovery ActionType = "RECOVERY"
+
+	// サーキットブレーカーの状態遷移
+	ActionCircuitOpen     ActionType = "CIRCUIT_OPEN"
+	ActionCircuitHalfOpen ActionType = "CIRCUIT_HALF_OPEN"
+	ActionCircuitClose    ActionType = "CIRCUIT_CLOSE"
 )
 
 // ErrorInfo はエラー情報を表す
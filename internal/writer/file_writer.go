@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 	"vibe-coding-logger/internal"
@@ -15,6 +17,9 @@ type FileWriter struct {
 	filename  string
 	file      *os.File
 	formatter internal.Formatter
+	watcher   pathWatcher
+	syncer    *syncer
+	lock      *processLock // 複数プロセスから書き込む場合のロック（単一プロセスの場合はnil）
 	mu        sync.Mutex
 }
 
@@ -34,6 +39,8 @@ func NewFileWriter(filename string) (*FileWriter, error) {
 	return &FileWriter{
 		filename: filename,
 		file:     file,
+		watcher:  newPathWatcher(),
+		syncer:   newSyncer(SyncPolicy{}),
 	}, nil
 }
 
@@ -52,13 +59,86 @@ func (w *FileWriter) Write(entry *internal.Entry) error {
 		return err
 	}
 
+	if err := w.lock.lock(); err != nil {
+		return err
+	}
+	defer w.lock.unlock()
+
+	// ファイルが外部でリネーム・削除されていれば開き直す
+	if w.watcher.moved(w.file, w.filename) {
+		if err := w.reopen(); err != nil {
+			return err
+		}
+	}
+
+	// エントリは1回のwriteで書き込み、他のプロセスの書き込みと混ざらないようにする
 	_, err = w.file.Write(formatted)
 	if err != nil {
 		return err
 	}
 
-	// 即座に書き込み（バッファリングを無効化）
-	return w.file.Sync()
+	// 同期の設定に従ってディスクに同期（デフォルトでは即座に同期）
+	return w.syncer.afterWrite(w.file, 1, entry.Level)
+}
+
+// SetMultiProcess は複数プロセスから同じファイルに書き込むモードを設定する
+// 有効にすると「ファイル名.lock」のアドバイザリロック（flock）を取得してから書き込む
+// flockのない環境（Windowsなど）ではプロセス内の排他のみを行う
+func (w *FileWriter) SetMultiProcess(enabled bool) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var err error
+	w.lock, err = switchLock(w.lock, enabled, w.filename+lockSuffix)
+	return err
+}
+
+// Reopen はファイルを閉じて同じパスで開き直す
+// logrotateなどでファイルを移動した後に呼び出すと、新しいファイルへの書き込みに切り替わる
+func (w *FileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.reopen()
+}
+
+// reopen はファイルを開き直す（内部用）
+func (w *FileWriter) reopen() error {
+	file, err := openAppend(w.filename)
+	if err != nil {
+		return err
+	}
+	if w.file != nil {
+		_ = w.syncer.syncIfDirty(w.file) // エラーは無視（移動済みのファイルへのベストエフォート）
+		w.file.Close()
+	}
+	w.file = file
+	return nil
+}
+
+// SetSyncPolicy はディスクへの同期の設定を行う
+func (w *FileWriter) SetSyncPolicy(policy SyncPolicy) {
+	w.mu.Lock()
+	old := w.syncer
+	w.syncer = newSyncer(policy)
+	w.syncer.unsynced = old.unsynced
+	w.syncer.start("FileWriter", w.syncIfDirty)
+	w.mu.Unlock()
+
+	old.halt()
+}
+
+// syncIfDirty は未同期のエントリがあればディスクに同期する
+func (w *FileWriter) syncIfDirty() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.syncer.syncIfDirty(w.file)
+}
+
+// SetReopenCheckInterval はパスのリネーム・削除を確認する間隔を設定する（0の場合は確認しない、デフォルトは1秒）
+func (w *FileWriter) SetReopenCheckInterval(interval time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.watcher.interval = interval
 }
 
 // SetFormatter はフォーマッターを設定する
@@ -68,13 +148,31 @@ func (w *FileWriter) SetFormatter(f internal.Formatter) {
 	w.formatter = f
 }
 
-// Close はファイルを閉じる
+// Flush はファイルの内容をディスクに同期する
+func (w *FileWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.syncer.sync(w.file)
+}
+
+// Close は未同期の内容をディスクに同期してからファイルを閉じる
 func (w *FileWriter) Close() error {
+	w.mu.Lock()
+	s := w.syncer
+	w.mu.Unlock()
+	s.halt()
+
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
+	_ = w.lock.close()
 	if w.file != nil {
-		return w.file.Close()
+		syncErr := w.syncer.syncIfDirty(w.file)
+		if err := w.file.Close(); err != nil {
+			return err
+		}
+		return syncErr
 	}
 	return nil
 }
@@ -87,6 +185,10 @@ type RotatingFileWriter struct {
 	currentFile  *os.File
 	currentSize  int64
 	formatter    internal.Formatter
+	retention    *retention
+	watcher      pathWatcher
+	syncer       *syncer
+	lock         *processLock // 複数プロセスから書き込む場合のロック（単一プロセスの場合はnil）
 	mu           sync.Mutex
 }
 
@@ -101,6 +203,8 @@ func NewRotatingFileWriter(baseFilename string, maxSize int64, maxFiles int) (*R
 		baseFilename: baseFilename,
 		maxSize:      maxSize,
 		maxFiles:     maxFiles,
+		watcher:      newPathWatcher(),
+		syncer:       newSyncer(SyncPolicy{}),
 	}
 
 	if err := rfw.openCurrentFile(); err != nil {
@@ -124,6 +228,16 @@ func (w *RotatingFileWriter) Write(entry *internal.Entry) error {
 		return err
 	}
 
+	// 複数プロセスの場合はロックを取得してから、サイズの確認・ローテーション・書き込みを行う
+	if err := w.lock.lock(); err != nil {
+		return err
+	}
+	defer w.lock.unlock()
+
+	if err := w.follow(); err != nil {
+		return err
+	}
+
 	// ファイルサイズをチェック
 	if w.currentSize+int64(len(formatted)) > w.maxSize {
 		if err := w.rotate(); err != nil {
@@ -137,28 +251,55 @@ func (w *RotatingFileWriter) Write(entry *internal.Entry) error {
 	}
 
 	w.currentSize += int64(n)
-	return w.currentFile.Sync()
+	return w.syncer.afterWrite(w.currentFile, 1, entry.Level)
+}
+
+// follow はファイルが外部でリネーム・削除されていれば開き直す
+// 複数プロセスの場合は他のプロセスのローテーションと書き込みに追従するため、毎回確認してサイズを更新する
+func (w *RotatingFileWriter) follow() error {
+	if w.lock == nil {
+		if w.watcher.moved(w.currentFile, w.baseFilename) {
+			return w.reopen()
+		}
+		return nil
+	}
+
+	if pathMoved(w.currentFile, w.baseFilename) {
+		return w.reopen()
+	}
+	stat, err := w.currentFile.Stat()
+	if err != nil {
+		return err
+	}
+	w.currentSize = stat.Size()
+	return nil
 }
 
 // rotate はファイルをローテーションする
 func (w *RotatingFileWriter) rotate() error {
-	// 現在のファイルを閉じる
+	// 現在のファイルを同期してから閉じる
 	if w.currentFile != nil {
+		_ = w.syncer.syncIfDirty(w.currentFile) // エラーは無視（ログローテーション時のベストエフォート）
 		w.currentFile.Close()
 	}
 
-	// 既存のファイルを番号付きにリネーム
-	for i := w.maxFiles - 1; i >= 1; i-- {
-		oldName := fmt.Sprintf("%s.%d", w.baseFilename, i)
-		newName := fmt.Sprintf("%s.%d", w.baseFilename, i+1)
-
-		if i == w.maxFiles-1 {
-			// 最古のファイルを削除
-			os.Remove(newName)
-		}
+	// 圧縮中のファイルの置き換えと競合しないようにリネームする
+	w.retention.lock()
 
-		if _, err := os.Stat(oldName); err == nil {
-			_ = os.Rename(oldName, newName) // エラーは無視（ログローテーション時のベストエフォート）
+	// 既存のファイルを番号付きにリネーム（圧縮済みのファイルも同じ番号でリネームする）
+	for i := w.maxFiles - 1; i >= 1; i-- {
+		for _, ext := range []string{"", compressedSuffix} {
+			oldName := fmt.Sprintf("%s.%d%s", w.baseFilename, i, ext)
+			newName := fmt.Sprintf("%s.%d%s", w.baseFilename, i+1, ext)
+
+			if i == w.maxFiles-1 {
+				// 最古のファイルを削除
+				os.Remove(newName)
+			}
+
+			if _, err := os.Stat(oldName); err == nil {
+				_ = os.Rename(oldName, newName) // エラーは無視（ログローテーション時のベストエフォート）
+			}
 		}
 	}
 
@@ -167,148 +308,182 @@ func (w *RotatingFileWriter) rotate() error {
 		_ = os.Rename(w.baseFilename, w.baseFilename+".1") // エラーは無視（ログローテーション時のベストエフォート）
 	}
 
+	w.retention.unlock()
+	w.retention.notify()
+
 	// 新しいファイルを作成
 	return w.openCurrentFile()
 }
 
-// openCurrentFile は現在のファイルを開く
-func (w *RotatingFileWriter) openCurrentFile() error {
-	file, err := os.OpenFile(w.baseFilename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-	if err != nil {
-		return err
-	}
-
-	w.currentFile = file
+// SetRetention はローテーション済みファイル（.1, .2, ... とその.gz）の圧縮と保持の設定を行う
+// 設定時に既存のローテーション済みファイルにも適用される
+func (w *RotatingFileWriter) SetRetention(policy RetentionPolicy) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 
-	// 現在のファイルサイズを取得
-	stat, err := file.Stat()
-	if err != nil {
-		return err
+	w.retention.close()
+	w.retention = nil
+	if policy.enabled() {
+		w.retention = newRetention(policy, w.baseFilename, rotatingBackupParser(filepath.Base(w.baseFilename)), w.lock, false)
 	}
-	w.currentSize = stat.Size()
-
-	return nil
 }
 
-// SetFormatter はフォーマッターを設定する
-func (w *RotatingFileWriter) SetFormatter(formatter internal.Formatter) {
+// SetMultiProcess は複数プロセスから同じファイルに書き込むモードを設定する
+// 有効にすると「ベース名.lock」のアドバイザリロック（flock）を取得してから書き込みとローテーションを行い、
+// 他のプロセスがローテーションした場合は新しいファイルに追従する
+// 圧縮と削除はロックを取得できた1つのプロセスのみが行う
+func (w *RotatingFileWriter) SetMultiProcess(enabled bool) error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	w.formatter = formatter
-}
 
-// Close はファイルを閉じる
-func (w *RotatingFileWriter) Close() error {
-	w.mu.Lock()
-	defer w.mu.Unlock()
+	// 古いロックを使っているバックグラウンドの処理を止めてからロックを切り替える
+	var policy RetentionPolicy
+	if w.retention != nil {
+		policy = w.retention.policy
+		w.retention.close()
+		w.retention = nil
+	}
 
-	if w.currentFile != nil {
-		return w.currentFile.Close()
+	var err error
+	w.lock, err = switchLock(w.lock, enabled, w.baseFilename+lockSuffix)
+	if policy.enabled() {
+		w.retention = newRetention(policy, w.baseFilename, rotatingBackupParser(filepath.Base(w.baseFilename)), w.lock, false)
 	}
-	return nil
+	return err
 }
 
-// DailyRotatingFileWriter は日付ベースでローテーションするファイルライター
-type DailyRotatingFileWriter struct {
-	baseFilename string
-	currentDate  string
-	currentFile  *os.File
-	formatter    internal.Formatter
-	mu           sync.Mutex
+// rotatingBackupParser は「ベース名.番号」のファイルを番号が小さいほど新しいものとして解析する
+func rotatingBackupParser(base string) backupParser {
+	return func(name string) (int64, bool) {
+		suffix, ok := strings.CutPrefix(name, base+".")
+		if !ok {
+			return 0, false
+		}
+		n, err := strconv.ParseInt(suffix, 10, 64)
+		if err != nil || n < 1 {
+			return 0, false
+		}
+		return -n, true
+	}
 }
 
-// NewDailyRotatingFileWriter は新しい日次ローテーションファイルライターを作成する
-func NewDailyRotatingFileWriter(baseFilename string) (*DailyRotatingFileWriter, error) {
-	dir := filepath.Dir(baseFilename)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return nil, err
-	}
+// Reopen は現在のファイルを閉じて同じパスで開き直す
+func (w *RotatingFileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.reopen()
+}
 
-	drfw := &DailyRotatingFileWriter{
-		baseFilename: baseFilename,
+// reopen は現在のファイルを開き直す（内部用）
+func (w *RotatingFileWriter) reopen() error {
+	if w.currentFile != nil {
+		_ = w.syncer.syncIfDirty(w.currentFile) // エラーは無視（移動済みのファイルへのベストエフォート）
+		w.currentFile.Close()
 	}
+	return w.openCurrentFile()
+}
 
-	if err := drfw.openCurrentFile(); err != nil {
-		return nil, err
-	}
+// SetSyncPolicy はディスクへの同期の設定を行う
+func (w *RotatingFileWriter) SetSyncPolicy(policy SyncPolicy) {
+	w.mu.Lock()
+	old := w.syncer
+	w.syncer = newSyncer(policy)
+	w.syncer.unsynced = old.unsynced
+	w.syncer.start("RotatingFileWriter", w.syncIfDirty)
+	w.mu.Unlock()
 
-	return drfw, nil
+	old.halt()
 }
 
-// Write はエントリを日次ローテーションファイルに書き込む
-func (w *DailyRotatingFileWriter) Write(entry *internal.Entry) error {
+// syncIfDirty は未同期のエントリがあればディスクに同期する
+func (w *RotatingFileWriter) syncIfDirty() error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
+	return w.syncer.syncIfDirty(w.currentFile)
+}
 
-	if w.formatter == nil {
-		w.formatter = formatter.NewJSONFormatter()
-	}
-
-	// 日付が変わったかチェック
-	currentDate := time.Now().Format("2006-01-02")
-	if w.currentDate != currentDate {
-		if err := w.rotate(); err != nil {
-			return err
-		}
-	}
-
-	formatted, err := w.formatter.Format(entry)
-	if err != nil {
-		return err
-	}
+// SetReopenCheckInterval はパスのリネーム・削除を確認する間隔を設定する（0の場合は確認しない、デフォルトは1秒）
+func (w *RotatingFileWriter) SetReopenCheckInterval(interval time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.watcher.interval = interval
+}
 
-	_, err = w.currentFile.Write(formatted)
+// openCurrentFile は現在のファイルを開く
+func (w *RotatingFileWriter) openCurrentFile() error {
+	file, err := openAppend(w.baseFilename)
 	if err != nil {
 		return err
 	}
 
-	return w.currentFile.Sync()
-}
-
-// rotate はファイルをローテーションする
-func (w *DailyRotatingFileWriter) rotate() error {
-	// 現在のファイルを閉じる
-	if w.currentFile != nil {
-		w.currentFile.Close()
-	}
-
-	// 新しいファイルを開く
-	return w.openCurrentFile()
-}
-
-// openCurrentFile は現在のファイルを開く
-func (w *DailyRotatingFileWriter) openCurrentFile() error {
-	currentDate := time.Now().Format("2006-01-02")
-	filename := fmt.Sprintf("%s.%s", w.baseFilename, currentDate)
+	w.currentFile = file
 
-	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	// 現在のファイルサイズを取得
+	stat, err := file.Stat()
 	if err != nil {
 		return err
 	}
+	w.currentSize = stat.Size()
 
-	w.currentFile = file
-	w.currentDate = currentDate
 	return nil
 }
 
 // SetFormatter はフォーマッターを設定する
-func (w *DailyRotatingFileWriter) SetFormatter(formatter internal.Formatter) {
+func (w *RotatingFileWriter) SetFormatter(formatter internal.Formatter) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 	w.formatter = formatter
 }
 
-// Close はファイルを閉じる
-func (w *DailyRotatingFileWriter) Close() error {
+// Flush は現在のファイルの内容をディスクに同期する
+func (w *RotatingFileWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.syncer.sync(w.currentFile)
+}
+
+// Close は未同期の内容をディスクに同期してからファイルを閉じる
+// 圧縮中のファイルがあれば完了を待ち、未圧縮のファイルは次回の起動時に圧縮される
+func (w *RotatingFileWriter) Close() error {
+	w.mu.Lock()
+	s := w.syncer
+	w.mu.Unlock()
+	s.halt()
+
 	w.mu.Lock()
 	defer w.mu.Unlock()
 
+	w.retention.close()
+	_ = w.lock.close()
 	if w.currentFile != nil {
-		return w.currentFile.Close()
+		syncErr := w.syncer.syncIfDirty(w.currentFile)
+		if err := w.currentFile.Close(); err != nil {
+			return err
+		}
+		return syncErr
 	}
 	return nil
 }
 
+// DailyRotatingFileWriter は日付ベースでローテーションするファイルライター
+// 「ベース名.YYYY-MM-DD」のファイルに書き込み、ローカルタイムの0時にローテーションする
+type DailyRotatingFileWriter struct {
+	*RotationWriter
+}
+
+// NewDailyRotatingFileWriter は新しい日次ローテーションファイルライターを作成する
+func NewDailyRotatingFileWriter(baseFilename string) (*DailyRotatingFileWriter, error) {
+	rw, err := NewRotationWriter(RotationOptions{
+		Filename: baseFilename + "." + placeholderDate,
+		Interval: RotateDaily,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &DailyRotatingFileWriter{RotationWriter: rw}, nil
+}
+
 // VibeFileWriter はバイブコーディング専用のファイルライター
 type VibeFileWriter struct {
 	*FileWriter
@@ -338,26 +513,34 @@ func NewVibeFileWriter(baseFilename, sessionID, problemDomain string) (*VibeFile
 	return vfw, nil
 }
 
+// DefaultMaxLatency はBufferedFileWriterがバッファに入ったエントリを出力するまでの最大の遅延のデフォルト値
+const DefaultMaxLatency = time.Second
+
 // BufferedFileWriter はバッファリングされたファイルライター
 type BufferedFileWriter struct {
 	*FileWriter
 	buffer     [][]byte
 	bufferSize int
+	maxLevel   internal.LogLevel // バッファ内のエントリの最も高いレベル（SyncOnError用）
+	timer      latencyTimer
 	mu         sync.Mutex
 }
 
 // NewBufferedFileWriter は新しいバッファリングファイルライターを作成する
+// バッファに入ったエントリはDefaultMaxLatencyが経過すると出力される（SetMaxLatencyで変更可能）
 func NewBufferedFileWriter(filename string, bufferSize int) (*BufferedFileWriter, error) {
 	fileWriter, err := NewFileWriter(filename)
 	if err != nil {
 		return nil, err
 	}
 
-	return &BufferedFileWriter{
+	w := &BufferedFileWriter{
 		FileWriter: fileWriter,
 		buffer:     make([][]byte, 0, bufferSize),
 		bufferSize: bufferSize,
-	}, nil
+	}
+	w.timer.setLatency(DefaultMaxLatency)
+	return w, nil
 }
 
 // Write はエントリをバッファに書き込む
@@ -375,36 +558,119 @@ func (w *BufferedFileWriter) Write(entry *internal.Entry) error {
 	}
 
 	w.buffer = append(w.buffer, formatted)
+	if entry.Level > w.maxLevel {
+		w.maxLevel = entry.Level
+	}
 
 	// バッファが満杯になったら出力
 	if len(w.buffer) >= w.bufferSize {
-		return w.flush()
+		return w.flush(false)
+	}
+
+	// 最初のエントリから最大の遅延が経過したら出力する
+	if len(w.buffer) == 1 {
+		w.timer.arm(w.flushOnTimer)
 	}
 
 	return nil
 }
 
-// Flush はバッファの内容をファイルに出力する
+// SetFormatter はフォーマッターを設定する
+// WriteはBufferedFileWriterのロックでフォーマッターを参照するため、同じロックを取得して設定する
+func (w *BufferedFileWriter) SetFormatter(f internal.Formatter) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.FileWriter.SetFormatter(f)
+}
+
+// SetMaxLatency はバッファに入ったエントリを出力するまでの最大の遅延を設定する（0以下の場合はバッファが満杯になるまで出力しない）
+func (w *BufferedFileWriter) SetMaxLatency(latency time.Duration) {
+	w.timer.setLatency(latency)
+}
+
+// Flush はバッファの内容をファイルに出力し、ディスクに同期する
 func (w *BufferedFileWriter) Flush() error {
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	return w.flush()
+	return w.flush(true)
+}
+
+// flushOnTimer は最大の遅延が経過したときにバッファの内容をファイルに出力する
+func (w *BufferedFileWriter) flushOnTimer() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.flush(false); err != nil {
+		fmt.Printf("BufferedFileWriter flush error: %v\n", err)
+	}
 }
 
 // flush はバッファの内容をファイルに出力する（内部用）
-func (w *BufferedFileWriter) flush() error {
-	if len(w.buffer) == 0 {
+// forceがtrueの場合は同期の設定に関わらずディスクに同期する
+func (w *BufferedFileWriter) flush(force bool) error {
+	w.timer.disarm()
+
+	w.FileWriter.mu.Lock()
+	defer w.FileWriter.mu.Unlock()
+
+	n := len(w.buffer)
+	if n > 0 {
+		if err := w.lock.lock(); err != nil {
+			return err
+		}
+		err := w.writeBuffer()
+		w.lock.unlock()
+		if err != nil {
+			return err
+		}
+	}
+
+	level := w.maxLevel
+	w.buffer = w.buffer[:0] // バッファをクリア
+	w.maxLevel = internal.DEBUG
+
+	if force {
+		w.syncer.unsynced += n
+		return w.syncer.syncIfDirty(w.file)
+	}
+	if n == 0 {
 		return nil
 	}
+	return w.syncer.afterWrite(w.file, n, level)
+}
 
-	for _, formatted := range w.buffer {
-		if _, err := w.file.Write(formatted); err != nil {
+// writeBuffer はバッファ内のエントリをまとめて1回のwriteで書き込む
+// 複数プロセスの場合もエントリの途中で他のプロセスの書き込みと混ざらない
+func (w *BufferedFileWriter) writeBuffer() error {
+	// ファイルが外部でリネーム・削除されていれば開き直す
+	if w.watcher.moved(w.file, w.filename) {
+		if err := w.FileWriter.reopen(); err != nil {
 			return err
 		}
 	}
 
-	w.buffer = w.buffer[:0] // バッファをクリア
-	return w.file.Sync()
+	size := 0
+	for _, formatted := range w.buffer {
+		size += len(formatted)
+	}
+	data := make([]byte, 0, size)
+	for _, formatted := range w.buffer {
+		data = append(data, formatted...)
+	}
+
+	_, err := w.file.Write(data)
+	return err
+}
+
+// Reopen はバッファの内容を出力してから、ファイルを同じパスで開き直す
+func (w *BufferedFileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.flush(true); err != nil {
+		return err
+	}
+	return w.FileWriter.Reopen()
 }
 
 // Close はファイルを閉じ、バッファを出力する
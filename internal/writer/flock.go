@@ -0,0 +1,96 @@
+package writer
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	// lockSuffix は書き込みとローテーションの排他に使うロックファイルの拡張子
+	lockSuffix = ".lock"
+	// retentionLockSuffix は圧縮と削除を行うプロセスを1つに限るロックファイルの拡張子
+	retentionLockSuffix = ".retention.lock"
+)
+
+// processLock はロックファイルのアドバイザリロック（flock）によるプロセス間の排他ロック
+// flockは同じプロセス内の別のゴルーチンを排他しないため、ミューテックスを併用する
+// flockのない環境（Windowsなど）ではプロセス内の排他のみを行う
+type processLock struct {
+	path string
+	file *os.File
+	mu   sync.Mutex
+}
+
+// newProcessLock はロックファイルを開く（存在しない場合は作成する）
+func newProcessLock(path string) (*processLock, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &processLock{path: path, file: file}, nil
+}
+
+// lock はロックを取得するまで待つ（nilの場合は何もしない）
+func (l *processLock) lock() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	if err := lockFile(l.file); err != nil {
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// unlock はロックを解放する（nilの場合は何もしない）
+func (l *processLock) unlock() {
+	if l == nil {
+		return
+	}
+	_ = unlockFile(l.file) // 解放できない場合もファイルを閉じれば解放される
+	l.mu.Unlock()
+}
+
+// tryLock は待たずにロックの取得を試み、取得できたかどうかを返す（nilの場合は常に取得できる）
+func (l *processLock) tryLock() (bool, error) {
+	if l == nil {
+		return true, nil
+	}
+	if !l.mu.TryLock() {
+		return false, nil
+	}
+	ok, err := tryLockFile(l.file)
+	if err != nil || !ok {
+		l.mu.Unlock()
+	}
+	return ok, err
+}
+
+// sibling は同じ名前で拡張子の異なるロックファイルを開く
+func (l *processLock) sibling(suffix string) (*processLock, error) {
+	return newProcessLock(strings.TrimSuffix(l.path, lockSuffix) + suffix)
+}
+
+// close はロックファイルを閉じる（nilの場合は何もしない）
+func (l *processLock) close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// switchLock は複数プロセスのモードの有効・無効に応じてロックファイルを開く・閉じる
+// 無効にした場合とロックファイルを開けなかった場合はnilを返す
+func switchLock(current *processLock, enabled bool, path string) (*processLock, error) {
+	if enabled == (current != nil) {
+		return current, nil
+	}
+	if !enabled {
+		return nil, current.close()
+	}
+	return newProcessLock(path)
+}
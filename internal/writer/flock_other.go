@@ -0,0 +1,20 @@
+//go:build !unix
+
+package writer
+
+import "os"
+
+// lockFile はflockのない環境では何もしない（プロセス内の排他のみ行われる）
+func lockFile(f *os.File) error {
+	return nil
+}
+
+// tryLockFile はflockのない環境では常に取得できたものとする
+func tryLockFile(f *os.File) (bool, error) {
+	return true, nil
+}
+
+// unlockFile はflockのない環境では何もしない
+func unlockFile(f *os.File) error {
+	return nil
+}
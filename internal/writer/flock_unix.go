@@ -0,0 +1,50 @@
+//go:build unix
+
+package writer
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockFile はファイルに排他的なアドバイザリロックをかける（取得するまで待つ）
+func lockFile(f *os.File) error {
+	return flock(f, syscall.LOCK_EX)
+}
+
+// tryLockFile は待たずに排他的なアドバイザリロックの取得を試みる
+func tryLockFile(f *os.File) (bool, error) {
+	err := flock(f, syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// unlockFile はアドバイザリロックを解放する
+func unlockFile(f *os.File) error {
+	return flock(f, syscall.LOCK_UN)
+}
+
+// flock はシグナルで中断された場合も再試行してflockを呼び出す
+func flock(f *os.File, how int) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var opErr error
+	err = conn.Control(func(fd uintptr) {
+		for {
+			opErr = syscall.Flock(int(fd), how)
+			if opErr != syscall.EINTR {
+				return
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return opErr
+}
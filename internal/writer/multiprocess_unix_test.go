@@ -0,0 +1,193 @@
+//go:build unix
+
+package writer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"vibe-coding-logger/internal"
+)
+
+// 子プロセスとして起動されたことを示す環境変数
+const (
+	envWriterKind   = "VIBE_LOGGER_TEST_WRITER_KIND"
+	envWriterPath   = "VIBE_LOGGER_TEST_WRITER_PATH"
+	envWriterWorker = "VIBE_LOGGER_TEST_WRITER_WORKER"
+)
+
+const (
+	testProcesses        = 4
+	testEntriesPerWorker = 300
+	testRotationMaxSize  = 16 * 1024
+)
+
+// testPayload はエントリを大きくし、書き込みが混ざった場合に検出しやすくするための値
+var testPayload = strings.Repeat("x", 512)
+
+// TestHelperWriterProcess は子プロセスとしてエントリを書き込む（通常のテスト実行では何もしない）
+func TestHelperWriterProcess(t *testing.T) {
+	kind := os.Getenv(envWriterKind)
+	if kind == "" {
+		t.Skip("子プロセスとしてのみ実行する")
+	}
+	worker, err := strconv.Atoi(os.Getenv(envWriterWorker))
+	if err != nil {
+		t.Fatalf("ワーカー番号: %v", err)
+	}
+
+	type entryWriter interface {
+		Write(entry *internal.Entry) error
+		Close() error
+	}
+	var w entryWriter
+	switch kind {
+	case "file":
+		fw, err := NewFileWriter(os.Getenv(envWriterPath))
+		if err != nil {
+			t.Fatalf("NewFileWriter: %v", err)
+		}
+		if err := fw.SetMultiProcess(true); err != nil {
+			t.Fatalf("SetMultiProcess: %v", err)
+		}
+		fw.SetSyncPolicy(SyncPolicy{Mode: SyncNever})
+		w = fw
+	case "rotation":
+		rw, err := NewRotationWriter(RotationOptions{
+			Filename:     os.Getenv(envWriterPath),
+			MaxSize:      testRotationMaxSize,
+			Sync:         SyncPolicy{Mode: SyncNever},
+			MultiProcess: true,
+		})
+		if err != nil {
+			t.Fatalf("NewRotationWriter: %v", err)
+		}
+		w = rw
+	default:
+		t.Fatalf("不明な種類: %s", kind)
+	}
+
+	for i := 0; i < testEntriesPerWorker; i++ {
+		err := w.Write(&internal.Entry{
+			ID:        fmt.Sprintf("%d-%d", worker, i),
+			Timestamp: time.Now(),
+			Level:     internal.INFO,
+			Operation: "multi_process",
+			Context:   map[string]interface{}{"worker": worker, "seq": i, "payload": testPayload},
+		})
+		if err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+// runWriterProcesses はテストバイナリをtestProcesses個の子プロセスとして同時に起動し、終了を待つ
+func runWriterProcesses(t *testing.T, kind, path string) {
+	t.Helper()
+
+	cmds := make([]*exec.Cmd, testProcesses)
+	outputs := make([]*strings.Builder, testProcesses)
+	for i := range cmds {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestHelperWriterProcess$", "-test.count=1")
+		cmd.Env = append(os.Environ(),
+			envWriterKind+"="+kind,
+			envWriterPath+"="+path,
+			envWriterWorker+"="+strconv.Itoa(i),
+		)
+		outputs[i] = &strings.Builder{}
+		cmd.Stdout = outputs[i]
+		cmd.Stderr = outputs[i]
+		if err := cmd.Start(); err != nil {
+			t.Fatalf("子プロセスの起動: %v", err)
+		}
+		cmds[i] = cmd
+	}
+	for i, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Fatalf("子プロセス%dが失敗しました: %v\n%s", i, err, outputs[i])
+		}
+	}
+}
+
+// verifyEntries はファイル群のすべての行が1件の完全なエントリであり、
+// 各プロセスのエントリが欠けも重複もなく書き込まれていることを確認する
+func verifyEntries(t *testing.T, files []string) {
+	t.Helper()
+
+	seen := make(map[string]bool)
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatalf("ファイルを開けません: %v", err)
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			var entry struct {
+				ID      string                 `json:"id"`
+				Context map[string]interface{} `json:"context"`
+			}
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				f.Close()
+				t.Fatalf("%s:%d: 書き込みが混ざっています: %v\n%s", filepath.Base(file), line, err, scanner.Bytes())
+			}
+			if entry.Context["payload"] != testPayload {
+				t.Errorf("%s:%d: payloadが壊れています", filepath.Base(file), line)
+			}
+			if seen[entry.ID] {
+				t.Errorf("%s:%d: エントリ%sが重複しています", filepath.Base(file), line, entry.ID)
+			}
+			seen[entry.ID] = true
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: 読み込みエラー: %v", file, err)
+		}
+	}
+
+	if want := testProcesses * testEntriesPerWorker; len(seen) != want {
+		t.Errorf("エントリ数 = %d, want %d", len(seen), want)
+	}
+}
+
+func TestFileWriterMultiProcess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	runWriterProcesses(t, "file", path)
+	verifyEntries(t, []string{path})
+}
+
+func TestRotationWriterMultiProcess(t *testing.T) {
+	dir := t.TempDir()
+	runWriterProcesses(t, "rotation", filepath.Join(dir, "app-{seq}.log"))
+
+	files, err := filepath.Glob(filepath.Join(dir, "app-*.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 合計で約800KBを書き込むため、16KBごとに何度もローテーションしているはず
+	if len(files) < 2 {
+		t.Fatalf("ローテーションされていません: %v", files)
+	}
+	// 他のプロセスが書き込んだ分も含めてサイズを判定するため、どのファイルもMaxSizeを超えない
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > testRotationMaxSize {
+			t.Errorf("%s: サイズ = %d, want <= %d", filepath.Base(file), info.Size(), testRotationMaxSize)
+		}
+	}
+	verifyEntries(t, files)
+}
@@ -0,0 +1,59 @@
+package writer
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// defaultReopenCheckInterval はパスのリネーム・削除を確認するデフォルトの間隔
+const defaultReopenCheckInterval = time.Second
+
+// pathWatcher は開いているファイルのパスがリネーム・削除されたことを一定間隔で検出する
+// logrotateなどの外部ツールがファイルを移動した後も、古いファイルに書き込み続けないようにする
+type pathWatcher struct {
+	interval  time.Duration // 確認の間隔（0の場合は確認しない）
+	lastCheck time.Time
+}
+
+// newPathWatcher はデフォルトの間隔で確認するpathWatcherを作成する
+func newPathWatcher() pathWatcher {
+	return pathWatcher{interval: defaultReopenCheckInterval}
+}
+
+// moved は前回の確認から間隔が経過していれば、開いているファイルとパスのファイルが異なるかどうかを確認する
+// 確認はstat2回で済む
+func (p *pathWatcher) moved(file *os.File, path string) bool {
+	if p.interval <= 0 || file == nil {
+		return false
+	}
+	now := time.Now()
+	if now.Sub(p.lastCheck) < p.interval {
+		return false
+	}
+	p.lastCheck = now
+	return pathMoved(file, path)
+}
+
+// pathMoved は開いているファイルとパスのファイルが異なる（リネーム・削除された）かどうかを判定する
+// ファイルの同一性はinode（Windowsではファイルインデックス）で判定する
+func pathMoved(file *os.File, path string) bool {
+	pathInfo, err := os.Stat(path)
+	if err != nil {
+		// 削除された場合は開き直す（権限などその他のエラーでは開き直しても解決しない）
+		return os.IsNotExist(err)
+	}
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return !os.SameFile(fileInfo, pathInfo)
+}
+
+// openAppend はディレクトリを作成してからファイルを追記モードで開く
+func openAppend(filename string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+}
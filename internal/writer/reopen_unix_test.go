@@ -0,0 +1,149 @@
+//go:build unix
+
+package writer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fileOperations はファイルに書き込まれたエントリの操作名を順に返す
+func fileOperations(t *testing.T, path string) []string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ops []string
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		for _, op := range []string{"before", "after", "moved"} {
+			if strings.Contains(line, `"operation":"`+op+`"`) {
+				ops = append(ops, op)
+			}
+		}
+	}
+	return ops
+}
+
+// assertOpenAt は開いているファイルがpathのファイル（同じinode）であることを確認する
+func assertOpenAt(t *testing.T, file *os.File, path string) {
+	t.Helper()
+	fileInfo, err := file.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pathInfo, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("元のパスにファイルがありません: %v", err)
+	}
+	if !os.SameFile(fileInfo, pathInfo) {
+		t.Errorf("開いているファイルが%sではありません", filepath.Base(path))
+	}
+}
+
+func TestFileWriterReopensMovedPath(t *testing.T) {
+	tests := []struct {
+		name string
+		move func(path string) error
+	}{
+		{"リネーム", func(path string) error { return os.Rename(path, path+".1") }},
+		{"削除", os.Remove},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "app.log")
+			w, err := NewFileWriter(path)
+			if err != nil {
+				t.Fatalf("NewFileWriter: %v", err)
+			}
+			defer w.Close()
+			w.SetReopenCheckInterval(time.Nanosecond)
+
+			if err := w.Write(testEntry("before")); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			if err := tt.move(path); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Write(testEntry("after")); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+
+			assertOpenAt(t, w.file, path)
+			if got := fileOperations(t, path); len(got) != 1 || got[0] != "after" {
+				t.Errorf("元のパスへの書き込み = %v, want [after]", got)
+			}
+		})
+	}
+}
+
+func TestFileWriterExplicitReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := NewFileWriter(path)
+	if err != nil {
+		t.Fatalf("NewFileWriter: %v", err)
+	}
+	defer w.Close()
+	// 確認を無効にすると、Reopenを呼び出すまでは移動後のファイルに書き込み続ける
+	w.SetReopenCheckInterval(0)
+
+	if err := w.Write(testEntry("before")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(testEntry("moved")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("Reopenの前に元のパスが作成されました: %v", err)
+	}
+
+	if err := w.Reopen(); err != nil {
+		t.Fatalf("Reopen: %v", err)
+	}
+	if err := w.Write(testEntry("after")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	assertOpenAt(t, w.file, path)
+	if got := fileOperations(t, path+".1"); len(got) != 2 || got[0] != "before" || got[1] != "moved" {
+		t.Errorf("移動後のファイルへの書き込み = %v, want [before moved]", got)
+	}
+	if got := fileOperations(t, path); len(got) != 1 || got[0] != "after" {
+		t.Errorf("元のパスへの書き込み = %v, want [after]", got)
+	}
+}
+
+func TestRotationWriterReopensMovedPath(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewRotationWriter(RotationOptions{Filename: filepath.Join(dir, "app-{seq}.log"), MaxSize: 1 << 20})
+	if err != nil {
+		t.Fatalf("NewRotationWriter: %v", err)
+	}
+	defer w.Close()
+	w.SetReopenCheckInterval(time.Nanosecond)
+
+	path := w.CurrentFilename()
+	if err := w.Write(testEntry("before")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := os.Rename(path, filepath.Join(dir, "archived.log")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(testEntry("after")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	if got := w.CurrentFilename(); got != path {
+		t.Errorf("書き込み中のファイル = %s, want %s", got, path)
+	}
+	assertOpenAt(t, w.currentFile, path)
+	if got := fileOperations(t, path); len(got) != 1 || got[0] != "after" {
+		t.Errorf("元のパスへの書き込み = %v, want [after]", got)
+	}
+}
@@ -0,0 +1,479 @@
+package writer
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RetentionPolicy はローテーション済みファイルの圧縮と保持の設定
+// ゼロ値の場合は圧縮も削除も行わない
+// 圧縮形式は標準ライブラリで扱えるgzipのみに対応し、zstdなどの他の形式は選択できない
+type RetentionPolicy struct {
+	Compress     bool          // ローテーション済みファイルをバックグラウンドでgzip圧縮する
+	MaxAge       time.Duration // 最終更新からの保持期間（0の場合は無制限）
+	MaxBackups   int           // 保持するローテーション済みファイル数（0の場合は無制限）
+	MaxTotalSize int64         // ローテーション済みファイルの合計サイズの上限（バイト、0の場合は無制限）
+	ErrorHandler func(error)   // バックグラウンドでの圧縮・削除のエラーの通知先（nilの場合は標準エラー出力）
+}
+
+// enabled は圧縮または削除のいずれかが設定されているかどうかを判定する
+func (p RetentionPolicy) enabled() bool {
+	return p.Compress || p.MaxAge > 0 || p.MaxBackups > 0 || p.MaxTotalSize > 0
+}
+
+const (
+	// compressedSuffix は圧縮済みファイルの拡張子
+	compressedSuffix = ".gz"
+	// compressingSuffix は圧縮中の一時ファイルの拡張子
+	compressingSuffix = ".gz.tmp"
+	// maxAgeCheckInterval はMaxAgeによる削除を確認する間隔の上限
+	maxAgeCheckInterval = time.Hour
+)
+
+// backupParser はファイル名（.gzを除く）がローテーション済みファイルであれば、新しいものほど大きい順位を返す
+type backupParser func(name string) (rank int64, ok bool)
+
+// backupFile はローテーション済みファイルの情報
+type backupFile struct {
+	path       string
+	rank       int64
+	size       int64
+	modTime    time.Time
+	compressed bool
+}
+
+// retention はローテーション済みファイルの圧縮と削除をバックグラウンドで行う
+// 処理のたびにディレクトリを走査するため、ローテーションや圧縮の途中でプロセスが終了しても
+// 次回の起動時に未圧縮のファイル・圧縮途中の一時ファイル・重複したファイルが整理される
+// 複数プロセスから書き込む場合、圧縮と削除はロックを取得できた1つのプロセスのみが行う
+type retention struct {
+	policy RetentionPolicy
+	dir    string
+	parse  backupParser
+
+	// fileLock はライターと共有するプロセス間のロック（単一プロセスの場合はnil）
+	// ファイルのリネーム・削除はこのロックを取得してから行う
+	fileLock *processLock
+	// workerLock は圧縮と削除を行うプロセスを1つに限るロック（単一プロセスの場合はnil）
+	workerLock *processLock
+	// keepNewest は最新のローテーション済みファイルを対象外にするかどうか
+	// 他のプロセスがまだ書き込んでいる可能性があるファイルを圧縮・削除しないようにする
+	keepNewest bool
+
+	// mu はローテーション済みファイルのリネームと圧縮結果の置き換えを排他する
+	mu     sync.Mutex
+	active string // 書き込み中のファイル名（圧縮・削除の対象外）
+
+	notifyCh  chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// newRetention はローテーション済みファイルの管理を開始する
+// 開始時に一度ディレクトリを走査し、前回の実行で残ったファイルも整理する
+// fileLockには複数プロセスから書き込む場合にライターが使うロックを渡す（単一プロセスの場合はnil）
+// 書き込み中のファイルがparseで解析できるライターは、複数プロセスの場合にkeepNewestをtrueにする
+func newRetention(policy RetentionPolicy, activePath string, parse backupParser, fileLock *processLock, keepNewest bool) *retention {
+	r := &retention{
+		policy:     policy,
+		dir:        filepath.Dir(activePath),
+		parse:      parse,
+		fileLock:   fileLock,
+		keepNewest: keepNewest,
+		active:     filepath.Base(activePath),
+		notifyCh:   make(chan struct{}, 1),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	if fileLock != nil {
+		workerLock, err := fileLock.sibling(retentionLockSuffix)
+		if err != nil {
+			// ロックファイルを作成できない場合は、他のプロセスと競合しないよう圧縮と削除を行わない
+			r.handleError(err)
+			close(r.done)
+			return r
+		}
+		r.workerLock = workerLock
+	}
+	go r.run()
+	r.notify()
+	return r
+}
+
+// enter はプロセス間のロックとプロセス内のロックを取得する（バックグラウンド処理用）
+// ライターはプロセス間のロックを取得してからlockを呼び出すため、同じ順序で取得する
+func (r *retention) enter() error {
+	if err := r.fileLock.lock(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	return nil
+}
+
+// leave はenterで取得したロックを解放する
+func (r *retention) leave() {
+	r.mu.Unlock()
+	r.fileLock.unlock()
+}
+
+// lock はローテーション済みファイルのリネームを開始する（nilの場合は何もしない）
+// 複数プロセスの場合、ライターはプロセス間のロックを取得した状態で呼び出す
+func (r *retention) lock() {
+	if r != nil {
+		r.mu.Lock()
+	}
+}
+
+// unlock はローテーション済みファイルのリネームを終了する（nilの場合は何もしない）
+func (r *retention) unlock() {
+	if r != nil {
+		r.mu.Unlock()
+	}
+}
+
+// setActive は書き込み中のファイルを変更する（nilの場合は何もしない）
+// 新しいファイルを作成する前に呼び出し、作成途中のファイルが圧縮されないようにする
+func (r *retention) setActive(path string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.active = filepath.Base(path)
+}
+
+// notify はローテーションが行われたことを通知する（nilの場合は何もしない）
+func (r *retention) notify() {
+	if r == nil {
+		return
+	}
+	select {
+	case r.notifyCh <- struct{}{}:
+	default:
+	}
+}
+
+// close はバックグラウンドの処理を停止し、圧縮中のファイルがあれば完了を待つ（nilの場合は何もしない）
+func (r *retention) close() {
+	if r == nil {
+		return
+	}
+	r.closeOnce.Do(func() {
+		close(r.stop)
+		<-r.done
+		_ = r.workerLock.close()
+	})
+}
+
+// run は通知または一定間隔ごとにローテーション済みファイルを処理する
+func (r *retention) run() {
+	defer close(r.done)
+
+	var tick <-chan time.Time
+	if r.policy.MaxAge > 0 {
+		interval := r.policy.MaxAge
+		if interval > maxAgeCheckInterval {
+			interval = maxAgeCheckInterval
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-r.notifyCh:
+		case <-tick:
+		case <-r.stop:
+			return
+		}
+		if err := r.process(); err != nil {
+			r.handleError(err)
+		}
+	}
+}
+
+// handleError はバックグラウンドでのエラーを通知する
+func (r *retention) handleError(err error) {
+	if r.policy.ErrorHandler != nil {
+		r.policy.ErrorHandler(err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Log retention error: %v\n", err)
+}
+
+// stopped は停止が要求されたかどうかを判定する
+func (r *retention) stopped() bool {
+	select {
+	case <-r.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// process は圧縮途中の一時ファイルを削除し、未圧縮のファイルを圧縮してから保持ポリシーを適用する
+// 他のプロセスが処理中の場合は何もしない（そのプロセスが処理する）
+func (r *retention) process() error {
+	ok, err := r.workerLock.tryLock()
+	if err != nil || !ok {
+		return err
+	}
+	defer r.workerLock.unlock()
+
+	var errs []error
+
+	// 一時ファイルはこのゴルーチンでのみ作成されるため、残っているものは中断された圧縮のもの
+	if err := r.removeStaleTemps(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if r.policy.Compress {
+		backups, err := r.list()
+		if err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		for _, b := range backups {
+			if b.compressed {
+				continue
+			}
+			if r.stopped() {
+				return errors.Join(errs...)
+			}
+			if err := r.compress(b.path); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if err := r.cleanup(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// list はローテーション済みファイルを新しい順に返す
+// 圧縮済みと未圧縮のファイルが両方ある場合（置き換えの途中で終了した場合）は未圧縮のファイルを削除する
+func (r *retention) list() ([]backupFile, error) {
+	if err := r.enter(); err != nil {
+		return nil, err
+	}
+	defer r.leave()
+
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backupFile
+	compressed := make(map[string]bool)
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || name == r.active {
+			continue
+		}
+		base, isCompressed := strings.CutSuffix(name, compressedSuffix)
+		rank, ok := r.parse(base)
+		if !ok {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		if isCompressed {
+			compressed[base] = true
+		}
+		backups = append(backups, backupFile{
+			path:       filepath.Join(r.dir, name),
+			rank:       rank,
+			size:       info.Size(),
+			modTime:    info.ModTime(),
+			compressed: isCompressed,
+		})
+	}
+
+	result := backups[:0]
+	for _, b := range backups {
+		if !b.compressed && compressed[filepath.Base(b.path)] {
+			_ = os.Remove(b.path) // 圧縮済みのファイルが完成しているため重複を削除する
+			continue
+		}
+		result = append(result, b)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].rank > result[j].rank
+	})
+	if r.keepNewest && len(result) > 0 && !result[0].compressed {
+		result = result[1:]
+	}
+	return result, nil
+}
+
+// compress はファイルをgzip圧縮し、元のファイルを圧縮済みのファイルに置き換える
+// 圧縮中にファイルがリネームされた場合は、リネーム後の名前で置き換える
+func (r *retention) compress(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer src.Close()
+
+	srcInfo, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + compressingSuffix
+	if err := writeGzip(tmpPath, src, srcInfo); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	if err := r.enter(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	defer r.leave()
+
+	current, found := r.locate(srcInfo)
+	if !found {
+		// 圧縮中に保持数を超えて削除された
+		return os.Remove(tmpPath)
+	}
+	if err := os.Rename(tmpPath, current+compressedSuffix); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return os.Remove(current)
+}
+
+// writeGzip は内容をgzip圧縮してファイルに書き込み、元のファイルの更新時刻を引き継ぐ
+func writeGzip(path string, src io.Reader, srcInfo os.FileInfo) error {
+	dst, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, srcInfo.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	gz.Name = srcInfo.Name()
+	gz.ModTime = srcInfo.ModTime()
+
+	_, err = io.Copy(gz, src)
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = dst.Sync()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	// MaxAgeの判定に使うため、更新時刻は元のファイルのものを保つ
+	return os.Chtimes(path, srcInfo.ModTime(), srcInfo.ModTime())
+}
+
+// locate は未圧縮のローテーション済みファイルから同じファイルを探し、現在のパスを返す
+// enterでロックを取得した状態で呼び出す
+func (r *retention) locate(info os.FileInfo) (string, bool) {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return "", false
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if name == r.active {
+			continue
+		}
+		if _, ok := r.parse(name); !ok {
+			continue
+		}
+		path := filepath.Join(r.dir, name)
+		if candidate, err := os.Stat(path); err == nil && os.SameFile(info, candidate) {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// cleanup は保持ポリシーを超えたローテーション済みファイルを削除する
+func (r *retention) cleanup() error {
+	if r.policy.MaxAge <= 0 && r.policy.MaxBackups <= 0 && r.policy.MaxTotalSize <= 0 {
+		return nil
+	}
+
+	backups, err := r.list()
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-r.policy.MaxAge)
+	var total int64
+	var errs []error
+	for i, b := range backups {
+		total += b.size
+		expired := r.policy.MaxAge > 0 && b.modTime.Before(cutoff)
+		tooMany := r.policy.MaxBackups > 0 && i >= r.policy.MaxBackups
+		tooLarge := r.policy.MaxTotalSize > 0 && total > r.policy.MaxTotalSize
+		if !expired && !tooMany && !tooLarge {
+			continue
+		}
+		if err := r.remove(b.path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// remove はローテーション済みファイルを削除する
+func (r *retention) remove(path string) error {
+	if err := r.enter(); err != nil {
+		return err
+	}
+	defer r.leave()
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// removeStaleTemps は中断された圧縮の一時ファイルを削除する
+func (r *retention) removeStaleTemps() error {
+	entries, err := os.ReadDir(r.dir)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, e := range entries {
+		base, ok := strings.CutSuffix(e.Name(), compressingSuffix)
+		if !ok {
+			continue
+		}
+		if _, ok := r.parse(base); !ok {
+			continue
+		}
+		if err := os.Remove(filepath.Join(r.dir, e.Name())); err != nil && !os.IsNotExist(err) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
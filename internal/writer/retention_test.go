@@ -0,0 +1,249 @@
+package writer
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"vibe-coding-logger/internal"
+)
+
+// newTestRetention は「app.log.番号」のローテーション済みファイルを管理するretentionを作成する
+// バックグラウンドのゴルーチンは開始せず、processを直接呼び出して確認する
+func newTestRetention(dir string, policy RetentionPolicy) *retention {
+	return &retention{
+		policy:   policy,
+		dir:      dir,
+		parse:    rotatingBackupParser("app.log"),
+		active:   "app.log",
+		notifyCh: make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// createFiles はファイル名ごとの内容でファイルを作成する
+func createFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// dirNames はディレクトリ内のファイル名をソートして返す
+func dirNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	slices.Sort(names)
+	return names
+}
+
+// readGzip は圧縮済みファイルを展開した内容を返す
+func readGzip(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("%s: %v", filepath.Base(path), err)
+	}
+	b, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("%s: %v", filepath.Base(path), err)
+	}
+	return string(b)
+}
+
+func TestRetentionCompress(t *testing.T) {
+	dir := t.TempDir()
+	createFiles(t, dir, map[string]string{
+		"app.log":   "active\n",
+		"app.log.1": "newer\n",
+		"app.log.2": "older\n",
+		"other.log": "unrelated\n",
+	})
+	old := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(dir, "app.log.2"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	r := newTestRetention(dir, RetentionPolicy{Compress: true})
+	if err := r.process(); err != nil {
+		t.Fatalf("process: %v", err)
+	}
+
+	// 書き込み中のファイルとローテーション済みファイルでないものは対象外
+	want := []string{"app.log", "app.log.1.gz", "app.log.2.gz", "other.log"}
+	if got := dirNames(t, dir); !slices.Equal(got, want) {
+		t.Fatalf("ファイル = %v, want %v", got, want)
+	}
+	if got := readGzip(t, filepath.Join(dir, "app.log.2.gz")); got != "older\n" {
+		t.Errorf("展開した内容 = %q", got)
+	}
+	// MaxAgeの判定に使うため、更新時刻は元のファイルのものを保つ
+	info, err := os.Stat(filepath.Join(dir, "app.log.2.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(old) {
+		t.Errorf("更新時刻 = %v, want %v", info.ModTime(), old)
+	}
+}
+
+func TestRetentionCleanup(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		policy RetentionPolicy
+		want   []string
+	}{
+		{"MaxBackups", RetentionPolicy{MaxBackups: 2}, []string{"app.log", "app.log.1", "app.log.2.gz"}},
+		{"MaxAge", RetentionPolicy{MaxAge: time.Hour}, []string{"app.log", "app.log.1", "app.log.2.gz", "app.log.3"}},
+		{"MaxTotalSize", RetentionPolicy{MaxTotalSize: 25}, []string{"app.log", "app.log.1", "app.log.2.gz"}},
+		{"組み合わせ", RetentionPolicy{MaxAge: time.Hour, MaxBackups: 1}, []string{"app.log", "app.log.1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			// ローテーション済みファイルは10バイトずつで、app.log.4とapp.log.5は保持期間を過ぎている
+			createFiles(t, dir, map[string]string{
+				"app.log":      "active",
+				"app.log.1":    strings.Repeat("1", 10),
+				"app.log.2.gz": strings.Repeat("2", 10),
+				"app.log.3":    strings.Repeat("3", 10),
+				"app.log.4":    strings.Repeat("4", 10),
+				"app.log.5":    strings.Repeat("5", 10),
+			})
+			for _, name := range []string{"app.log.4", "app.log.5"} {
+				if err := os.Chtimes(filepath.Join(dir, name), now.Add(-2*time.Hour), now.Add(-2*time.Hour)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			r := newTestRetention(dir, tt.policy)
+			if err := r.process(); err != nil {
+				t.Fatalf("process: %v", err)
+			}
+			if got := dirNames(t, dir); !slices.Equal(got, tt.want) {
+				t.Errorf("ファイル = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetentionStartupCleanup(t *testing.T) {
+	dir := t.TempDir()
+	// 前回の実行で、app.log.1の圧縮が中断され、app.log.2は置き換えの途中で終了した
+	createFiles(t, dir, map[string]string{
+		"app.log":          "active\n",
+		"app.log.1":        "newer\n",
+		"app.log.1.gz.tmp": "partial",
+		"app.log.2":        "older\n",
+	})
+	gzPath := filepath.Join(dir, "app.log.2.gz")
+	f, err := os.Create(gzPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	if _, err := gz.Write([]byte("older\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := errors.Join(gz.Close(), f.Close()); err != nil {
+		t.Fatal(err)
+	}
+
+	r := newTestRetention(dir, RetentionPolicy{Compress: true})
+	if err := r.process(); err != nil {
+		t.Fatalf("process: %v", err)
+	}
+
+	// 一時ファイルと重複した未圧縮のファイルは削除され、app.log.1は改めて圧縮される
+	if got := dirNames(t, dir); !slices.Equal(got, []string{"app.log", "app.log.1.gz", "app.log.2.gz"}) {
+		t.Fatalf("ファイル = %v", got)
+	}
+	if got := readGzip(t, filepath.Join(dir, "app.log.1.gz")); got != "newer\n" {
+		t.Errorf("app.log.1の展開した内容 = %q", got)
+	}
+	if got := readGzip(t, gzPath); got != "older\n" {
+		t.Errorf("app.log.2の展開した内容 = %q", got)
+	}
+}
+
+func TestRetentionErrorHandler(t *testing.T) {
+	errs := make(chan error, 1)
+	policy := RetentionPolicy{
+		Compress: true,
+		ErrorHandler: func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		},
+	}
+
+	// 存在しないディレクトリを走査するとエラーになり、標準出力ではなくErrorHandlerに通知される
+	r := newTestRetention(filepath.Join(t.TempDir(), "missing"), policy)
+	go r.run()
+	r.notify()
+	defer r.close()
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("エラー = %v, want ファイルが存在しないエラー", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ErrorHandlerが呼ばれませんでした")
+	}
+}
+
+func TestRotatingFileWriterRetention(t *testing.T) {
+	dir := t.TempDir()
+	entry := &internal.Entry{ID: "1", Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), Level: internal.INFO, Operation: "op"}
+
+	// 1件ごとにローテーションする
+	w, err := NewRotatingFileWriter(filepath.Join(dir, "app.log"), 1, 10)
+	if err != nil {
+		t.Fatalf("NewRotatingFileWriter: %v", err)
+	}
+	defer w.Close()
+	w.SetRetention(RetentionPolicy{Compress: true, MaxBackups: 2})
+
+	for i := 0; i < 5; i++ {
+		if err := w.Write(entry); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	// 圧縮と削除はバックグラウンドで行われる
+	want := []string{"app.log", "app.log.1.gz", "app.log.2.gz"}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got := dirNames(t, dir)
+		if slices.Equal(got, want) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("ファイル = %v, want %v", got, want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
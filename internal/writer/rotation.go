@@ -0,0 +1,649 @@
+package writer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"vibe-coding-logger/internal"
+	"vibe-coding-logger/internal/formatter"
+)
+
+// RotationInterval は時間によるローテーションの間隔
+type RotationInterval int
+
+const (
+	// RotateNever は時間によるローテーションを行わない
+	RotateNever RotationInterval = iota
+	// RotateHourly は毎時0分にローテーションする
+	RotateHourly
+	// RotateDaily は毎日0時にローテーションする
+	RotateDaily
+	// RotateWeekly は毎週月曜日の0時にローテーションする
+	RotateWeekly
+)
+
+// ファイル名テンプレートのプレースホルダー
+const (
+	placeholderDate = "{date}" // 期間の開始日（2006-01-02）
+	placeholderHour = "{hour}" // 期間の開始時（15）
+	placeholderTime = "{time}" // 期間の開始時刻（150405）
+	placeholderSeq  = "{seq}"  // 同じ期間内の連番（1から）
+)
+
+// ErrInvalidRotationTemplate はファイル名テンプレートがローテーションの設定と合わない場合のエラー
+var ErrInvalidRotationTemplate = errors.New("ファイル名テンプレートがローテーションの設定と合いません")
+
+// RotationOptions はRotationWriterの設定
+type RotationOptions struct {
+	// Filename はファイル名のテンプレート（例: logs/app-{date}-{seq}.log）
+	// {date}・{hour}・{time}は期間の開始時刻、{seq}は同じ期間内の連番に置き換えられる
+	// プレースホルダーはファイル名の部分にのみ使用でき、ディレクトリの部分には使用できない
+	Filename string
+	// MaxSize はローテーションするファイルサイズ（バイト、0の場合はサイズによるローテーションを行わない）
+	// 使用する場合はテンプレートに{seq}が必要
+	MaxSize int64
+	// Interval は時間によるローテーションの間隔
+	Interval RotationInterval
+	// Every は一定間隔でのローテーション（0の場合は行わない）
+	// 1日以下の場合はLocationの0時を起点に区切り、1日を超える場合はUNIX時間を起点に区切る
+	// Intervalと併用した場合はいずれかの区切りに達した時点でローテーションする
+	Every time.Duration
+	// Location は期間の区切りとファイル名の時刻に使うタイムゾーン（nilの場合はローカルタイム）
+	Location *time.Location
+	// CurrentLink は書き込み中のファイルを指すシンボリックリンクのパス（空の場合は作成しない）
+	// ローテーションのたびにアトミックに張り替えるため、tail -F で追従できる
+	CurrentLink string
+	// Retention はローテーション済みファイルの圧縮と保持の設定
+	Retention RetentionPolicy
+	// Sync はディスクへの同期の設定（ゼロ値の場合は書き込みのたびに同期する）
+	Sync SyncPolicy
+	// MultiProcess は複数プロセスから同じテンプレートのファイルに書き込むかどうか
+	// 有効にするとアドバイザリロック（flock）を取得してから書き込みとローテーションを行う
+	MultiProcess bool
+}
+
+// RotationWriter はサイズと時間の条件を組み合わせてローテーションするファイルライター
+// ファイル名はテンプレートから作成され、ローテーション済みのファイルはリネームされない
+type RotationWriter struct {
+	opts     RotationOptions
+	dir      string
+	template string
+	pattern  *regexp.Regexp
+	hasSeq   bool
+	hasTime  bool
+
+	currentFile *os.File
+	currentSize int64
+	periodStart time.Time
+	nextRotate  time.Time
+	seq         int
+	formatter   internal.Formatter
+	retention   *retention
+	watcher     pathWatcher
+	syncer      *syncer
+	lock        *processLock // 複数プロセスから書き込む場合のロック（単一プロセスの場合はnil）
+	now         func() time.Time
+	mu          sync.Mutex
+}
+
+// NewRotationWriter は新しいローテーションライターを作成する
+// 起動時点の期間のファイルが既にあれば、その最後のファイルに追記する
+func NewRotationWriter(opts RotationOptions) (*RotationWriter, error) {
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+
+	dir, template := filepath.Split(opts.Filename)
+	if dir == "" {
+		dir = "."
+	}
+	if strings.Contains(dir, "{") {
+		return nil, fmt.Errorf("%w: ディレクトリにプレースホルダーは使用できません: %s", ErrInvalidRotationTemplate, opts.Filename)
+	}
+
+	w := &RotationWriter{
+		opts:     opts,
+		dir:      filepath.Clean(dir),
+		template: template,
+		pattern:  templatePattern(template),
+		hasSeq:   strings.Contains(template, placeholderSeq),
+		hasTime: strings.Contains(template, placeholderDate) ||
+			strings.Contains(template, placeholderHour) ||
+			strings.Contains(template, placeholderTime),
+		watcher: newPathWatcher(),
+		syncer:  newSyncer(opts.Sync),
+		now:     time.Now,
+	}
+
+	if opts.MaxSize > 0 && !w.hasSeq {
+		return nil, fmt.Errorf("%w: サイズによるローテーションには{seq}が必要です: %s", ErrInvalidRotationTemplate, opts.Filename)
+	}
+	if w.timeTriggered() && !w.hasSeq && !w.hasTime {
+		return nil, fmt.Errorf("%w: 時間によるローテーションには{date}・{hour}・{time}・{seq}のいずれかが必要です: %s", ErrInvalidRotationTemplate, opts.Filename)
+	}
+
+	if err := os.MkdirAll(w.dir, 0755); err != nil {
+		return nil, err
+	}
+
+	if opts.MultiProcess {
+		lock, err := newProcessLock(w.lockPath())
+		if err != nil {
+			return nil, err
+		}
+		w.lock = lock
+	}
+
+	if err := w.openInitial(); err != nil {
+		_ = w.lock.close()
+		return nil, err
+	}
+
+	if opts.Retention.enabled() {
+		w.retention = newRetention(opts.Retention, w.currentFile.Name(), w.parseBackup, w.lock, true)
+	}
+	w.syncer.start("RotationWriter", w.syncIfDirty)
+	return w, nil
+}
+
+// openInitial は起動時点の期間の最後のファイルを開く
+func (w *RotationWriter) openInitial() error {
+	if err := w.lock.lock(); err != nil {
+		return err
+	}
+	defer w.lock.unlock()
+
+	w.periodStart, w.nextRotate = w.period(w.now())
+	if err := w.openFile(w.latestSeq(), true); err != nil {
+		return err
+	}
+	if err := w.updateLink(); err != nil {
+		w.currentFile.Close()
+		return err
+	}
+	return nil
+}
+
+// lockPath はロックファイルのパスを返す
+// テンプレートからパスを作るため、同じテンプレートに書き込むプロセスは同じロックファイルを使う
+func (w *RotationWriter) lockPath() string {
+	return filepath.Join(w.dir, w.template+lockSuffix)
+}
+
+// timeTriggered は時間によるローテーションが設定されているかどうかを判定する
+func (w *RotationWriter) timeTriggered() bool {
+	return w.opts.Interval != RotateNever || w.opts.Every > 0
+}
+
+// Write はエントリを書き込み、必要であれば書き込む前にローテーションする
+func (w *RotationWriter) Write(entry *internal.Entry) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.formatter == nil {
+		w.formatter = formatter.NewJSONFormatter()
+	}
+
+	formatted, err := w.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+
+	// 複数プロセスの場合はロックを取得してから、ローテーションの判定と書き込みを行う
+	if err := w.lock.lock(); err != nil {
+		return err
+	}
+	defer w.lock.unlock()
+
+	if err := w.follow(); err != nil {
+		return err
+	}
+
+	now := w.now()
+	switch {
+	case w.timeTriggered() && !now.Before(w.nextRotate):
+		if err := w.rotate(now, true); err != nil {
+			return err
+		}
+	case w.opts.MaxSize > 0 && w.currentSize > 0 && w.currentSize+int64(len(formatted)) > w.opts.MaxSize:
+		if err := w.rotate(now, false); err != nil {
+			return err
+		}
+	}
+
+	n, err := w.currentFile.Write(formatted)
+	if err != nil {
+		return err
+	}
+
+	w.currentSize += int64(n)
+	return w.syncer.afterWrite(w.currentFile, 1, entry.Level)
+}
+
+// follow はファイルが外部でリネーム・削除されていれば開き直す
+// 複数プロセスの場合は他のプロセスが作成した新しい連番のファイルに切り替え、サイズを更新する
+func (w *RotationWriter) follow() error {
+	if w.lock == nil {
+		if w.watcher.moved(w.currentFile, w.currentFile.Name()) {
+			return w.reopen()
+		}
+		return nil
+	}
+
+	// 他のプロセスがローテーションしていれば、次の連番のファイルが存在する
+	if (w.hasSeq && w.seqExists(w.seq+1)) || pathMoved(w.currentFile, w.currentFile.Name()) {
+		w.seq = max(w.seq, w.latestSeq())
+		return w.reopen()
+	}
+	stat, err := w.currentFile.Stat()
+	if err != nil {
+		return err
+	}
+	w.currentSize = stat.Size()
+	return nil
+}
+
+// rotate は現在のファイルを閉じて次のファイルを開く
+// 期間が変わった場合は連番を1に戻し、同じ期間内では連番を進める
+func (w *RotationWriter) rotate(now time.Time, periodChanged bool) error {
+	if w.currentFile != nil {
+		_ = w.syncer.syncIfDirty(w.currentFile) // エラーは無視（ローテーション時のベストエフォート）
+		w.currentFile.Close()
+	}
+
+	seq := w.seq + 1
+	if periodChanged {
+		w.periodStart, w.nextRotate = w.period(now)
+		seq = 1
+	} else if !w.timeTriggered() {
+		// サイズのみでローテーションする場合、ファイル名の日時が変わったら連番を1に戻す
+		start, _ := w.period(now)
+		if w.render(start, 0) != w.render(w.periodStart, 0) {
+			seq = 1
+		}
+		w.periodStart = start
+	}
+	appendExisting := false
+	if w.lock != nil && seq == 1 {
+		// 他のプロセスが既に新しい期間のファイルを作成していれば、その最後のファイルに追記する
+		seq, appendExisting = w.latestSeq(), true
+	}
+	if err := w.openFile(seq, appendExisting); err != nil {
+		return err
+	}
+
+	_ = w.updateLink() // エラーは無視（ローテーション時のベストエフォート）
+	w.retention.notify()
+	return nil
+}
+
+// Reopen は現在のファイルを閉じて同じパスで開き直す
+func (w *RotationWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.reopen()
+}
+
+// reopen は現在のファイルを開き直す（内部用）
+func (w *RotationWriter) reopen() error {
+	if w.currentFile != nil {
+		_ = w.syncer.syncIfDirty(w.currentFile) // エラーは無視（移動済みのファイルへのベストエフォート）
+		w.currentFile.Close()
+	}
+	if err := w.openFile(w.seq, true); err != nil {
+		return err
+	}
+	_ = w.updateLink() // エラーは無視（ベストエフォート）
+	return nil
+}
+
+// SetReopenCheckInterval はパスのリネーム・削除を確認する間隔を設定する（0の場合は確認しない、デフォルトは1秒）
+func (w *RotationWriter) SetReopenCheckInterval(interval time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.watcher.interval = interval
+}
+
+// openFile は連番seqのファイルを開く
+// appendExistingがfalseの場合、{seq}を含むテンプレートでは既存のファイルを飛ばして次の連番を使う
+func (w *RotationWriter) openFile(seq int, appendExisting bool) error {
+	for {
+		name := w.filename(seq)
+		info, err := os.Stat(name)
+		exists := err == nil
+		full := exists && w.opts.MaxSize > 0 && info.Size() >= w.opts.MaxSize
+		if !exists && w.hasSeq && w.compressedExists(seq) {
+			// 圧縮済みの連番は再利用しない（同じ名前の未圧縮ファイルは重複として削除される）
+			exists, full = true, true
+		}
+		if exists && w.hasSeq && (!appendExisting || full) {
+			seq++
+			appendExisting = false
+			continue
+		}
+
+		// 作成したファイルがローテーション済みとして圧縮されないよう、先に書き込み中のファイルとして登録する
+		w.retention.setActive(name)
+
+		file, err := openAppend(name)
+		if err != nil {
+			return err
+		}
+		stat, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return err
+		}
+
+		w.currentFile = file
+		w.currentSize = stat.Size()
+		w.seq = seq
+		return nil
+	}
+}
+
+// latestSeq は現在の期間で既に存在するファイルの最大の連番を返す（存在しない場合は1）
+// 保持期間の経過などで途中の連番が削除されていても最大の連番を見つけるため、ディレクトリを走査する
+// 圧縮済みのファイルも存在するものとして数える
+func (w *RotationWriter) latestSeq() int {
+	if !w.hasSeq {
+		return 1
+	}
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return 1
+	}
+
+	latest := 1
+	for _, entry := range entries {
+		if seq, ok := w.currentPeriodSeq(strings.TrimSuffix(entry.Name(), compressedSuffix)); ok && seq > latest {
+			latest = seq
+		}
+	}
+	return latest
+}
+
+// currentPeriodSeq はファイル名が現在の期間のファイルであれば、その連番を返す
+func (w *RotationWriter) currentPeriodSeq(name string) (int, bool) {
+	match := w.pattern.FindStringSubmatch(name)
+	if match == nil {
+		return 0, false
+	}
+	seq, err := strconv.Atoi(match[w.pattern.SubexpIndex("seq")])
+	if err != nil {
+		return 0, false
+	}
+	// 日時のプレースホルダーが異なる他の期間のファイルは対象外とする
+	return seq, w.render(w.periodStart, seq) == name
+}
+
+// seqExists は連番seqのファイルまたはその圧縮済みファイルが存在するかどうかを判定する
+func (w *RotationWriter) seqExists(seq int) bool {
+	if _, err := os.Stat(w.filename(seq)); err == nil {
+		return true
+	}
+	return w.compressedExists(seq)
+}
+
+// compressedExists は連番seqのファイルの圧縮済みファイルが存在するかどうかを判定する
+func (w *RotationWriter) compressedExists(seq int) bool {
+	_, err := os.Stat(w.filename(seq) + compressedSuffix)
+	return err == nil
+}
+
+// filename は現在の期間と連番からファイル名を作成する
+// 時間によるローテーションがない場合、期間の開始時刻はファイルを作成した時刻になる
+func (w *RotationWriter) filename(seq int) string {
+	return filepath.Join(w.dir, w.render(w.periodStart, seq))
+}
+
+// render はテンプレートのプレースホルダーを時刻tと連番seqで置き換える
+func (w *RotationWriter) render(t time.Time, seq int) string {
+	return strings.NewReplacer(
+		placeholderDate, t.Format("2006-01-02"),
+		placeholderHour, t.Format("15"),
+		placeholderTime, t.Format("150405"),
+		placeholderSeq, strconv.Itoa(seq),
+	).Replace(w.template)
+}
+
+// period は時刻tを含む期間の開始時刻と次のローテーション時刻を返す
+// 時間によるローテーションがない場合、次のローテーション時刻はゼロ値になる
+func (w *RotationWriter) period(t time.Time) (start, next time.Time) {
+	t = t.In(w.opts.Location)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, w.opts.Location)
+
+	merge := func(s, n time.Time) {
+		if start.IsZero() || s.After(start) {
+			start = s
+		}
+		if next.IsZero() || n.Before(next) {
+			next = n
+		}
+	}
+
+	switch w.opts.Interval {
+	case RotateHourly:
+		s := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, w.opts.Location)
+		merge(s, s.Add(time.Hour))
+	case RotateDaily:
+		merge(midnight, midnight.AddDate(0, 0, 1))
+	case RotateWeekly:
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		s := midnight.AddDate(0, 0, -daysSinceMonday)
+		merge(s, s.AddDate(0, 0, 7))
+	}
+
+	if every := w.opts.Every; every > 0 {
+		if every <= 24*time.Hour {
+			s := midnight.Add(t.Sub(midnight) / every * every)
+			n := s.Add(every)
+			if nextMidnight := midnight.AddDate(0, 0, 1); n.After(nextMidnight) {
+				n = nextMidnight
+			}
+			merge(s, n)
+		} else {
+			s := t.Truncate(every).In(w.opts.Location)
+			merge(s, s.Add(every))
+		}
+	}
+
+	if start.IsZero() {
+		start = t
+	}
+	return start, next
+}
+
+// updateLink はCurrentLinkを書き込み中のファイルに張り替える
+// 一時的なリンクを作成してからリネームするため、リンクが存在しない瞬間はない
+func (w *RotationWriter) updateLink() error {
+	if w.opts.CurrentLink == "" {
+		return nil
+	}
+
+	target := w.currentFile.Name()
+	linkDir := filepath.Dir(w.opts.CurrentLink)
+	if rel, err := filepath.Rel(linkDir, target); err == nil {
+		target = rel
+	}
+
+	tmp := w.opts.CurrentLink + ".tmp"
+	_ = os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, w.opts.CurrentLink); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// templatePattern はテンプレートから作成されたファイル名に一致する正規表現を作成する
+func templatePattern(template string) *regexp.Regexp {
+	placeholders := regexp.MustCompile(`\{(date|hour|time|seq)\}`)
+
+	var b strings.Builder
+	b.WriteString("^")
+	last := 0
+	for _, loc := range placeholders.FindAllStringSubmatchIndex(template, -1) {
+		b.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		switch template[loc[2]:loc[3]] {
+		case "date":
+			b.WriteString(`(?P<date>\d{4}-\d{2}-\d{2})`)
+		case "hour":
+			b.WriteString(`(?P<hour>\d{2})`)
+		case "time":
+			b.WriteString(`(?P<time>\d{6})`)
+		case "seq":
+			b.WriteString(`(?P<seq>\d+)`)
+		}
+		last = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(template[last:]))
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// parseBackup はテンプレートから作成されたファイル名を、期間の開始時刻と連番が新しいほど大きい順位に変換する
+func (w *RotationWriter) parseBackup(name string) (int64, bool) {
+	match := w.pattern.FindStringSubmatch(name)
+	if match == nil {
+		return 0, false
+	}
+
+	var layout, value []string
+	var seq int64
+	for i, group := range w.pattern.SubexpNames() {
+		switch group {
+		case "date":
+			layout, value = append(layout, "2006-01-02"), append(value, match[i])
+		case "hour":
+			layout, value = append(layout, "15"), append(value, match[i])
+		case "time":
+			layout, value = append(layout, "150405"), append(value, match[i])
+		case "seq":
+			n, err := strconv.ParseInt(match[i], 10, 64)
+			if err != nil {
+				return 0, false
+			}
+			seq = n
+		}
+	}
+
+	var t time.Time
+	if len(layout) > 0 {
+		parsed, err := time.ParseInLocation(strings.Join(layout, "|"), strings.Join(value, "|"), w.opts.Location)
+		if err != nil {
+			return 0, false
+		}
+		t = parsed
+	}
+	return t.Unix()*1_000_000 + seq, true
+}
+
+// CurrentFilename は書き込み中のファイルのパスを返す
+func (w *RotationWriter) CurrentFilename() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.currentFile.Name()
+}
+
+// SetRetention はローテーション済みファイルの圧縮と保持の設定を変更する
+// 設定時に既存のローテーション済みファイルにも適用される
+func (w *RotationWriter) SetRetention(policy RetentionPolicy) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.retention.close()
+	w.retention = nil
+	w.opts.Retention = policy
+	if policy.enabled() {
+		w.retention = newRetention(policy, w.currentFile.Name(), w.parseBackup, w.lock, true)
+	}
+}
+
+// SetMultiProcess は複数プロセスから同じテンプレートのファイルに書き込むモードを設定する
+// 有効にするとテンプレートと同じディレクトリの「テンプレート.lock」のアドバイザリロック（flock）を取得してから
+// 書き込みとローテーションを行い、他のプロセスがローテーションした場合は新しいファイルに追従する
+// 圧縮と削除はロックを取得できた1つのプロセスのみが行う
+func (w *RotationWriter) SetMultiProcess(enabled bool) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// 古いロックを使っているバックグラウンドの処理を止めてからロックを切り替える
+	w.retention.close()
+	w.retention = nil
+
+	var err error
+	w.lock, err = switchLock(w.lock, enabled, w.lockPath())
+	w.opts.MultiProcess = w.lock != nil
+	if w.opts.Retention.enabled() {
+		w.retention = newRetention(w.opts.Retention, w.currentFile.Name(), w.parseBackup, w.lock, true)
+	}
+	return err
+}
+
+// SetSyncPolicy はディスクへの同期の設定を行う
+func (w *RotationWriter) SetSyncPolicy(policy SyncPolicy) {
+	w.mu.Lock()
+	old := w.syncer
+	w.opts.Sync = policy
+	w.syncer = newSyncer(policy)
+	w.syncer.unsynced = old.unsynced
+	w.syncer.start("RotationWriter", w.syncIfDirty)
+	w.mu.Unlock()
+
+	old.halt()
+}
+
+// syncIfDirty は未同期のエントリがあればディスクに同期する
+func (w *RotationWriter) syncIfDirty() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.syncer.syncIfDirty(w.currentFile)
+}
+
+// SetFormatter はフォーマッターを設定する
+func (w *RotationWriter) SetFormatter(formatter internal.Formatter) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.formatter = formatter
+}
+
+// Flush は現在のファイルの内容をディスクに同期する
+func (w *RotationWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.syncer.sync(w.currentFile)
+}
+
+// Close は未同期の内容をディスクに同期してからファイルを閉じる
+// 圧縮中のファイルがあれば完了を待ち、未圧縮のファイルは次回の起動時に圧縮される
+func (w *RotationWriter) Close() error {
+	w.mu.Lock()
+	s := w.syncer
+	w.mu.Unlock()
+	s.halt()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.retention.close()
+	_ = w.lock.close()
+	if w.currentFile != nil {
+		syncErr := w.syncer.syncIfDirty(w.currentFile)
+		if err := w.currentFile.Close(); err != nil {
+			return err
+		}
+		return syncErr
+	}
+	return nil
+}
@@ -0,0 +1,352 @@
+package writer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+	"vibe-coding-logger/internal"
+	"vibe-coding-logger/internal/formatter"
+)
+
+// jst はローカルタイムと異なるタイムゾーンでの期間の区切りを確認するためのタイムゾーン
+var jst = time.FixedZone("JST", 9*60*60)
+
+// testEntry は書き込み用のエントリを作成する（同じ操作名であれば出力の長さは同じ）
+func testEntry(op string) *internal.Entry {
+	return &internal.Entry{ID: op, Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), Level: internal.INFO, Operation: op}
+}
+
+// newTestRotationWriter は一時ディレクトリにローテーションライターを作成する
+// 同期はテストの速度のため無効にする
+func newTestRotationWriter(t *testing.T, opts RotationOptions) *RotationWriter {
+	t.Helper()
+	if !filepath.IsAbs(opts.Filename) {
+		opts.Filename = filepath.Join(t.TempDir(), opts.Filename)
+	}
+	if opts.Sync == (SyncPolicy{}) {
+		opts.Sync = SyncPolicy{Mode: SyncNever}
+	}
+	w, err := NewRotationWriter(opts)
+	if err != nil {
+		t.Fatalf("NewRotationWriter: %v", err)
+	}
+	t.Cleanup(func() { w.Close() })
+	return w
+}
+
+// writeAt は現在時刻をnowとしてエントリを書き込み、書き込んだファイル名を返す
+func writeAt(t *testing.T, w *RotationWriter, now time.Time) string {
+	t.Helper()
+	w.mu.Lock()
+	w.now = func() time.Time { return now }
+	w.mu.Unlock()
+	if err := w.Write(testEntry("op")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	return filepath.Base(w.CurrentFilename())
+}
+
+// listFiles はディレクトリ内のファイル名をソートして返す（ロックファイルを除く）
+func listFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), lockSuffix) {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestRotationWriterSize(t *testing.T) {
+	dir := t.TempDir()
+	line, err := formatter.NewJSONFormatter().Format(testEntry("op"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	maxSize := int64(2*len(line) + 1)
+	w := newTestRotationWriter(t, RotationOptions{Filename: filepath.Join(dir, "app-{seq}.log"), MaxSize: maxSize})
+
+	for i := 0; i < 5; i++ {
+		if err := w.Write(testEntry("op")); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	// 2件ごとにローテーションし、ローテーション済みのファイルはリネームされない
+	if got := listFiles(t, dir); !equalStrings(got, []string{"app-1.log", "app-2.log", "app-3.log"}) {
+		t.Fatalf("ファイル = %v", got)
+	}
+	for _, name := range []string{"app-1.log", "app-2.log"} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > maxSize {
+			t.Errorf("%s: サイズ = %d, want <= %d", name, info.Size(), maxSize)
+		}
+	}
+	if got := filepath.Base(w.CurrentFilename()); got != "app-3.log" {
+		t.Errorf("書き込み中のファイル = %s, want app-3.log", got)
+	}
+}
+
+func TestRotationWriterIntervals(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		interval RotationInterval
+		before   time.Time
+		after    time.Time
+		want     [2]string
+	}{
+		{
+			// UTCでは01:59:59だが、JSTの10時台から11時台への切り替わり
+			name:     "毎時",
+			template: "app-{date}-{hour}.log",
+			interval: RotateHourly,
+			before:   time.Date(2030, 1, 1, 1, 59, 59, 0, time.UTC),
+			after:    time.Date(2030, 1, 1, 2, 0, 0, 0, time.UTC),
+			want:     [2]string{"app-2030-01-01-10.log", "app-2030-01-01-11.log"},
+		},
+		{
+			name:     "毎日",
+			template: "app-{date}.log",
+			interval: RotateDaily,
+			before:   time.Date(2030, 1, 1, 23, 59, 59, 0, jst),
+			after:    time.Date(2030, 1, 2, 0, 0, 0, 0, jst),
+			want:     [2]string{"app-2030-01-01.log", "app-2030-01-02.log"},
+		},
+		{
+			// 2030-01-06は日曜日（UTCでは同じ週の日曜日15時）
+			name:     "毎週",
+			template: "app-{date}.log",
+			interval: RotateWeekly,
+			before:   time.Date(2030, 1, 6, 23, 59, 59, 0, jst),
+			after:    time.Date(2030, 1, 7, 0, 0, 0, 0, jst),
+			want:     [2]string{"app-2029-12-31.log", "app-2030-01-07.log"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := newTestRotationWriter(t, RotationOptions{Filename: tt.template, Interval: tt.interval, Location: jst})
+
+			if got := writeAt(t, w, tt.before); got != tt.want[0] {
+				t.Errorf("区切りの前のファイル = %s, want %s", got, tt.want[0])
+			}
+			if got := writeAt(t, w, tt.after); got != tt.want[1] {
+				t.Errorf("区切りの後のファイル = %s, want %s", got, tt.want[1])
+			}
+		})
+	}
+}
+
+func TestRotationWriterPeriod(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      RotationOptions
+		t         time.Time
+		wantStart time.Time
+		wantNext  time.Time
+	}{
+		{
+			name:      "毎時",
+			opts:      RotationOptions{Interval: RotateHourly},
+			t:         time.Date(2030, 1, 1, 10, 30, 0, 0, jst),
+			wantStart: time.Date(2030, 1, 1, 10, 0, 0, 0, jst),
+			wantNext:  time.Date(2030, 1, 1, 11, 0, 0, 0, jst),
+		},
+		{
+			name:      "毎週（月曜日の0時）",
+			opts:      RotationOptions{Interval: RotateWeekly},
+			t:         time.Date(2030, 1, 1, 10, 30, 0, 0, jst),
+			wantStart: time.Date(2029, 12, 31, 0, 0, 0, 0, jst),
+			wantNext:  time.Date(2030, 1, 7, 0, 0, 0, 0, jst),
+		},
+		{
+			// 0時を起点に5時間ごとに区切り、最後の区切りは0時で打ち切る
+			name:      "Everyが0時をまたぐ",
+			opts:      RotationOptions{Every: 5 * time.Hour},
+			t:         time.Date(2030, 1, 1, 23, 30, 0, 0, jst),
+			wantStart: time.Date(2030, 1, 1, 20, 0, 0, 0, jst),
+			wantNext:  time.Date(2030, 1, 2, 0, 0, 0, 0, jst),
+		},
+		{
+			name:      "Everyの0時直後",
+			opts:      RotationOptions{Every: 5 * time.Hour},
+			t:         time.Date(2030, 1, 2, 0, 0, 0, 0, jst),
+			wantStart: time.Date(2030, 1, 2, 0, 0, 0, 0, jst),
+			wantNext:  time.Date(2030, 1, 2, 5, 0, 0, 0, jst),
+		},
+		{
+			name:      "EveryとDailyの併用は早い方の区切り",
+			opts:      RotationOptions{Interval: RotateDaily, Every: 10 * time.Hour},
+			t:         time.Date(2030, 1, 1, 12, 0, 0, 0, jst),
+			wantStart: time.Date(2030, 1, 1, 10, 0, 0, 0, jst),
+			wantNext:  time.Date(2030, 1, 1, 20, 0, 0, 0, jst),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Location = jst
+			w := &RotationWriter{opts: tt.opts}
+			start, next := w.period(tt.t)
+			if !start.Equal(tt.wantStart) || !next.Equal(tt.wantNext) {
+				t.Errorf("period = (%v, %v), want (%v, %v)", start, next, tt.wantStart, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestRotationWriterEveryAtMidnight(t *testing.T) {
+	w := newTestRotationWriter(t, RotationOptions{Filename: "app-{date}-{time}.log", Every: 5 * time.Hour, Location: jst})
+
+	names := []string{
+		writeAt(t, w, time.Date(2030, 1, 1, 23, 30, 0, 0, jst)),
+		writeAt(t, w, time.Date(2030, 1, 1, 23, 59, 59, 0, jst)),
+		writeAt(t, w, time.Date(2030, 1, 2, 0, 0, 0, 0, jst)),
+	}
+	want := []string{"app-2030-01-01-200000.log", "app-2030-01-01-200000.log", "app-2030-01-02-000000.log"}
+	if !equalStrings(names, want) {
+		t.Errorf("ファイル = %v, want %v", names, want)
+	}
+}
+
+func TestRotationWriterTemplateValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		opts RotationOptions
+	}{
+		{"ディレクトリのプレースホルダー", RotationOptions{Filename: "{date}/app.log"}},
+		{"サイズに{seq}がない", RotationOptions{Filename: "app-{date}.log", MaxSize: 1024}},
+		{"時間にプレースホルダーがない", RotationOptions{Filename: "app.log", Interval: RotateDaily}},
+		{"Everyにプレースホルダーがない", RotationOptions{Filename: "app.log", Every: time.Hour}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.Filename = filepath.Join(t.TempDir(), tt.opts.Filename)
+			w, err := NewRotationWriter(tt.opts)
+			if err == nil {
+				w.Close()
+			}
+			if !errors.Is(err, ErrInvalidRotationTemplate) {
+				t.Errorf("エラー = %v, want ErrInvalidRotationTemplate", err)
+			}
+		})
+	}
+}
+
+func TestRotationWriterCurrentLink(t *testing.T) {
+	dir := t.TempDir()
+	link := filepath.Join(dir, "current.log")
+	w := newTestRotationWriter(t, RotationOptions{
+		Filename:    filepath.Join(dir, "app-{date}.log"),
+		Interval:    RotateDaily,
+		Location:    jst,
+		CurrentLink: link,
+	})
+
+	for _, now := range []time.Time{
+		time.Date(2030, 1, 1, 12, 0, 0, 0, jst),
+		time.Date(2030, 1, 2, 12, 0, 0, 0, jst),
+	} {
+		name := writeAt(t, w, now)
+		// リンクは書き込み中のファイルを相対パスで指す
+		target, err := os.Readlink(link)
+		if err != nil {
+			t.Fatalf("Readlink: %v", err)
+		}
+		if target != name {
+			t.Errorf("リンク先 = %s, want %s", target, name)
+		}
+	}
+	// 張り替えに使った一時的なリンクは残らない
+	if _, err := os.Lstat(link + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("一時的なリンクが残っています: %v", err)
+	}
+}
+
+func TestRotationWriterResumesHighestSeq(t *testing.T) {
+	dir := t.TempDir()
+	// 途中の連番が削除され、最後のファイルは圧縮済み
+	for _, name := range []string{"app-3.log", "app-7.log.gz", "other-9.log"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := newTestRotationWriter(t, RotationOptions{Filename: filepath.Join(dir, "app-{seq}.log"), MaxSize: 1 << 20})
+	if got := filepath.Base(w.CurrentFilename()); got != "app-8.log" {
+		t.Errorf("書き込み中のファイル = %s, want app-8.log", got)
+	}
+}
+
+func TestRotationWriterLatestSeqIgnoresOtherPeriods(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"app-2030-01-01-5.log", "app-2030-01-02-2.log"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := newTestRotationWriter(t, RotationOptions{Filename: filepath.Join(dir, "app-{date}-{seq}.log"), Interval: RotateDaily, Location: jst})
+	w.mu.Lock()
+	w.periodStart = time.Date(2030, 1, 2, 0, 0, 0, 0, jst)
+	got := w.latestSeq()
+	w.mu.Unlock()
+	if got != 2 {
+		t.Errorf("latestSeq = %d, want 2", got)
+	}
+}
+
+func TestRotationWriterMultiProcessFollowsWithGaps(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"app-1.log", "app-3.log"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w := newTestRotationWriter(t, RotationOptions{Filename: filepath.Join(dir, "app-{seq}.log"), MaxSize: 1 << 20, MultiProcess: true})
+
+	// 途中の連番が欠けていても、最大の連番のファイルに書き込み続ける
+	for i := 0; i < 2; i++ {
+		if err := w.Write(testEntry("op")); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		if got := filepath.Base(w.CurrentFilename()); got != "app-3.log" {
+			t.Fatalf("書き込み中のファイル = %s, want app-3.log", got)
+		}
+	}
+
+	// 他のプロセスがローテーションして作成したファイルに追従する
+	if err := os.WriteFile(filepath.Join(dir, "app-4.log"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(testEntry("op")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got := filepath.Base(w.CurrentFilename()); got != "app-4.log" {
+		t.Errorf("書き込み中のファイル = %s, want app-4.log", got)
+	}
+}
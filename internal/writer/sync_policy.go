@@ -0,0 +1,189 @@
+package writer
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+	"vibe-coding-logger/internal"
+)
+
+// SyncMode はファイルの内容をディスクに同期（fsync）するタイミング
+type SyncMode int
+
+const (
+	// SyncAlways はエントリを書き込むたびに同期する（デフォルト）
+	SyncAlways SyncMode = iota
+	// SyncEveryN はEveryN件のエントリを書き込むたびに同期する
+	SyncEveryN
+	// SyncInterval はバックグラウンドでInterval間隔ごとに同期する（書き込みがなかった場合は同期しない）
+	SyncInterval
+	// SyncOnError はERROR以上のエントリを書き込んだときのみ同期する
+	SyncOnError
+	// SyncNever は同期せず、OSに任せる（FlushやCloseの呼び出し時は同期する）
+	SyncNever
+)
+
+// String はSyncModeを文字列に変換する
+func (m SyncMode) String() string {
+	switch m {
+	case SyncAlways:
+		return "ALWAYS"
+	case SyncEveryN:
+		return "EVERY_N"
+	case SyncInterval:
+		return "INTERVAL"
+	case SyncOnError:
+		return "ON_ERROR"
+	case SyncNever:
+		return "NEVER"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// SyncPolicy はファイルライターの同期の設定
+// ゼロ値は書き込みのたびに同期する
+type SyncPolicy struct {
+	Mode     SyncMode      // 同期するタイミング
+	EveryN   int           // SyncEveryNで同期するエントリ数（0の場合は100）
+	Interval time.Duration // SyncIntervalで同期する間隔（0の場合は1秒）
+}
+
+// syncer はSyncPolicyに従ってファイルを同期する
+// 呼び出し側のライターのロックを保持した状態で使う
+type syncer struct {
+	policy   SyncPolicy
+	unsynced int // 最後の同期以降に書き込んだエントリ数
+
+	stop     chan struct{}
+	done     chan struct{}
+	haltOnce sync.Once
+}
+
+// newSyncer はデフォルト値を補ったSyncPolicyのsyncerを作成する
+func newSyncer(policy SyncPolicy) *syncer {
+	if policy.EveryN <= 0 {
+		policy.EveryN = 100
+	}
+	if policy.Interval <= 0 {
+		policy.Interval = time.Second
+	}
+	return &syncer{policy: policy}
+}
+
+// afterWrite はn件のエントリ（最も高いレベルはlevel）を書き込んだ後、ポリシーに従って同期する
+func (s *syncer) afterWrite(file *os.File, n int, level internal.LogLevel) error {
+	s.unsynced += n
+
+	switch s.policy.Mode {
+	case SyncAlways:
+		return s.sync(file)
+	case SyncEveryN:
+		if s.unsynced >= s.policy.EveryN {
+			return s.sync(file)
+		}
+	case SyncOnError:
+		if level >= internal.ERROR {
+			return s.sync(file)
+		}
+	}
+	return nil
+}
+
+// sync はファイルを同期する
+func (s *syncer) sync(file *os.File) error {
+	s.unsynced = 0
+	if file == nil {
+		return nil
+	}
+	return file.Sync()
+}
+
+// syncIfDirty は未同期のエントリがあれば同期する（SyncIntervalのバックグラウンド処理用）
+func (s *syncer) syncIfDirty(file *os.File) error {
+	if s.unsynced == 0 {
+		return nil
+	}
+	return s.sync(file)
+}
+
+// start はSyncIntervalの場合にバックグラウンドでの同期を開始する
+// syncFnはライターのロックを取得してsyncIfDirtyを呼び出す関数
+func (s *syncer) start(name string, syncFn func() error) {
+	if s.policy.Mode != SyncInterval {
+		return
+	}
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+
+		ticker := time.NewTicker(s.policy.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := syncFn(); err != nil {
+					fmt.Printf("%s sync error: %v\n", name, err)
+				}
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// halt はバックグラウンドでの同期を停止する（複数回呼び出しても安全）
+// syncFnがライターのロックを取得するため、ライターのロックを保持していない状態で呼び出す
+func (s *syncer) halt() {
+	s.haltOnce.Do(func() {
+		if s.stop != nil {
+			close(s.stop)
+			<-s.done
+		}
+	})
+}
+
+// latencyTimer はバッファに入った最初のエントリから一定時間後にバッファを出力するタイマー
+type latencyTimer struct {
+	mu      sync.Mutex
+	latency time.Duration // 最大の遅延（0の場合はタイマーを使わない）
+	timer   *time.Timer
+}
+
+// arm はタイマーが動いていなければ開始する
+func (t *latencyTimer) arm(flush func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.latency <= 0 || t.timer != nil {
+		return
+	}
+	t.timer = time.AfterFunc(t.latency, func() {
+		t.mu.Lock()
+		t.timer = nil
+		t.mu.Unlock()
+		flush()
+	})
+}
+
+// disarm はタイマーを止める
+func (t *latencyTimer) disarm() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+}
+
+// setLatency は最大の遅延を設定する
+func (t *latencyTimer) setLatency(latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.latency = latency
+}
@@ -0,0 +1,221 @@
+package writer
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+	"vibe-coding-logger/internal"
+	"vibe-coding-logger/internal/formatter"
+)
+
+// unsyncedCount は最後の同期以降に書き込んだエントリ数を返す
+func unsyncedCount(w *FileWriter) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.syncer.unsynced
+}
+
+// levelEntry はレベルを指定したエントリを作成する
+func levelEntry(level internal.LogLevel) *internal.Entry {
+	entry := testEntry("op")
+	entry.Level = level
+	return entry
+}
+
+func TestFileWriterSyncPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy SyncPolicy
+		levels []internal.LogLevel
+		want   []int // 各エントリを書き込んだ後の未同期のエントリ数
+	}{
+		{"SyncAlways", SyncPolicy{}, []internal.LogLevel{internal.INFO, internal.INFO}, []int{0, 0}},
+		{"SyncEveryN", SyncPolicy{Mode: SyncEveryN, EveryN: 3}, []internal.LogLevel{internal.INFO, internal.INFO, internal.INFO, internal.INFO}, []int{1, 2, 0, 1}},
+		{"SyncOnError", SyncPolicy{Mode: SyncOnError}, []internal.LogLevel{internal.INFO, internal.WARN, internal.ERROR, internal.INFO}, []int{1, 2, 0, 1}},
+		{"SyncNever", SyncPolicy{Mode: SyncNever}, []internal.LogLevel{internal.ERROR, internal.FATAL}, []int{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := NewFileWriter(filepath.Join(t.TempDir(), "app.log"))
+			if err != nil {
+				t.Fatalf("NewFileWriter: %v", err)
+			}
+			defer w.Close()
+			w.SetSyncPolicy(tt.policy)
+
+			for i, level := range tt.levels {
+				if err := w.Write(levelEntry(level)); err != nil {
+					t.Fatalf("Write: %v", err)
+				}
+				if got := unsyncedCount(w); got != tt.want[i] {
+					t.Errorf("%d件目（%s）の後の未同期のエントリ数 = %d, want %d", i+1, level, got, tt.want[i])
+				}
+			}
+
+			// Flushはポリシーに関わらず同期する
+			if err := w.Flush(); err != nil {
+				t.Fatalf("Flush: %v", err)
+			}
+			if got := unsyncedCount(w); got != 0 {
+				t.Errorf("Flush後の未同期のエントリ数 = %d, want 0", got)
+			}
+		})
+	}
+}
+
+func TestFileWriterSyncInterval(t *testing.T) {
+	w, err := NewFileWriter(filepath.Join(t.TempDir(), "app.log"))
+	if err != nil {
+		t.Fatalf("NewFileWriter: %v", err)
+	}
+	defer w.Close()
+	w.SetSyncPolicy(SyncPolicy{Mode: SyncInterval, Interval: 10 * time.Millisecond})
+
+	for i := 0; i < 3; i++ {
+		if err := w.Write(testEntry("op")); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	// 書き込み時には同期せず、バックグラウンドで同期する
+	deadline := time.Now().Add(5 * time.Second)
+	for unsyncedCount(w) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Intervalが経過しても同期されませんでした")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Closeでバックグラウンドの同期を停止する
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	select {
+	case <-w.syncer.done:
+	default:
+		t.Error("Close後もバックグラウンドの同期が動いています")
+	}
+}
+
+func TestBufferedFileWriterSyncOnError(t *testing.T) {
+	w, err := NewBufferedFileWriter(filepath.Join(t.TempDir(), "app.log"), 2)
+	if err != nil {
+		t.Fatalf("NewBufferedFileWriter: %v", err)
+	}
+	defer w.Close()
+	w.SetSyncPolicy(SyncPolicy{Mode: SyncOnError})
+
+	// バッファの出力はバッファ内の最も高いレベルで判定する
+	for _, level := range []internal.LogLevel{internal.INFO, internal.INFO} {
+		if err := w.Write(levelEntry(level)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if got := unsyncedCount(w.FileWriter); got != 2 {
+		t.Errorf("INFOのみのバッファを出力した後の未同期のエントリ数 = %d, want 2", got)
+	}
+	for _, level := range []internal.LogLevel{internal.ERROR, internal.INFO} {
+		if err := w.Write(levelEntry(level)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if got := unsyncedCount(w.FileWriter); got != 0 {
+		t.Errorf("ERRORを含むバッファを出力した後の未同期のエントリ数 = %d, want 0", got)
+	}
+}
+
+// fileSize はファイルのサイズを返す
+func fileSize(t *testing.T, path string) int64 {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Size()
+}
+
+func TestBufferedFileWriterDefaultMaxLatency(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := NewBufferedFileWriter(path, 100)
+	if err != nil {
+		t.Fatalf("NewBufferedFileWriter: %v", err)
+	}
+	defer w.Close()
+
+	start := time.Now()
+	if err := w.Write(testEntry("op")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got := fileSize(t, path); got != 0 {
+		t.Fatalf("バッファが満杯になる前に出力されました: %dバイト", got)
+	}
+
+	// バッファが満杯にならなくても、DefaultMaxLatencyが経過すると出力される
+	deadline := start.Add(DefaultMaxLatency + 5*time.Second)
+	for fileSize(t, path) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("DefaultMaxLatencyが経過しても出力されませんでした")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if elapsed := time.Since(start); elapsed < DefaultMaxLatency {
+		t.Errorf("%vで出力されました, want %v以上", elapsed, DefaultMaxLatency)
+	}
+}
+
+func TestBufferedFileWriterNegativeMaxLatency(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	w, err := NewBufferedFileWriter(path, 100)
+	if err != nil {
+		t.Fatalf("NewBufferedFileWriter: %v", err)
+	}
+	defer w.Close()
+	w.SetMaxLatency(-1)
+
+	if err := w.Write(testEntry("op")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	// 負の値の場合はタイマーを使わず、バッファが満杯になるかFlushを呼び出すまで出力しない
+	w.timer.mu.Lock()
+	armed := w.timer.timer != nil
+	w.timer.mu.Unlock()
+	if armed {
+		t.Error("MaxLatencyが負の値でもタイマーが開始されました")
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got := fileSize(t, path); got == 0 {
+		t.Error("Flushで出力されませんでした")
+	}
+}
+
+func TestBufferedFileWriterSetFormatterConcurrent(t *testing.T) {
+	w, err := NewBufferedFileWriter(filepath.Join(t.TempDir(), "app.log"), 10)
+	if err != nil {
+		t.Fatalf("NewBufferedFileWriter: %v", err)
+	}
+	defer w.Close()
+
+	// -raceで実行すると、WriteとSetFormatterが同じロックでフォーマッターを扱うことを確認できる
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			if err := w.Write(testEntry("op")); err != nil {
+				t.Errorf("Write: %v", err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			w.SetFormatter(formatter.NewJSONFormatter())
+		}
+	}()
+	wg.Wait()
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"time"
 	"vibe-coding-logger/internal"
 	"vibe-coding-logger/internal/formatter"
 	"vibe-coding-logger/internal/writer"
@@ -45,17 +46,67 @@ func convertToInternalErrorInfo(errorInfo *ErrorInfo) *internal.ErrorInfo {
 	}
 }
 
-// NewConsoleWriter は新しいコンソールライターを作成します
-func NewConsoleWriter() Writer {
-	return &consoleWriterImpl{
-		internalWriter: writer.NewConsoleWriter(),
+// convertFromInternalEntry はinternal.Entryをpkg/loggerのEntryに変換します
+func convertFromInternalEntry(entry *internal.Entry) *Entry {
+	return &Entry{
+		ID:          entry.ID,
+		Timestamp:   entry.Timestamp,
+		Level:       LogLevel(entry.Level),
+		Action:      ActionType(entry.Action),
+		Operation:   entry.Operation,
+		Input:       entry.Input,
+		Output:      entry.Output,
+		Error:       convertFromInternalErrorInfo(entry.Error),
+		Duration:    entry.Duration,
+		Context:     entry.Context,
+		Tags:        entry.Tags,
+		TraceID:     entry.TraceID,
+		SpanID:      entry.SpanID,
+		ParentID:    entry.ParentID,
+		Metadata:    entry.Metadata,
+		SystemInfo:  entry.SystemInfo,
+		RuntimeInfo: entry.RuntimeInfo,
 	}
 }
 
-// NewTextFormatter は新しいテキストフォーマッターを作成します
-func NewTextFormatter() Formatter {
-	return &formatterAdapter{
-		internalFormatter: formatter.NewTextFormatter(),
+// convertFromInternalErrorInfo はinternal.ErrorInfoをpkg/loggerのErrorInfoに変換します
+func convertFromInternalErrorInfo(errorInfo *internal.ErrorInfo) *ErrorInfo {
+	if errorInfo == nil {
+		return nil
+	}
+	return &ErrorInfo{
+		Message:    errorInfo.Message,
+		Type:       errorInfo.Type,
+		Code:       errorInfo.Code,
+		Stack:      errorInfo.Stack,
+		Retryable:  errorInfo.Retryable,
+		Resolution: errorInfo.Resolution,
+		Context:    errorInfo.Context,
+	}
+}
+
+const (
+	defaultRotatingMaxSize  = 10 * 1024 * 1024 // RotatingFileOptions.MaxSizeのデフォルト値
+	defaultRotatingMaxFiles = 5                // RotatingFileOptions.MaxFilesのデフォルト値
+	defaultBufferSize       = 100              // BufferedFileOptions.BufferSizeのデフォルト値
+)
+
+// toInternalFormatter はLogger.Formatterをinternal.Formatterに変換します
+// formatterAdapter以外のユーザー定義フォーマッターはpublicFormatterAdapterで包みます
+func toInternalFormatter(f Formatter) internal.Formatter {
+	if f == nil {
+		return nil
+	}
+	if fa, ok := f.(*formatterAdapter); ok {
+		return fa.internalFormatter
+	}
+	return &publicFormatterAdapter{formatter: f}
+}
+
+// NewConsoleWriter は新しいコンソールライターを作成します
+func NewConsoleWriter() Writer {
+	return &writerAdapter{
+		internalWriter: writer.NewConsoleWriter(),
 	}
 }
 
@@ -65,56 +116,421 @@ func NewFileWriter(filename string) (Writer, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &fileWriterImpl{
+	return &writerAdapter{
 		internalWriter: internalWriter,
 	}, nil
 }
 
-// consoleWriterImpl はinternal/writerを使用したWriter実装
-type consoleWriterImpl struct {
-	internalWriter internal.Writer
+// SyncMode はファイルの内容をディスクに同期（fsync）するタイミングです
+type SyncMode int
+
+const (
+	SyncAlways   SyncMode = iota // エントリを書き込むたびに同期する（デフォルト）
+	SyncEveryN                   // EveryN件のエントリを書き込むたびに同期する
+	SyncInterval                 // バックグラウンドでInterval間隔ごとに同期する
+	SyncOnError                  // ERROR以上のエントリを書き込んだときのみ同期する
+	SyncNever                    // FlushやCloseの呼び出し時のみ同期する
+)
+
+// SyncPolicy はファイルライターのディスクへの同期の設定です
+// ゼロ値の場合は書き込みのたびに同期します（負荷の高い環境ではSyncIntervalなどを検討してください）
+type SyncPolicy struct {
+	Mode     SyncMode      // 同期するタイミング
+	EveryN   int           // SyncEveryNで同期するエントリ数（0の場合は100）
+	Interval time.Duration // SyncIntervalで同期する間隔（0の場合は1秒）
 }
 
-func (cw *consoleWriterImpl) Write(entry *Entry) error {
-	internalEntry := convertToInternalEntry(entry)
-	return cw.internalWriter.Write(internalEntry)
+// toInternal は内部ライターの同期の設定に変換します
+func (p SyncPolicy) toInternal() writer.SyncPolicy {
+	return writer.SyncPolicy{
+		Mode:     writer.SyncMode(p.Mode),
+		EveryN:   p.EveryN,
+		Interval: p.Interval,
+	}
 }
 
-func (cw *consoleWriterImpl) Close() error {
-	return cw.internalWriter.Close()
+// FileOptions はファイルライターの設定です
+type FileOptions struct {
+	Filename     string     // 出力先のファイル名
+	Sync         SyncPolicy // ディスクへの同期の設定
+	MultiProcess bool       // 複数プロセスから同じファイルに書き込む（flockで書き込みを排他する、flockのない環境ではプロセス内の排他のみ）
 }
 
-// formatterAdapter はinternal.FormatterをLogger.Formatterにアダプトします
-type formatterAdapter struct {
-	internalFormatter internal.Formatter
+// NewFileWriterWithOptions は設定を指定してファイルライターを作成します
+func NewFileWriterWithOptions(opts FileOptions) (Writer, error) {
+	internalWriter, err := writer.NewFileWriter(opts.Filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := internalWriter.SetMultiProcess(opts.MultiProcess); err != nil {
+		internalWriter.Close()
+		return nil, err
+	}
+	internalWriter.SetSyncPolicy(opts.Sync.toInternal())
+	return &writerAdapter{
+		internalWriter: internalWriter,
+	}, nil
 }
 
-func (fa *formatterAdapter) Format(entry *Entry) ([]byte, error) {
-	internalEntry := convertToInternalEntry(entry)
-	return fa.internalFormatter.Format(internalEntry)
+// RetentionOptions はローテーション済みファイルの圧縮と保持の設定です
+// ゼロ値の場合は圧縮も削除も行いません
+// 圧縮形式はgzipのみに対応しています（zstdなどの他の形式は選択できません）
+type RetentionOptions struct {
+	Compress     bool          // ローテーション済みファイルをバックグラウンドでgzip圧縮する（拡張子.gz）
+	MaxAge       time.Duration // 最終更新からの保持期間（0の場合は無制限）
+	MaxBackups   int           // 保持するローテーション済みファイル数（0の場合は無制限）
+	MaxTotalSize int64         // ローテーション済みファイルの合計サイズの上限（バイト、0の場合は無制限）
+	ErrorHandler func(error)   // バックグラウンドでの圧縮・削除のエラーの通知先（nilの場合は標準エラー出力）
 }
 
-// fileWriterImpl はinternal/writerを使用したFileWriter実装
-type fileWriterImpl struct {
-	internalWriter *writer.FileWriter
+// toPolicy は内部ライターの保持ポリシーに変換します
+func (o RetentionOptions) toPolicy() writer.RetentionPolicy {
+	return writer.RetentionPolicy{
+		Compress:     o.Compress,
+		MaxAge:       o.MaxAge,
+		MaxBackups:   o.MaxBackups,
+		MaxTotalSize: o.MaxTotalSize,
+		ErrorHandler: o.ErrorHandler,
+	}
 }
 
-func (fw *fileWriterImpl) Write(entry *Entry) error {
+// RotatingFileOptions はサイズベースでローテーションするファイルライターの設定です
+type RotatingFileOptions struct {
+	Filename  string           // 出力先のファイル名
+	MaxSize   int64            // ローテーションするファイルサイズ（バイト、0の場合は10MB）
+	MaxFiles  int              // 保持する世代数（0の場合は5）
+	Retention RetentionOptions // ローテーション済みファイルの圧縮と保持の設定
+	Sync      SyncPolicy       // ディスクへの同期の設定
+	// MultiProcess は複数プロセスから同じファイルに書き込むかどうかです
+	// 有効にするとflockで書き込みとローテーションを排他し、圧縮と削除は1つのプロセスのみが行います
+	MultiProcess bool
+}
+
+// NewRotatingFileWriter はサイズベースでローテーションするファイルライターを作成します
+func NewRotatingFileWriter(opts RotatingFileOptions) (Writer, error) {
+	maxSize := opts.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultRotatingMaxSize
+	}
+	maxFiles := opts.MaxFiles
+	if maxFiles <= 0 {
+		maxFiles = defaultRotatingMaxFiles
+	}
+
+	internalWriter, err := writer.NewRotatingFileWriter(opts.Filename, maxSize, maxFiles)
+	if err != nil {
+		return nil, err
+	}
+	if err := internalWriter.SetMultiProcess(opts.MultiProcess); err != nil {
+		internalWriter.Close()
+		return nil, err
+	}
+	internalWriter.SetRetention(opts.Retention.toPolicy())
+	internalWriter.SetSyncPolicy(opts.Sync.toInternal())
+	return &writerAdapter{
+		internalWriter: internalWriter,
+	}, nil
+}
+
+// DailyRotatingFileOptions は日付ベースでローテーションするファイルライターの設定です
+type DailyRotatingFileOptions struct {
+	Filename  string           // ベースファイル名（実際のファイル名には日付が付与されます）
+	Retention RetentionOptions // 前日までのファイルの圧縮と保持の設定
+	Sync      SyncPolicy       // ディスクへの同期の設定
+	// MultiProcess は複数プロセスから同じファイルに書き込むかどうかです
+	// 有効にするとflockで書き込みとローテーションを排他し、圧縮と削除は1つのプロセスのみが行います
+	MultiProcess bool
+}
+
+// NewDailyRotatingFileWriter は日付ベースでローテーションするファイルライターを作成します
+func NewDailyRotatingFileWriter(opts DailyRotatingFileOptions) (Writer, error) {
+	internalWriter, err := writer.NewDailyRotatingFileWriter(opts.Filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := internalWriter.SetMultiProcess(opts.MultiProcess); err != nil {
+		internalWriter.Close()
+		return nil, err
+	}
+	internalWriter.SetRetention(opts.Retention.toPolicy())
+	internalWriter.SetSyncPolicy(opts.Sync.toInternal())
+	return &writerAdapter{
+		internalWriter: internalWriter,
+	}, nil
+}
+
+// RotationInterval は時間によるローテーションの間隔です
+type RotationInterval int
+
+const (
+	RotateNever  RotationInterval = iota // 時間によるローテーションを行わない
+	RotateHourly                         // 毎時0分にローテーションする
+	RotateDaily                          // 毎日0時にローテーションする
+	RotateWeekly                         // 毎週月曜日の0時にローテーションする
+)
+
+// ErrInvalidRotationTemplate はファイル名テンプレートがローテーションの設定と合わない場合のエラーです
+var ErrInvalidRotationTemplate = writer.ErrInvalidRotationTemplate
+
+// RotationFileOptions はサイズと時間の条件を組み合わせてローテーションするファイルライターの設定です
+type RotationFileOptions struct {
+	// Filename はファイル名のテンプレートです（例: logs/app-{date}-{seq}.log）
+	// {date}（2006-01-02）・{hour}（15）・{time}（150405）は期間の開始時刻、{seq}は同じ期間内の連番（1から）に置き換えられます
+	Filename string
+	// MaxSize はローテーションするファイルサイズです（バイト、0の場合はサイズによるローテーションを行わない、使用する場合は{seq}が必要）
+	MaxSize int64
+	// Interval は時間によるローテーションの間隔です
+	Interval RotationInterval
+	// Every は一定間隔でのローテーションです（0の場合は行わない、1日以下の場合はLocationの0時を起点に区切る）
+	Every time.Duration
+	// Location は期間の区切りとファイル名の時刻に使うタイムゾーンです（nilの場合はローカルタイム）
+	Location *time.Location
+	// CurrentLink は書き込み中のファイルを指すシンボリックリンクのパスです（空の場合は作成しない）
+	CurrentLink string
+	// Retention はローテーション済みファイルの圧縮と保持の設定です
+	Retention RetentionOptions
+	// Sync はディスクへの同期の設定です
+	Sync SyncPolicy
+	// MultiProcess は複数プロセスから同じテンプレートのファイルに書き込むかどうかです
+	// 有効にするとflockで書き込みとローテーションを排他し、圧縮と削除は1つのプロセスのみが行います
+	// ロックファイルはテンプレートと同じディレクトリに「テンプレート.lock」として作成されます
+	MultiProcess bool
+}
+
+// NewRotationFileWriter はサイズ・毎時・毎日・毎週・一定間隔の条件を組み合わせてローテーションするファイルライターを作成します
+// いずれかの条件を満たした時点でテンプレートから作成した新しいファイルに切り替えます
+func NewRotationFileWriter(opts RotationFileOptions) (Writer, error) {
+	internalWriter, err := writer.NewRotationWriter(writer.RotationOptions{
+		Filename:     opts.Filename,
+		MaxSize:      opts.MaxSize,
+		Interval:     writer.RotationInterval(opts.Interval),
+		Every:        opts.Every,
+		Location:     opts.Location,
+		CurrentLink:  opts.CurrentLink,
+		Retention:    opts.Retention.toPolicy(),
+		Sync:         opts.Sync.toInternal(),
+		MultiProcess: opts.MultiProcess,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &writerAdapter{
+		internalWriter: internalWriter,
+	}, nil
+}
+
+// BufferedFileOptions はバッファリングされたファイルライターの設定です
+type BufferedFileOptions struct {
+	Filename   string        // 出力先のファイル名
+	BufferSize int           // バッファに保持するエントリ数（0の場合は100）
+	MaxLatency time.Duration // バッファに入ったエントリを出力するまでの最大の遅延（0の場合は1秒、負の値の場合はバッファが満杯になるまで出力しない）
+	Sync       SyncPolicy    // バッファを出力したときのディスクへの同期の設定
+	// MultiProcess は複数プロセスから同じファイルに書き込むかどうかです（有効にするとflockでバッファの書き込みを排他します）
+	MultiProcess bool
+}
+
+// NewBufferedFileWriter はバッファリングされたファイルライターを作成します
+// バッファ内のエントリは、バッファが満杯になるか、MaxLatencyが経過するか、FlushまたはCloseを呼び出すまでファイルに書き込まれません
+func NewBufferedFileWriter(opts BufferedFileOptions) (Writer, error) {
+	bufferSize := opts.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = defaultBufferSize
+	}
+
+	internalWriter, err := writer.NewBufferedFileWriter(opts.Filename, bufferSize)
+	if err != nil {
+		return nil, err
+	}
+	if err := internalWriter.SetMultiProcess(opts.MultiProcess); err != nil {
+		internalWriter.Close()
+		return nil, err
+	}
+	if opts.MaxLatency != 0 {
+		internalWriter.SetMaxLatency(opts.MaxLatency)
+	}
+	internalWriter.SetSyncPolicy(opts.Sync.toInternal())
+	return &writerAdapter{
+		internalWriter: internalWriter,
+	}, nil
+}
+
+// VibeFileOptions はバイブコーディング専用ファイルライターの設定です
+type VibeFileOptions struct {
+	BaseFilename  string     // ベースファイル名（セッションIDと問題ドメインが付与されます）
+	SessionID     string     // セッションの一意識別子
+	ProblemDomain string     // 問題領域
+	Sync          SyncPolicy // ディスクへの同期の設定
+	MultiProcess  bool       // 同じセッションのファイルに複数プロセスから書き込む（flockで書き込みを排他する、flockのない環境ではプロセス内の排他のみ）
+}
+
+// NewVibeFileWriter はバイブコーディング専用のファイルライターを作成します
+// 常にバイブJSONフォーマッターを使用し、Logger.SetFormatterの影響を受けません
+func NewVibeFileWriter(opts VibeFileOptions) (Writer, error) {
+	internalWriter, err := writer.NewVibeFileWriter(opts.BaseFilename, opts.SessionID, opts.ProblemDomain)
+	if err != nil {
+		return nil, err
+	}
+	if err := internalWriter.SetMultiProcess(opts.MultiProcess); err != nil {
+		internalWriter.Close()
+		return nil, err
+	}
+	internalWriter.SetSyncPolicy(opts.Sync.toInternal())
+	return &fixedFormatterWriter{Writer: &writerAdapter{
+		internalWriter: internalWriter,
+	}}, nil
+}
+
+// NewTextFormatter は新しいテキストフォーマッターを作成します
+func NewTextFormatter() Formatter {
+	return &formatterAdapter{
+		internalFormatter: formatter.NewTextFormatter(),
+	}
+}
+
+// NewConsoleFormatter は新しいコンソールフォーマッターを作成します
+func NewConsoleFormatter() Formatter {
+	return &formatterAdapter{
+		internalFormatter: formatter.NewConsoleFormatter(),
+	}
+}
+
+// NewCompactTextFormatter は新しいコンパクトテキストフォーマッターを作成します
+func NewCompactTextFormatter() Formatter {
+	return &formatterAdapter{
+		internalFormatter: formatter.NewCompactTextFormatter(),
+	}
+}
+
+// NewVibeTextFormatter は新しいバイブテキストフォーマッターを作成します
+func NewVibeTextFormatter() Formatter {
+	return &formatterAdapter{
+		internalFormatter: formatter.NewVibeTextFormatter(),
+	}
+}
+
+// NewJSONFormatter は新しいJSONフォーマッターを作成します
+func NewJSONFormatter() Formatter {
+	return &formatterAdapter{
+		internalFormatter: formatter.NewJSONFormatter(),
+	}
+}
+
+// NewPrettyJSONFormatter は整形されたJSONフォーマッターを作成します
+func NewPrettyJSONFormatter() Formatter {
+	return &formatterAdapter{
+		internalFormatter: formatter.NewPrettyJSONFormatter(),
+	}
+}
+
+// NewCompactJSONFormatter は新しいコンパクトJSONフォーマッターを作成します
+func NewCompactJSONFormatter() Formatter {
+	return &formatterAdapter{
+		internalFormatter: formatter.NewCompactJSONFormatter(),
+	}
+}
+
+// NewStructuredJSONFormatter は新しい構造化JSONフォーマッターを作成します
+func NewStructuredJSONFormatter() Formatter {
+	return &formatterAdapter{
+		internalFormatter: formatter.NewStructuredJSONFormatter(),
+	}
+}
+
+// NewVibeJSONFormatter は新しいバイブJSONフォーマッターを作成します
+func NewVibeJSONFormatter() Formatter {
+	return &formatterAdapter{
+		internalFormatter: formatter.NewVibeJSONFormatter(),
+	}
+}
+
+// WithFormatter はロガーのフォーマッターに関わらず指定したフォーマッターを使うWriterを返します
+// 返されるWriterはFormattableWriterを実装しないため、Logger.SetFormatterの影響を受けません
+func WithFormatter(w FormattableWriter, f Formatter) Writer {
+	w.SetFormatter(f)
+	return &fixedFormatterWriter{Writer: w}
+}
+
+// internalFormattableWriter はフォーマッターを設定可能な内部ライター
+type internalFormattableWriter interface {
+	internal.Writer
+	SetFormatter(f internal.Formatter)
+}
+
+// writerAdapter はinternal/writerのライターをLogger.Writerにアダプトします
+type writerAdapter struct {
+	internalWriter internalFormattableWriter
+}
+
+func (wa *writerAdapter) Write(entry *Entry) error {
 	internalEntry := convertToInternalEntry(entry)
-	return fw.internalWriter.Write(internalEntry)
+	return wa.internalWriter.Write(internalEntry)
 }
 
-func (fw *fileWriterImpl) Close() error {
-	return fw.internalWriter.Close()
+func (wa *writerAdapter) Close() error {
+	return wa.internalWriter.Close()
+}
+
+// SetFormatter は内部ライターにフォーマッターを設定します
+func (wa *writerAdapter) SetFormatter(formatter Formatter) {
+	if f := toInternalFormatter(formatter); f != nil {
+		wa.internalWriter.SetFormatter(f)
+	}
 }
 
-func (fw *fileWriterImpl) SetFormatter(formatter Formatter) {
-	if fa, ok := formatter.(*formatterAdapter); ok {
-		fw.internalWriter.SetFormatter(fa.internalFormatter)
+// Flush は内部ライターがバッファを持つ場合に内容を出力します
+func (wa *writerAdapter) Flush() error {
+	if f, ok := wa.internalWriter.(interface{ Flush() error }); ok {
+		return f.Flush()
 	}
+	return nil
+}
+
+// Reopen は内部ライターがファイルを開き直せる場合に開き直します
+func (wa *writerAdapter) Reopen() error {
+	if r, ok := wa.internalWriter.(interface{ Reopen() error }); ok {
+		return r.Reopen()
+	}
+	return nil
+}
+
+// fixedFormatterWriter はフォーマッターを固定したWriter
+type fixedFormatterWriter struct {
+	Writer
+}
+
+// Flush は内包するWriterがFlushableWriterであれば内容を出力します
+func (fw *fixedFormatterWriter) Flush() error {
+	if f, ok := fw.Writer.(FlushableWriter); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Reopen は内包するWriterがReopenableWriterであればファイルを開き直します
+func (fw *fixedFormatterWriter) Reopen() error {
+	return reopenWriter(fw.Writer)
+}
+
+// formatterAdapter はinternal.FormatterをLogger.Formatterにアダプトします
+type formatterAdapter struct {
+	internalFormatter internal.Formatter
+}
+
+func (fa *formatterAdapter) Format(entry *Entry) ([]byte, error) {
+	internalEntry := convertToInternalEntry(entry)
+	return fa.internalFormatter.Format(internalEntry)
 }
 
 // GetInternalFormatter は内部フォーマッターを取得します
 func (fa *formatterAdapter) GetInternalFormatter() internal.Formatter {
 	return fa.internalFormatter
 }
+
+// publicFormatterAdapter はLogger.Formatterをinternal.Formatterにアダプトします
+type publicFormatterAdapter struct {
+	formatter Formatter
+}
+
+func (pa *publicFormatterAdapter) Format(entry *internal.Entry) ([]byte, error) {
+	return pa.formatter.Format(convertFromInternalEntry(entry))
+}
@@ -0,0 +1,35 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewVibeFileWriterKeepsVibeFormatter(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "vibe")
+	w, err := NewVibeFileWriter(VibeFileOptions{BaseFilename: base, SessionID: "session", ProblemDomain: "auth"})
+	if err != nil {
+		t.Fatalf("NewVibeFileWriter: %v", err)
+	}
+
+	// Newはテキストフォーマッターを設定するが、バイブ専用のファイルライターには適用されない
+	l := New(DEBUG)
+	l.SetFormatter(NewTextFormatter())
+	l.AddWriter(w)
+	l.Info("login")
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	b, err := os.ReadFile(base + "_session_auth.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimSpace(b), &entry); err != nil {
+		t.Fatalf("バイブJSONフォーマッターで出力されていません: %v\n%s", err, b)
+	}
+}
@@ -0,0 +1,410 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrAsyncWriterClosed は閉じられたAsyncWriterへの書き込み時に返されるエラー
+var ErrAsyncWriterClosed = errors.New("非同期ライターは既に閉じられています")
+
+// ErrAsyncWriterCloseTimeout はCloseの待機時間内にキューを処理しきれなかった場合に返されるエラー
+var ErrAsyncWriterCloseTimeout = errors.New("非同期ライターのキューを時間内に処理できませんでした")
+
+// OverflowPolicy はキューが満杯になった場合の挙動を表す
+type OverflowPolicy int
+
+const (
+	// OverflowBlock はキューに空きができるまで呼び出し元をブロックする
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest は新しいエントリを破棄する
+	OverflowDropNewest
+	// OverflowDropOldest はキュー内の最も古いエントリを破棄して新しいエントリを追加する
+	OverflowDropOldest
+	// OverflowSample は溢れたエントリのうちSampleRate件に1件だけをブロックして追加し、残りを破棄する
+	OverflowSample
+)
+
+// String はOverflowPolicyを文字列に変換する
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowBlock:
+		return "BLOCK"
+	case OverflowDropNewest:
+		return "DROP_NEWEST"
+	case OverflowDropOldest:
+		return "DROP_OLDEST"
+	case OverflowSample:
+		return "SAMPLE"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// AsyncWriterOptions はAsyncWriterの設定です
+type AsyncWriterOptions struct {
+	QueueSize      int            // キューの容量（0の場合は1024）
+	Workers        int            // 書き込みを行うゴルーチン数（0の場合は1、2以上では順序は保証されません）
+	OverflowPolicy OverflowPolicy // キューが満杯になった場合の挙動
+	SampleRate     int            // OverflowSample時に何件に1件を残すか（0の場合は10）
+	CloseTimeout   time.Duration  // Close時にキューを処理する最大待機時間（0の場合は5秒）
+	ErrorHandler   func(error)    // バックグラウンドでの書き込みエラーの通知先（nilの場合は標準出力）
+}
+
+// AsyncWriterStats はAsyncWriterの統計情報です
+type AsyncWriterStats struct {
+	Enqueued uint64 // キューに追加されたエントリ数
+	Written  uint64 // 書き込みに成功したエントリ数
+	Failed   uint64 // 書き込みに失敗したエントリ数
+	Dropped  uint64 // 溢れて破棄されたエントリ数
+	Queued   int    // 現在キューに残っているエントリ数
+}
+
+// AsyncWriter は任意のWriterをラップし、バックグラウンドのゴルーチンで書き込みを行う
+// 呼び出し元はキューへの追加のみを行うため、遅いディスクなどの影響を受けません
+//
+// エントリはキューに追加する前にコピーされるため、呼び出し元はログ出力後に
+// Context・Input・Outputなどに渡したマップを変更しても構いません
+type AsyncWriter struct {
+	writer  Writer
+	opts    AsyncWriterOptions
+	queue   chan asyncItem
+	closing chan struct{}
+	abort   chan struct{} // CloseTimeoutの経過後に閉じられ、キューに残ったエントリを破棄させる
+	workers sync.WaitGroup
+
+	// closed はCloseの開始後にtrueとなる（muで保護）
+	closed bool
+	mu     sync.RWMutex
+
+	// pending は世代ごとの未処理エントリ数（pendingMuで保護）
+	// Flushは世代を進め、呼び出し前の世代のエントリがすべて処理されるまで待機する
+	epoch       uint64
+	pending     map[uint64]int64
+	pendingMu   sync.Mutex
+	pendingCond *sync.Cond
+
+	enqueued atomic.Uint64
+	written  atomic.Uint64
+	failed   atomic.Uint64
+	dropped  atomic.Uint64
+	overflow atomic.Uint64
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// asyncItem はキューに追加されたエントリとその世代
+type asyncItem struct {
+	entry *Entry
+	epoch uint64
+}
+
+// NewAsyncWriter は新しい非同期ライターを作成し、バックグラウンドのゴルーチンを開始する
+func NewAsyncWriter(writer Writer, opts AsyncWriterOptions) *AsyncWriter {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1024
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+	if opts.SampleRate <= 0 {
+		opts.SampleRate = 10
+	}
+	if opts.CloseTimeout <= 0 {
+		opts.CloseTimeout = 5 * time.Second
+	}
+
+	aw := &AsyncWriter{
+		writer:  writer,
+		opts:    opts,
+		queue:   make(chan asyncItem, opts.QueueSize),
+		closing: make(chan struct{}),
+		abort:   make(chan struct{}),
+		pending: make(map[uint64]int64),
+	}
+	aw.pendingCond = sync.NewCond(&aw.pendingMu)
+
+	for i := 0; i < opts.Workers; i++ {
+		aw.workers.Add(1)
+		go aw.run()
+	}
+
+	return aw
+}
+
+// Write はエントリをキューに追加する
+// キューが満杯の場合はOverflowPolicyに従って処理する
+func (w *AsyncWriter) Write(entry *Entry) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return ErrAsyncWriterClosed
+	}
+
+	// バックグラウンドで書き込む間に呼び出し元がマップを変更しても影響しないようコピーする
+	item := asyncItem{entry: cloneEntry(entry), epoch: w.begin()}
+
+	// 空きがあればそのまま追加
+	select {
+	case w.queue <- item:
+		w.enqueued.Add(1)
+		return nil
+	default:
+	}
+
+	switch w.opts.OverflowPolicy {
+	case OverflowDropNewest:
+		w.drop(item)
+		return nil
+
+	case OverflowDropOldest:
+		for {
+			select {
+			case w.queue <- item:
+				w.enqueued.Add(1)
+				return nil
+			default:
+			}
+			select {
+			case oldest := <-w.queue:
+				w.drop(oldest)
+			default:
+			}
+		}
+
+	case OverflowSample:
+		if (w.overflow.Add(1)-1)%uint64(w.opts.SampleRate) != 0 {
+			w.drop(item)
+			return nil
+		}
+		return w.enqueueBlocking(item)
+
+	default:
+		return w.enqueueBlocking(item)
+	}
+}
+
+// enqueueBlocking はキューに空きができるまで待機してエントリを追加する
+func (w *AsyncWriter) enqueueBlocking(item asyncItem) error {
+	select {
+	case w.queue <- item:
+		w.enqueued.Add(1)
+		return nil
+	case <-w.closing:
+		w.finish(item)
+		return ErrAsyncWriterClosed
+	}
+}
+
+// drop は破棄したエントリを記録する
+func (w *AsyncWriter) drop(item asyncItem) {
+	w.dropped.Add(1)
+	w.finish(item)
+}
+
+// begin は現在の世代の未処理エントリ数を増やし、その世代を返す
+func (w *AsyncWriter) begin() uint64 {
+	w.pendingMu.Lock()
+	defer w.pendingMu.Unlock()
+	w.pending[w.epoch]++
+	return w.epoch
+}
+
+// finish はエントリの世代の未処理エントリ数を減らし、0になった場合は待機中のFlushを起こす
+func (w *AsyncWriter) finish(item asyncItem) {
+	w.pendingMu.Lock()
+	defer w.pendingMu.Unlock()
+	if w.pending[item.epoch]--; w.pending[item.epoch] <= 0 {
+		delete(w.pending, item.epoch)
+		w.pendingCond.Broadcast()
+	}
+}
+
+// pendingUpTo は指定した世代以前に未処理のエントリが残っているかどうかを判定する（pendingMuを保持して呼び出す）
+func (w *AsyncWriter) pendingUpTo(epoch uint64) bool {
+	for e := range w.pending {
+		if e <= epoch {
+			return true
+		}
+	}
+	return false
+}
+
+// run はキューからエントリを取り出して書き込む
+func (w *AsyncWriter) run() {
+	defer w.workers.Done()
+
+	for item := range w.queue {
+		// Closeがタイムアウトした後は残りのエントリを書き込まずに破棄する
+		select {
+		case <-w.abort:
+			w.drop(item)
+			continue
+		default:
+		}
+
+		// ラップしたWriterのpanicでゴルーチンが終了しないよう、エラーに変換して通知する
+		if err := safeWrite(w.writer, item.entry); err != nil {
+			w.failed.Add(1)
+			w.handleError(err)
+		} else {
+			w.written.Add(1)
+		}
+		w.finish(item)
+	}
+}
+
+// handleError はバックグラウンドでの書き込みエラーを通知する
+func (w *AsyncWriter) handleError(err error) {
+	if w.opts.ErrorHandler != nil {
+		w.opts.ErrorHandler(err)
+		return
+	}
+	fmt.Printf("AsyncWriter write error: %v\n", err)
+}
+
+// Flush は呼び出し前に書き込まれたエントリがすべて処理されるまで待機し、
+// ラップしたWriterがFlushableWriterであればその内容も出力する
+// 呼び出し後に他のゴルーチンが書き込んだエントリは待たないため、書き込みが続いていても戻る
+func (w *AsyncWriter) Flush() error {
+	w.pendingMu.Lock()
+	target := w.epoch
+	w.epoch++
+	for w.pendingUpTo(target) {
+		w.pendingCond.Wait()
+	}
+	w.pendingMu.Unlock()
+
+	if f, ok := w.writer.(FlushableWriter); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Reopen はラップしたWriterがReopenableWriterであればファイルを開き直す
+// キューに残っているエントリは開き直した後のファイルに書き込まれる
+func (w *AsyncWriter) Reopen() error {
+	return reopenWriter(w.writer)
+}
+
+// SetFormatter はラップしたWriterがFormattableWriterであればフォーマッターを設定する
+func (w *AsyncWriter) SetFormatter(formatter Formatter) {
+	applyFormatter(w.writer, formatter)
+}
+
+// Stats は統計情報を取得する
+func (w *AsyncWriter) Stats() AsyncWriterStats {
+	return AsyncWriterStats{
+		Enqueued: w.enqueued.Load(),
+		Written:  w.written.Load(),
+		Failed:   w.failed.Load(),
+		Dropped:  w.dropped.Load(),
+		Queued:   len(w.queue),
+	}
+}
+
+// Dropped は溢れて破棄されたエントリ数を取得する
+func (w *AsyncWriter) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+// Close は新しい書き込みを拒否し、CloseTimeoutまでキューを処理してからラップしたWriterを閉じる
+// 時間内に処理しきれなかった場合はErrAsyncWriterCloseTimeoutを返す
+// その場合、キューに残ったエントリは破棄され（Droppedに計上）、書き込み中のエントリが完了した時点で
+// バックグラウンドでラップしたWriterを閉じる（閉じる際のエラーはErrorHandlerに通知される）
+func (w *AsyncWriter) Close() error {
+	w.closeOnce.Do(func() {
+		// ブロック中の書き込みを解放してから新しい書き込みを止める
+		close(w.closing)
+		w.mu.Lock()
+		w.closed = true
+		close(w.queue)
+		w.mu.Unlock()
+
+		done := make(chan struct{})
+		go func() {
+			w.workers.Wait()
+			close(done)
+		}()
+
+		timer := time.NewTimer(w.opts.CloseTimeout)
+		defer timer.Stop()
+
+		select {
+		case <-done:
+			if f, ok := w.writer.(FlushableWriter); ok {
+				if err := f.Flush(); err != nil {
+					w.closeErr = err
+				}
+			}
+			if err := w.writer.Close(); err != nil && w.closeErr == nil {
+				w.closeErr = err
+			}
+		case <-timer.C:
+			w.closeErr = ErrAsyncWriterCloseTimeout
+			close(w.abort)
+			go func() {
+				<-done
+				if err := w.writer.Close(); err != nil {
+					w.handleError(err)
+				}
+			}()
+		}
+	})
+	return w.closeErr
+}
+
+// cloneEntry はエントリのマップとスライスを再帰的にコピーしたエントリを作成する
+func cloneEntry(entry *Entry) *Entry {
+	clone := *entry
+	clone.Input = cloneMap(entry.Input)
+	clone.Output = cloneMap(entry.Output)
+	clone.Context = cloneMap(entry.Context)
+	clone.Metadata = cloneMap(entry.Metadata)
+	clone.SystemInfo = cloneMap(entry.SystemInfo)
+	clone.RuntimeInfo = cloneMap(entry.RuntimeInfo)
+	if entry.Tags != nil {
+		clone.Tags = append([]string(nil), entry.Tags...)
+	}
+	if entry.Error != nil {
+		errorInfo := *entry.Error
+		errorInfo.Context = cloneMap(entry.Error.Context)
+		clone.Error = &errorInfo
+	}
+	return &clone
+}
+
+// cloneMap はネストしたマップとスライスを含めてマップをコピーする（nilの場合はnil）
+func cloneMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	clone := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		clone[k] = cloneValue(v)
+	}
+	return clone
+}
+
+// cloneValue はマップとスライスの値をコピーする（その他の値はそのまま返す）
+func cloneValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return cloneMap(v)
+	case []interface{}:
+		clone := make([]interface{}, len(v))
+		for i, item := range v {
+			clone[i] = cloneValue(item)
+		}
+		return clone
+	case []string:
+		return append([]string(nil), v...)
+	default:
+		return v
+	}
+}
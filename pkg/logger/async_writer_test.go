@@ -0,0 +1,440 @@
+package logger
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryWriter は書き込まれたエントリをメモリに記録するテスト用のWriter
+type memoryWriter struct {
+	mu      sync.Mutex
+	entries []*Entry
+	closed  int
+	err     error // Writeが返すエラー
+}
+
+func (w *memoryWriter) Write(entry *Entry) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	w.entries = append(w.entries, entry)
+	return nil
+}
+
+func (w *memoryWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed++
+	return nil
+}
+
+// written は書き込まれたエントリを返す
+func (w *memoryWriter) written() []*Entry {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]*Entry(nil), w.entries...)
+}
+
+// closeCount はCloseが呼ばれた回数を返す
+func (w *memoryWriter) closeCount() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.closed
+}
+
+// operations は書き込まれたエントリの操作名を順に返す
+func (w *memoryWriter) operations() []string {
+	var ops []string
+	for _, e := range w.written() {
+		ops = append(ops, e.Operation)
+	}
+	return ops
+}
+
+// blockingWriter はreleaseが閉じられるまで書き込みをブロックするテスト用のWriter
+type blockingWriter struct {
+	memoryWriter
+	started chan struct{} // Writeが呼ばれるたびに通知される
+	release chan struct{}
+}
+
+func newBlockingWriter() *blockingWriter {
+	return &blockingWriter{
+		started: make(chan struct{}, 100),
+		release: make(chan struct{}),
+	}
+}
+
+func (w *blockingWriter) Write(entry *Entry) error {
+	// 書き込みが続くテストでバッファが一杯になっても書き込みを止めない
+	select {
+	case w.started <- struct{}{}:
+	default:
+	}
+	<-w.release
+	return w.memoryWriter.Write(entry)
+}
+
+// waitStarted はWriteが呼ばれるまで待機する
+func (w *blockingWriter) waitStarted(t *testing.T) {
+	t.Helper()
+	select {
+	case <-w.started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Writeが呼ばれませんでした")
+	}
+}
+
+// testEntry は操作名のみを持つテスト用のエントリを作成する
+func testEntry(operation string) *Entry {
+	return &Entry{Timestamp: time.Now(), Level: INFO, Operation: operation}
+}
+
+// mustWrite はエントリを書き込み、エラーの場合はテストを失敗させる
+func mustWrite(t *testing.T, w Writer, operations ...string) {
+	t.Helper()
+	for _, op := range operations {
+		if err := w.Write(testEntry(op)); err != nil {
+			t.Fatalf("Write(%s): %v", op, err)
+		}
+	}
+}
+
+// equalStrings は2つの文字列スライスが等しいかどうかを判定する
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestAsyncWriterWritesInOrder(t *testing.T) {
+	mem := &memoryWriter{}
+	w := NewAsyncWriter(mem, AsyncWriterOptions{})
+
+	mustWrite(t, w, "a", "b", "c")
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got := mem.operations(); !equalStrings(got, []string{"a", "b", "c"}) {
+		t.Errorf("書き込まれた操作 = %v", got)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	stats := w.Stats()
+	if stats.Enqueued != 3 || stats.Written != 3 || stats.Dropped != 0 || stats.Failed != 0 {
+		t.Errorf("統計情報 = %+v", stats)
+	}
+	if mem.closeCount() != 1 {
+		t.Errorf("Closeの回数 = %d, want 1", mem.closeCount())
+	}
+	if err := w.Write(testEntry("late")); !errors.Is(err, ErrAsyncWriterClosed) {
+		t.Errorf("Close後のWrite = %v, want %v", err, ErrAsyncWriterClosed)
+	}
+}
+
+func TestAsyncWriterOverflowBlock(t *testing.T) {
+	bw := newBlockingWriter()
+	w := NewAsyncWriter(bw, AsyncWriterOptions{QueueSize: 1, OverflowPolicy: OverflowBlock})
+	defer w.Close()
+
+	mustWrite(t, w, "1")
+	bw.waitStarted(t) // 1はワーカーが書き込み中
+	mustWrite(t, w, "2")
+
+	done := make(chan error, 1)
+	go func() { done <- w.Write(testEntry("3")) }()
+	select {
+	case err := <-done:
+		t.Fatalf("キューが満杯でもWriteが戻りました: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(bw.release)
+	if err := <-done; err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got := bw.operations(); !equalStrings(got, []string{"1", "2", "3"}) {
+		t.Errorf("書き込まれた操作 = %v", got)
+	}
+	if w.Dropped() != 0 {
+		t.Errorf("破棄された件数 = %d, want 0", w.Dropped())
+	}
+}
+
+func TestAsyncWriterOverflowDropNewest(t *testing.T) {
+	bw := newBlockingWriter()
+	w := NewAsyncWriter(bw, AsyncWriterOptions{QueueSize: 2, OverflowPolicy: OverflowDropNewest})
+	defer w.Close()
+
+	mustWrite(t, w, "1")
+	bw.waitStarted(t)
+	mustWrite(t, w, "2", "3", "4", "5")
+
+	if got := w.Dropped(); got != 2 {
+		t.Errorf("破棄された件数 = %d, want 2", got)
+	}
+	close(bw.release)
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got := bw.operations(); !equalStrings(got, []string{"1", "2", "3"}) {
+		t.Errorf("書き込まれた操作 = %v, want 新しいエントリが破棄される", got)
+	}
+}
+
+func TestAsyncWriterOverflowDropOldest(t *testing.T) {
+	bw := newBlockingWriter()
+	w := NewAsyncWriter(bw, AsyncWriterOptions{QueueSize: 2, OverflowPolicy: OverflowDropOldest})
+	defer w.Close()
+
+	mustWrite(t, w, "1")
+	bw.waitStarted(t)
+	mustWrite(t, w, "2", "3", "4", "5")
+
+	if got := w.Dropped(); got != 2 {
+		t.Errorf("破棄された件数 = %d, want 2", got)
+	}
+	close(bw.release)
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got := bw.operations(); !equalStrings(got, []string{"1", "4", "5"}) {
+		t.Errorf("書き込まれた操作 = %v, want 古いエントリが破棄される", got)
+	}
+}
+
+func TestAsyncWriterOverflowSample(t *testing.T) {
+	bw := newBlockingWriter()
+	w := NewAsyncWriter(bw, AsyncWriterOptions{QueueSize: 1, OverflowPolicy: OverflowSample, SampleRate: 3})
+	defer w.Close()
+
+	mustWrite(t, w, "1")
+	bw.waitStarted(t)
+	mustWrite(t, w, "2")
+
+	// 溢れた1件目は残すため、キューに空きができるまでブロックする
+	done := make(chan error, 1)
+	go func() { done <- w.Write(testEntry("3")) }()
+	deadline := time.Now().Add(5 * time.Second)
+	for w.overflow.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("溢れたエントリが処理されませんでした")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// 溢れた2件目と3件目は破棄される
+	mustWrite(t, w, "4", "5")
+	if got := w.Dropped(); got != 2 {
+		t.Errorf("破棄された件数 = %d, want 2", got)
+	}
+
+	close(bw.release)
+	if err := <-done; err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got := bw.operations(); !equalStrings(got, []string{"1", "2", "3"}) {
+		t.Errorf("書き込まれた操作 = %v", got)
+	}
+}
+
+func TestAsyncWriterCountsFailures(t *testing.T) {
+	var mu sync.Mutex
+	var handled []error
+	writeErr := errors.New("disk full")
+
+	mem := &memoryWriter{err: writeErr}
+	w := NewAsyncWriter(mem, AsyncWriterOptions{ErrorHandler: func(err error) {
+		mu.Lock()
+		handled = append(handled, err)
+		mu.Unlock()
+	}})
+	defer w.Close()
+
+	mustWrite(t, w, "1", "2")
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	stats := w.Stats()
+	if stats.Failed != 2 || stats.Written != 0 {
+		t.Errorf("統計情報 = %+v, want Failed=2", stats)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(handled) != 2 || !errors.Is(handled[0], writeErr) {
+		t.Errorf("通知されたエラー = %v", handled)
+	}
+}
+
+func TestAsyncWriterFlushWaitsOnlyForEarlierEntries(t *testing.T) {
+	bw := newBlockingWriter()
+	w := NewAsyncWriter(bw, AsyncWriterOptions{QueueSize: 10})
+	defer w.Close()
+
+	mustWrite(t, w, "1")
+	bw.waitStarted(t)
+
+	flushed := make(chan error, 1)
+	go func() { flushed <- w.Flush() }()
+	select {
+	case <-flushed:
+		t.Fatal("書き込み中のエントリを待たずにFlushが戻りました")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(bw.release)
+	if err := <-flushed; err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	// 書き込みが続いていても、Flushは呼び出し前のエントリを処理した時点で戻る
+	stop := make(chan struct{})
+	var writers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		writers.Add(1)
+		go func() {
+			defer writers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					_ = w.Write(testEntry("busy"))
+				}
+			}
+		}()
+	}
+	defer func() {
+		close(stop)
+		writers.Wait()
+	}()
+
+	go func() { flushed <- w.Flush() }()
+	select {
+	case err := <-flushed:
+		if err != nil {
+			t.Fatalf("Flush: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("書き込みが続く間Flushが戻りませんでした")
+	}
+}
+
+func TestAsyncWriterCopiesEntry(t *testing.T) {
+	bw := newBlockingWriter()
+	w := NewAsyncWriter(bw, AsyncWriterOptions{})
+	defer w.Close()
+
+	nested := map[string]interface{}{"id": 1}
+	input := map[string]interface{}{"user": nested}
+	entry := &Entry{Level: INFO, Operation: "op", Input: input, Context: map[string]interface{}{"k": "before"}}
+	if err := w.Write(entry); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	// 書き込み前に呼び出し元がマップを変更しても、書き込まれるエントリには影響しない
+	entry.Context["k"] = "after"
+	nested["id"] = 2
+	close(bw.release)
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	written := bw.written()
+	if len(written) != 1 {
+		t.Fatalf("書き込まれた件数 = %d, want 1", len(written))
+	}
+	if got := written[0].Context["k"]; got != "before" {
+		t.Errorf("Context[k] = %v, want before", got)
+	}
+	if got := written[0].Input["user"].(map[string]interface{})["id"]; got != 1 {
+		t.Errorf("Input[user][id] = %v, want 1", got)
+	}
+}
+
+func TestAsyncWriterCloseDrainsQueue(t *testing.T) {
+	bw := newBlockingWriter()
+	w := NewAsyncWriter(bw, AsyncWriterOptions{QueueSize: 10, CloseTimeout: 5 * time.Second})
+
+	mustWrite(t, w, "1", "2", "3")
+	bw.waitStarted(t)
+	time.AfterFunc(20*time.Millisecond, func() { close(bw.release) })
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := bw.operations(); !equalStrings(got, []string{"1", "2", "3"}) {
+		t.Errorf("書き込まれた操作 = %v", got)
+	}
+	if bw.closeCount() != 1 {
+		t.Errorf("Closeの回数 = %d, want 1", bw.closeCount())
+	}
+}
+
+func TestAsyncWriterCloseTimeoutDropsQueue(t *testing.T) {
+	var mu sync.Mutex
+	var handled []error
+	bw := newBlockingWriter()
+	w := NewAsyncWriter(bw, AsyncWriterOptions{
+		QueueSize:    10,
+		CloseTimeout: 20 * time.Millisecond,
+		ErrorHandler: func(err error) {
+			mu.Lock()
+			handled = append(handled, err)
+			mu.Unlock()
+		},
+	})
+
+	mustWrite(t, w, "1", "2", "3", "4")
+	bw.waitStarted(t)
+
+	if err := w.Close(); !errors.Is(err, ErrAsyncWriterCloseTimeout) {
+		t.Fatalf("Close = %v, want %v", err, ErrAsyncWriterCloseTimeout)
+	}
+	if bw.closeCount() != 0 {
+		t.Fatal("書き込み中にラップしたWriterが閉じられました")
+	}
+
+	// 書き込み中のエントリが完了すると、残りは破棄されてラップしたWriterが閉じられる
+	close(bw.release)
+	deadline := time.Now().Add(5 * time.Second)
+	for bw.closeCount() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("ラップしたWriterが閉じられませんでした")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if got := bw.operations(); !equalStrings(got, []string{"1"}) {
+		t.Errorf("書き込まれた操作 = %v, want [1]", got)
+	}
+	if got := w.Dropped(); got != 3 {
+		t.Errorf("破棄された件数 = %d, want 3", got)
+	}
+	if err := w.Flush(); err != nil {
+		t.Errorf("Close後のFlush: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(handled) != 0 {
+		t.Errorf("通知されたエラー = %v", handled)
+	}
+}
@@ -0,0 +1,302 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CircuitState はサーキットブレーカーの状態を表す
+type CircuitState int
+
+const (
+	// CircuitClosed は通常状態で、すべての呼び出しを通過させる
+	CircuitClosed CircuitState = iota
+	// CircuitOpen は遮断状態で、すべての呼び出しを拒否する
+	CircuitOpen
+	// CircuitHalfOpen は試行状態で、限られた数の呼び出しのみを通過させる
+	CircuitHalfOpen
+)
+
+// String はCircuitStateを文字列に変換する
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "CLOSED"
+	case CircuitOpen:
+		return "OPEN"
+	case CircuitHalfOpen:
+		return "HALF_OPEN"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// ErrCircuitOpen はサーキットブレーカーが呼び出しを拒否した場合に返されるエラー
+type ErrCircuitOpen struct {
+	Operation  string        // 拒否された操作名
+	State      CircuitState  // 拒否時の状態（OPENまたはHALF_OPEN）
+	RetryAfter time.Duration // 次に試行可能になるまでの目安時間
+}
+
+// Error はエラーメッセージを返す
+func (e *ErrCircuitOpen) Error() string {
+	return fmt.Sprintf("サーキットブレーカーが作動中のため実行できません: operation=%s state=%s retry_after=%s",
+		e.Operation, e.State, e.RetryAfter)
+}
+
+// CircuitBreakerOptions はサーキットブレーカーの設定です
+type CircuitBreakerOptions struct {
+	// FailureThreshold は遮断する連続失敗回数（0の場合は5）
+	FailureThreshold int
+	// FailureRateThreshold は遮断する失敗率（0.0〜1.0、0の場合は失敗率による判定を行わない）
+	FailureRateThreshold float64
+	// MinRequests は失敗率を判定するために必要な最小呼び出し数（0の場合は10）
+	MinRequests int
+	// Interval はCLOSED状態で失敗率の集計をリセットする間隔（0の場合はリセットしない）
+	Interval time.Duration
+	// OpenTimeout はOPENからHALF_OPENへ移行するまでの時間（0の場合は30秒）
+	OpenTimeout time.Duration
+	// HalfOpenMaxCalls はHALF_OPEN状態で許可する試行呼び出し数（0の場合は1）
+	HalfOpenMaxCalls int
+	// Now は現在時刻を返す関数（nilの場合はtime.Now、テスト用に差し替え可能）
+	Now func() time.Time
+}
+
+// circuitTransition は状態遷移の記録
+type circuitTransition struct {
+	from   CircuitState
+	to     CircuitState
+	reason string
+}
+
+// CircuitBreaker は操作ごとの失敗を監視し、失敗が続いた場合に呼び出しを遮断する
+type CircuitBreaker struct {
+	name   string
+	logger Logger
+	opts   CircuitBreakerOptions
+
+	mu                  sync.Mutex
+	state               CircuitState
+	openedAt            time.Time
+	intervalStart       time.Time
+	requests            int
+	failures            int
+	consecutiveFailures int
+	halfOpenInFlight    int
+	halfOpenSuccesses   int
+}
+
+// NewCircuitBreaker は新しいサーキットブレーカーを作成する
+func NewCircuitBreaker(logger Logger, name string, opts CircuitBreakerOptions) *CircuitBreaker {
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = 5
+	}
+	if opts.MinRequests <= 0 {
+		opts.MinRequests = 10
+	}
+	if opts.OpenTimeout <= 0 {
+		opts.OpenTimeout = 30 * time.Second
+	}
+	if opts.HalfOpenMaxCalls <= 0 {
+		opts.HalfOpenMaxCalls = 1
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	return &CircuitBreaker{
+		name:          name,
+		logger:        logger,
+		opts:          opts,
+		state:         CircuitClosed,
+		intervalStart: opts.Now(),
+	}
+}
+
+// Name は操作名を取得する
+func (cb *CircuitBreaker) Name() string {
+	return cb.name
+}
+
+// State は現在の状態を取得する
+// OpenTimeoutを経過している場合はHALF_OPENへ移行した状態を返す
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	transition := cb.advance(cb.opts.Now())
+	state := cb.state
+	cb.mu.Unlock()
+
+	cb.logTransition(transition)
+	return state
+}
+
+// Execute は状態に応じて関数を実行し、結果を記録する
+// 呼び出しが拒否された場合は*ErrCircuitOpenを返す
+// fnがパニックした場合は失敗として記録してから、パニックを呼び出し元に伝播させる
+func (cb *CircuitBreaker) Execute(fn func() error) error {
+	if err := cb.allow(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			// HALF_OPENの試行枠を解放しないと、以降の呼び出しがすべて拒否され続ける
+			cb.record(false)
+			panic(r)
+		}
+	}()
+
+	err := fn()
+	cb.record(err == nil)
+	return err
+}
+
+// Reset は状態をCLOSEDに戻し、集計をリセットする
+func (cb *CircuitBreaker) Reset() {
+	cb.mu.Lock()
+	transition := cb.setState(CircuitClosed, cb.opts.Now(), "manual_reset")
+	cb.mu.Unlock()
+
+	cb.logTransition(transition)
+}
+
+// allow は呼び出しを許可するかどうかを判定する
+func (cb *CircuitBreaker) allow() error {
+	now := cb.opts.Now()
+
+	cb.mu.Lock()
+	transition := cb.advance(now)
+
+	var rejected *ErrCircuitOpen
+	switch cb.state {
+	case CircuitOpen:
+		rejected = &ErrCircuitOpen{
+			Operation:  cb.name,
+			State:      CircuitOpen,
+			RetryAfter: cb.openedAt.Add(cb.opts.OpenTimeout).Sub(now),
+		}
+	case CircuitHalfOpen:
+		if cb.halfOpenInFlight >= cb.opts.HalfOpenMaxCalls {
+			rejected = &ErrCircuitOpen{
+				Operation: cb.name,
+				State:     CircuitHalfOpen,
+			}
+		} else {
+			cb.halfOpenInFlight++
+		}
+	}
+	cb.mu.Unlock()
+
+	cb.logTransition(transition)
+
+	if rejected != nil {
+		cb.logger.Debug(cb.name,
+			actionField(ActionSkip),
+			String("circuit_state", rejected.State.String()),
+			Duration("retry_after", rejected.RetryAfter))
+		return rejected
+	}
+	return nil
+}
+
+// record は呼び出し結果を記録し、必要に応じて状態を遷移させる
+func (cb *CircuitBreaker) record(success bool) {
+	now := cb.opts.Now()
+
+	cb.mu.Lock()
+	var transition *circuitTransition
+
+	switch cb.state {
+	case CircuitHalfOpen:
+		if cb.halfOpenInFlight > 0 {
+			cb.halfOpenInFlight--
+		}
+		if !success {
+			transition = cb.setState(CircuitOpen, now, "half_open_trial_failed")
+			break
+		}
+		cb.halfOpenSuccesses++
+		if cb.halfOpenSuccesses >= cb.opts.HalfOpenMaxCalls {
+			transition = cb.setState(CircuitClosed, now, "half_open_trials_succeeded")
+		}
+
+	case CircuitClosed:
+		cb.requests++
+		if success {
+			cb.consecutiveFailures = 0
+			break
+		}
+		cb.failures++
+		cb.consecutiveFailures++
+
+		if cb.consecutiveFailures >= cb.opts.FailureThreshold {
+			transition = cb.setState(CircuitOpen, now, "consecutive_failures")
+		} else if cb.opts.FailureRateThreshold > 0 && cb.requests >= cb.opts.MinRequests &&
+			float64(cb.failures)/float64(cb.requests) >= cb.opts.FailureRateThreshold {
+			transition = cb.setState(CircuitOpen, now, "failure_rate")
+		}
+	}
+	cb.mu.Unlock()
+
+	cb.logTransition(transition)
+}
+
+// advance は時間経過による状態遷移を行う（cb.muを保持した状態で呼び出すこと）
+func (cb *CircuitBreaker) advance(now time.Time) *circuitTransition {
+	switch cb.state {
+	case CircuitOpen:
+		if !now.Before(cb.openedAt.Add(cb.opts.OpenTimeout)) {
+			return cb.setState(CircuitHalfOpen, now, "open_timeout_elapsed")
+		}
+	case CircuitClosed:
+		if cb.opts.Interval > 0 && !now.Before(cb.intervalStart.Add(cb.opts.Interval)) {
+			cb.requests = 0
+			cb.failures = 0
+			cb.intervalStart = now
+		}
+	}
+	return nil
+}
+
+// setState は状態を変更し、集計をリセットする（cb.muを保持した状態で呼び出すこと）
+func (cb *CircuitBreaker) setState(state CircuitState, now time.Time, reason string) *circuitTransition {
+	transition := &circuitTransition{from: cb.state, to: state, reason: reason}
+
+	cb.state = state
+	cb.requests = 0
+	cb.failures = 0
+	cb.consecutiveFailures = 0
+	cb.halfOpenInFlight = 0
+	cb.halfOpenSuccesses = 0
+	cb.intervalStart = now
+	if state == CircuitOpen {
+		cb.openedAt = now
+	}
+
+	return transition
+}
+
+// logTransition は状態遷移を構造化エントリとして記録する
+func (cb *CircuitBreaker) logTransition(transition *circuitTransition) {
+	if transition == nil || transition.from == transition.to {
+		return
+	}
+
+	fields := []Field{
+		String("from_state", transition.from.String()),
+		String("to_state", transition.to.String()),
+		String("reason", transition.reason),
+		Int("failure_threshold", cb.opts.FailureThreshold),
+		Duration("open_timeout", cb.opts.OpenTimeout),
+	}
+
+	switch transition.to {
+	case CircuitOpen:
+		cb.logger.Warn(cb.name, append([]Field{actionField(ActionCircuitOpen)}, fields...)...)
+	case CircuitHalfOpen:
+		cb.logger.Info(cb.name, append([]Field{actionField(ActionCircuitHalfOpen)}, fields...)...)
+	case CircuitClosed:
+		cb.logger.Info(cb.name, append([]Field{actionField(ActionCircuitClose)}, fields...)...)
+	}
+}
@@ -0,0 +1,279 @@
+package logger
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// manualClock はAdvanceでのみ進むテスト用の時計
+type manualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance は時計をdだけ進める
+func (c *manualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// newTestBreaker はmanualClockの時刻を使うサーキットブレーカーと、状態遷移の記録先を作成する
+func newTestBreaker(opts CircuitBreakerOptions) (*CircuitBreaker, *memoryWriter, *manualClock) {
+	mem := &memoryWriter{}
+	l := New(DEBUG)
+	l.AddWriter(mem)
+
+	clock := &manualClock{now: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	opts.Now = clock.Now
+	return NewCircuitBreaker(l, "payment", opts), mem, clock
+}
+
+// transitions は記録された状態遷移を "FROM->TO" の形式で返す
+func transitions(mem *memoryWriter) []string {
+	var got []string
+	for _, e := range mem.written() {
+		if to, ok := e.Context["to_state"]; ok {
+			got = append(got, e.Context["from_state"].(string)+"->"+to.(string))
+		}
+	}
+	return got
+}
+
+var errTrial = errors.New("trial failed")
+
+func succeed() error { return nil }
+func fail() error    { return errTrial }
+
+func TestCircuitBreakerOpensAtThreshold(t *testing.T) {
+	cb, mem, _ := newTestBreaker(CircuitBreakerOptions{FailureThreshold: 3})
+
+	for i := 0; i < 2; i++ {
+		if err := cb.Execute(fail); !errors.Is(err, errTrial) {
+			t.Fatalf("Execute = %v, want %v", err, errTrial)
+		}
+	}
+	// 成功すると連続失敗回数はリセットされる
+	if err := cb.Execute(succeed); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		_ = cb.Execute(fail)
+	}
+	if cb.State() != CircuitClosed {
+		t.Fatalf("状態 = %s, want CLOSED", cb.State())
+	}
+
+	_ = cb.Execute(fail)
+	if cb.State() != CircuitOpen {
+		t.Fatalf("状態 = %s, want OPEN", cb.State())
+	}
+	if got := transitions(mem); !equalStrings(got, []string{"CLOSED->OPEN"}) {
+		t.Errorf("状態遷移 = %v", got)
+	}
+	for _, e := range mem.written() {
+		if e.Context["to_state"] == "OPEN" && (e.Level != WARN || e.Action != ActionCircuitOpen || e.Context["reason"] != "consecutive_failures") {
+			t.Errorf("遮断のエントリ = %+v", e)
+		}
+	}
+}
+
+func TestCircuitBreakerOpensAtFailureRate(t *testing.T) {
+	cb, _, _ := newTestBreaker(CircuitBreakerOptions{
+		FailureThreshold:     100,
+		FailureRateThreshold: 0.5,
+		MinRequests:          4,
+	})
+
+	// 最小呼び出し数に達するまでは失敗率で判定しない
+	_ = cb.Execute(fail)
+	_ = cb.Execute(succeed)
+	_ = cb.Execute(fail)
+	if cb.State() != CircuitClosed {
+		t.Fatalf("状態 = %s, want CLOSED", cb.State())
+	}
+	_ = cb.Execute(succeed)
+	if cb.State() != CircuitClosed {
+		t.Fatalf("成功で判定された状態 = %s, want CLOSED", cb.State())
+	}
+	_ = cb.Execute(fail)
+	if cb.State() != CircuitOpen {
+		t.Errorf("状態 = %s, want OPEN（5回中3回失敗）", cb.State())
+	}
+}
+
+func TestCircuitBreakerIntervalResetsCounts(t *testing.T) {
+	cb, _, clock := newTestBreaker(CircuitBreakerOptions{
+		FailureThreshold:     100,
+		FailureRateThreshold: 0.5,
+		MinRequests:          2,
+		Interval:             time.Minute,
+	})
+
+	_ = cb.Execute(fail)
+	clock.Advance(time.Minute)
+	// 前の集計はリセットされるため3回中1回の失敗となり遮断しない（リセットされなければ4回中2回で遮断する）
+	_ = cb.Execute(succeed)
+	_ = cb.Execute(succeed)
+	_ = cb.Execute(fail)
+	if cb.State() != CircuitClosed {
+		t.Errorf("状態 = %s, want CLOSED", cb.State())
+	}
+}
+
+func TestCircuitBreakerRejectsWhileOpen(t *testing.T) {
+	cb, _, clock := newTestBreaker(CircuitBreakerOptions{FailureThreshold: 1, OpenTimeout: time.Minute})
+
+	_ = cb.Execute(fail)
+	clock.Advance(20 * time.Second)
+
+	called := false
+	err := cb.Execute(func() error {
+		called = true
+		return nil
+	})
+	var open *ErrCircuitOpen
+	if !errors.As(err, &open) {
+		t.Fatalf("Execute = %v, want *ErrCircuitOpen", err)
+	}
+	if called {
+		t.Error("OPEN状態で関数が実行されました")
+	}
+	if open.State != CircuitOpen || open.RetryAfter != 40*time.Second || open.Operation != "payment" {
+		t.Errorf("エラー = %+v", open)
+	}
+}
+
+func TestCircuitBreakerHalfOpenAfterTimeout(t *testing.T) {
+	cb, mem, clock := newTestBreaker(CircuitBreakerOptions{FailureThreshold: 1, OpenTimeout: time.Minute})
+
+	_ = cb.Execute(fail)
+	clock.Advance(time.Minute - time.Nanosecond)
+	if cb.State() != CircuitOpen {
+		t.Fatalf("状態 = %s, want OPEN", cb.State())
+	}
+	clock.Advance(time.Nanosecond)
+	if cb.State() != CircuitHalfOpen {
+		t.Fatalf("状態 = %s, want HALF_OPEN", cb.State())
+	}
+	if got := transitions(mem); !equalStrings(got, []string{"CLOSED->OPEN", "OPEN->HALF_OPEN"}) {
+		t.Errorf("状態遷移 = %v", got)
+	}
+}
+
+func TestCircuitBreakerHalfOpenTrials(t *testing.T) {
+	tests := []struct {
+		name  string
+		trial func() error
+		want  CircuitState
+		last  string
+	}{
+		{"成功でCLOSED", succeed, CircuitClosed, "HALF_OPEN->CLOSED"},
+		{"失敗でOPEN", fail, CircuitOpen, "HALF_OPEN->OPEN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cb, mem, clock := newTestBreaker(CircuitBreakerOptions{FailureThreshold: 1, OpenTimeout: time.Minute})
+			_ = cb.Execute(fail)
+			clock.Advance(time.Minute)
+
+			_ = cb.Execute(tt.trial)
+			if cb.State() != tt.want {
+				t.Fatalf("状態 = %s, want %s", cb.State(), tt.want)
+			}
+			got := transitions(mem)
+			if len(got) == 0 || got[len(got)-1] != tt.last {
+				t.Errorf("状態遷移 = %v, want 最後が%s", got, tt.last)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerHalfOpenLimitsTrials(t *testing.T) {
+	cb, _, clock := newTestBreaker(CircuitBreakerOptions{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenMaxCalls: 2})
+	_ = cb.Execute(fail)
+	clock.Advance(time.Minute)
+
+	// 試行中の呼び出しが上限に達している間は拒否する
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	done := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			done <- cb.Execute(func() error {
+				started <- struct{}{}
+				<-release
+				return nil
+			})
+		}()
+	}
+	<-started
+	<-started
+
+	var open *ErrCircuitOpen
+	if err := cb.Execute(succeed); !errors.As(err, &open) || open.State != CircuitHalfOpen {
+		t.Fatalf("Execute = %v, want HALF_OPENの*ErrCircuitOpen", err)
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Fatalf("Execute: %v", err)
+		}
+	}
+	// HalfOpenMaxCalls回すべて成功するとCLOSEDに戻る
+	if cb.State() != CircuitClosed {
+		t.Errorf("状態 = %s, want CLOSED", cb.State())
+	}
+}
+
+func TestCircuitBreakerPanicReleasesHalfOpenSlot(t *testing.T) {
+	cb, _, clock := newTestBreaker(CircuitBreakerOptions{FailureThreshold: 1, OpenTimeout: time.Minute})
+	_ = cb.Execute(fail)
+	clock.Advance(time.Minute)
+
+	func() {
+		defer func() {
+			if r := recover(); r != "trial panic" {
+				t.Errorf("recover = %v, want trial panic", r)
+			}
+		}()
+		_ = cb.Execute(func() error { panic("trial panic") })
+	}()
+
+	// パニックは失敗として記録され、試行枠が解放される
+	if cb.State() != CircuitOpen {
+		t.Fatalf("状態 = %s, want OPEN", cb.State())
+	}
+	clock.Advance(time.Minute)
+	if err := cb.Execute(succeed); err != nil {
+		t.Fatalf("Execute = %v, want 試行が許可される", err)
+	}
+	if cb.State() != CircuitClosed {
+		t.Errorf("状態 = %s, want CLOSED", cb.State())
+	}
+}
+
+func TestCircuitBreakerReset(t *testing.T) {
+	cb, mem, _ := newTestBreaker(CircuitBreakerOptions{FailureThreshold: 1})
+	_ = cb.Execute(fail)
+
+	cb.Reset()
+	if cb.State() != CircuitClosed {
+		t.Fatalf("状態 = %s, want CLOSED", cb.State())
+	}
+	if err := cb.Execute(succeed); err != nil {
+		t.Errorf("Execute: %v", err)
+	}
+	if got := transitions(mem); !equalStrings(got, []string{"CLOSED->OPEN", "OPEN->CLOSED"}) {
+		t.Errorf("状態遷移 = %v", got)
+	}
+}
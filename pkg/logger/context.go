@@ -0,0 +1,142 @@
+package logger
+
+import (
+	"context"
+	"sync"
+)
+
+// contextKey はcontext.Contextに値を格納するためのキー
+type contextKey int
+
+const (
+	loggerContextKey contextKey = iota
+	traceIDContextKey
+	spanIDContextKey
+	tagsContextKey
+	fieldsContextKey
+)
+
+var (
+	defaultLoggerOnce sync.Once
+	defaultLogger     Logger
+)
+
+// ContextWithLogger はロガーを格納したコンテキストを返す
+func ContextWithLogger(ctx context.Context, logger Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey, logger)
+}
+
+// FromContext はコンテキストに格納されたロガーを返す
+// 返されるロガーはWithContext(ctx)済みのため、コンテキストのトレースIDやフィールドが各エントリに反映される
+// ロガーが格納されていない場合（ctxがnilの場合を含む）はパッケージ共通のデフォルトロガーを使用する
+func FromContext(ctx context.Context) Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerContextKey).(Logger); ok && logger != nil {
+			return logger.WithContext(ctx)
+		}
+	}
+
+	defaultLoggerOnce.Do(func() {
+		defaultLogger = Default()
+	})
+	return defaultLogger.WithContext(ctx)
+}
+
+// ContextWithTraceID はトレースIDを格納したコンテキストを返す
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDContextKey, traceID)
+}
+
+// TraceIDFromContext はコンテキストに格納されたトレースIDを取得する
+func TraceIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	traceID, _ := ctx.Value(traceIDContextKey).(string)
+	return traceID
+}
+
+// ContextWithSpanID はスパンIDを格納したコンテキストを返す
+func ContextWithSpanID(ctx context.Context, spanID string) context.Context {
+	return context.WithValue(ctx, spanIDContextKey, spanID)
+}
+
+// SpanIDFromContext はコンテキストに格納されたスパンIDを取得する
+func SpanIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	spanID, _ := ctx.Value(spanIDContextKey).(string)
+	return spanID
+}
+
+// ContextWithTags はタグを追加したコンテキストを返す
+// 既に格納されているタグは引き継がれる
+func ContextWithTags(ctx context.Context, tags ...string) context.Context {
+	existing := TagsFromContext(ctx)
+	merged := make([]string, 0, len(existing)+len(tags))
+	merged = append(merged, existing...)
+	merged = append(merged, tags...)
+	return context.WithValue(ctx, tagsContextKey, merged)
+}
+
+// TagsFromContext はコンテキストに格納されたタグを取得する
+func TagsFromContext(ctx context.Context) []string {
+	if ctx == nil {
+		return nil
+	}
+	tags, _ := ctx.Value(tagsContextKey).([]string)
+	return tags
+}
+
+// ContextWithField はフィールドを追加したコンテキストを返す
+func ContextWithField(ctx context.Context, key string, value interface{}) context.Context {
+	return ContextWithFields(ctx, map[string]interface{}{key: value})
+}
+
+// ContextWithFields は複数フィールドを追加したコンテキストを返す
+// 既に格納されているフィールドは引き継がれ、同じキーは上書きされる
+func ContextWithFields(ctx context.Context, fields map[string]interface{}) context.Context {
+	existing := FieldsFromContext(ctx)
+	merged := make(map[string]interface{}, len(existing)+len(fields))
+	for k, v := range existing {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, fieldsContextKey, merged)
+}
+
+// FieldsFromContext はコンテキストに格納されたフィールドを取得する
+// 返されるマップは読み取り専用として扱うこと
+func FieldsFromContext(ctx context.Context) map[string]interface{} {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsContextKey).(map[string]interface{})
+	return fields
+}
+
+// applyContextValues はコンテキストに格納された値をエントリに反映する
+// ロガー自身に設定されたトレースID・スパンID・フィールドが優先される
+func applyContextValues(entry *Entry, ctx context.Context) {
+	if ctx == nil {
+		return
+	}
+
+	if entry.TraceID == "" {
+		entry.TraceID = TraceIDFromContext(ctx)
+	}
+	if entry.SpanID == "" {
+		entry.SpanID = SpanIDFromContext(ctx)
+	}
+
+	for k, v := range FieldsFromContext(ctx) {
+		if _, exists := entry.Context[k]; !exists {
+			entry.Context[k] = v
+		}
+	}
+
+	entry.Tags = append(entry.Tags, TagsFromContext(ctx)...)
+}
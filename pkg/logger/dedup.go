@@ -0,0 +1,268 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DedupOptions はDedupWriterの設定です
+type DedupOptions struct {
+	// Window は重複を抑制する期間（0の場合は10秒）
+	// 最初のエントリから期間内に来た同じフィンガープリントのエントリは書き込まず、件数のみを集計する
+	Window time.Duration
+	// Levels は重複抑制の対象とするレベル（nilの場合はERRORとFATAL、その他のレベルは常に書き込む）
+	Levels []LogLevel
+	// MaxKeys は同時に追跡するフィンガープリントの上限（0の場合は10000、超えた場合は抑制せずに書き込む）
+	MaxKeys int
+	// Fingerprint はエントリのフィンガープリントを計算する関数（nilの場合はDefaultFingerprint）
+	Fingerprint func(entry *Entry) string
+	// Clock は時刻の取得に使うClock（nilの場合は実時間）
+	Clock Clock
+	// ErrorHandler はバックグラウンドでのロールアップ書き込みエラーの通知先（nilの場合は標準出力）
+	ErrorHandler func(error)
+}
+
+// DefaultFingerprint はレベル・操作名・エラーの種類とメッセージ・呼び出し元からフィンガープリントを計算する
+// ErrorInfoを持たないエントリ（ErrorHandler.HandleErrorなど）はContextのerror_type・error_messageを使い、
+// エラーを持たないエントリは値の異なるエントリをまとめないようContext全体も含める
+func DefaultFingerprint(entry *Entry) string {
+	var errType, errMessage interface{}
+	if entry.Error != nil {
+		errType, errMessage = entry.Error.Type, entry.Error.Message
+	} else {
+		errType, errMessage = entry.Context["error_type"], entry.Context["error_message"]
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d\x00%s\x00%v\x00%v\x00%v",
+		entry.Level, entry.Operation, errType, errMessage, entry.Metadata["caller"])
+	if errType == nil && errMessage == nil {
+		// fmtはマップのキーをソートして出力するため、同じ内容のContextは同じ文字列になる
+		fmt.Fprintf(&b, "\x00%v", entry.Context)
+	}
+	return b.String()
+}
+
+// dedupState はフィンガープリントごとの集計状態
+type dedupState struct {
+	entry     *Entry
+	firstSeen time.Time
+	lastSeen  time.Time
+	expiresAt time.Time
+	repeats   int
+}
+
+// DedupWriter は任意のWriterをラップし、同じフィンガープリントのエントリの繰り返しを抑制する
+// 期間内の最初のエントリはそのまま書き込み、期間が過ぎた後に抑制した件数を
+// repeat_count・first_seen・last_seenを持つ1件のロールアップエントリとして書き込む
+// ロールアップは期間後に同じエントリが来たとき、バックグラウンドの定期確認、またはFlush・Closeの呼び出し時に書き込まれる
+type DedupWriter struct {
+	writer Writer
+	opts   DedupOptions
+	levels map[LogLevel]bool
+
+	mu     sync.Mutex
+	states map[string]*dedupState
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewDedupWriter は新しい重複抑制ライターを作成し、ロールアップを定期的に書き込むゴルーチンを開始する
+func NewDedupWriter(writer Writer, opts DedupOptions) *DedupWriter {
+	if opts.Window <= 0 {
+		opts.Window = 10 * time.Second
+	}
+	if opts.MaxKeys <= 0 {
+		opts.MaxKeys = 10000
+	}
+	if opts.Fingerprint == nil {
+		opts.Fingerprint = DefaultFingerprint
+	}
+	if opts.Clock == nil {
+		opts.Clock = realClock{}
+	}
+	if opts.Levels == nil {
+		opts.Levels = []LogLevel{ERROR, FATAL}
+	}
+
+	dw := &DedupWriter{
+		writer: writer,
+		opts:   opts,
+		states: make(map[string]*dedupState),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	dw.levels = make(map[LogLevel]bool, len(opts.Levels))
+	for _, level := range opts.Levels {
+		dw.levels[level] = true
+	}
+
+	go dw.run()
+	return dw
+}
+
+// Write は重複していないエントリを書き込み、重複しているエントリは件数のみを集計する
+func (w *DedupWriter) Write(entry *Entry) error {
+	if !w.levels[entry.Level] {
+		return w.writer.Write(entry)
+	}
+
+	key := w.opts.Fingerprint(entry)
+	now := w.opts.Clock.Now()
+
+	w.mu.Lock()
+	var rollup *Entry
+	state, ok := w.states[key]
+	if ok && now.Before(state.expiresAt) {
+		state.repeats++
+		state.lastSeen = now
+		w.mu.Unlock()
+		return nil
+	}
+	if ok {
+		rollup = state.rollup()
+		delete(w.states, key)
+	}
+	if len(w.states) < w.opts.MaxKeys {
+		w.states[key] = &dedupState{
+			entry:     entry,
+			firstSeen: now,
+			lastSeen:  now,
+			expiresAt: now.Add(w.opts.Window),
+		}
+	}
+	w.mu.Unlock()
+
+	var errs []error
+	if rollup != nil {
+		errs = append(errs, w.writer.Write(rollup))
+	}
+	errs = append(errs, w.writer.Write(entry))
+	return errors.Join(errs...)
+}
+
+// rollup は抑制した件数を持つロールアップエントリを作成する（抑制していない場合はnil）
+func (s *dedupState) rollup() *Entry {
+	if s.repeats == 0 {
+		return nil
+	}
+
+	rollup := *s.entry
+	rollup.ID = uuid.New().String()
+	rollup.Timestamp = s.lastSeen
+
+	rollup.Context = make(map[string]interface{}, len(s.entry.Context)+3)
+	for k, v := range s.entry.Context {
+		rollup.Context[k] = v
+	}
+	rollup.Context["repeat_count"] = s.repeats
+	rollup.Context["first_seen"] = s.firstSeen
+	rollup.Context["last_seen"] = s.lastSeen
+
+	rollup.Metadata = make(map[string]interface{}, len(s.entry.Metadata)+1)
+	for k, v := range s.entry.Metadata {
+		rollup.Metadata[k] = v
+	}
+	rollup.Metadata["dedup_rollup"] = true
+
+	return &rollup
+}
+
+// collect は期限切れ（allがtrueの場合はすべて）の状態を取り除き、ロールアップエントリを返す
+func (w *DedupWriter) collect(all bool) []*Entry {
+	now := w.opts.Clock.Now()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var rollups []*Entry
+	for key, state := range w.states {
+		if !all && now.Before(state.expiresAt) {
+			continue
+		}
+		if rollup := state.rollup(); rollup != nil {
+			rollups = append(rollups, rollup)
+		}
+		delete(w.states, key)
+	}
+	return rollups
+}
+
+// writeRollups はロールアップエントリを書き込む
+func (w *DedupWriter) writeRollups(rollups []*Entry) error {
+	var errs []error
+	for _, rollup := range rollups {
+		if err := w.writer.Write(rollup); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// run は期限切れの状態を定期的に確認し、ロールアップを書き込む
+func (w *DedupWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.opts.Window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.writeRollups(w.collect(false)); err != nil {
+				w.handleError(err)
+			}
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// handleError はバックグラウンドでの書き込みエラーを通知する
+func (w *DedupWriter) handleError(err error) {
+	if w.opts.ErrorHandler != nil {
+		w.opts.ErrorHandler(err)
+		return
+	}
+	fmt.Printf("DedupWriter write error: %v\n", err)
+}
+
+// Flush は期間中のものも含めてすべてのロールアップを書き込み、
+// ラップしたWriterがFlushableWriterであればその内容も出力する
+func (w *DedupWriter) Flush() error {
+	err := w.writeRollups(w.collect(true))
+	if f, ok := w.writer.(FlushableWriter); ok {
+		err = errors.Join(err, f.Flush())
+	}
+	return err
+}
+
+// Reopen はラップしたWriterがReopenableWriterであればファイルを開き直す
+func (w *DedupWriter) Reopen() error {
+	return reopenWriter(w.writer)
+}
+
+// SetFormatter はラップしたWriterがFormattableWriterであればフォーマッターを設定する
+func (w *DedupWriter) SetFormatter(formatter Formatter) {
+	applyFormatter(w.writer, formatter)
+}
+
+// Close はバックグラウンドのゴルーチンを停止し、すべてのロールアップを書き込んでからラップしたWriterを閉じる
+func (w *DedupWriter) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.stop)
+		<-w.done
+
+		err := w.writeRollups(w.collect(true))
+		w.closeErr = errors.Join(err, w.writer.Close())
+	})
+	return w.closeErr
+}
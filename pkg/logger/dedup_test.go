@@ -0,0 +1,243 @@
+package logger
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestDedup はFakeClockを使うDedupWriterを作成する
+// バックグラウンドの定期確認が実時間で動かないよう、Windowは十分に長くしてcollectを直接呼び出す
+func newTestDedup(t *testing.T, opts DedupOptions) (*DedupWriter, *memoryWriter, *FakeClock) {
+	t.Helper()
+	mem := &memoryWriter{}
+	clock := NewFakeClock(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	opts.Clock = clock
+	if opts.Window == 0 {
+		opts.Window = time.Hour
+	}
+	dw := NewDedupWriter(mem, opts)
+	t.Cleanup(func() { _ = dw.Close() })
+	return dw, mem, clock
+}
+
+// errorEntry はエラー情報を持つERRORエントリを作成する
+func errorEntry(op, message string) *Entry {
+	return &Entry{
+		Level:     ERROR,
+		Operation: op,
+		Error:     &ErrorInfo{Type: "*errors.errorString", Message: message},
+		Context:   map[string]interface{}{"attempt": 1},
+	}
+}
+
+// rollups は書き込まれたロールアップエントリを返す
+func rollups(mem *memoryWriter) []*Entry {
+	var got []*Entry
+	for _, e := range mem.written() {
+		if e.Metadata["dedup_rollup"] == true {
+			got = append(got, e)
+		}
+	}
+	return got
+}
+
+func TestDedupWriterSuppressesRepeats(t *testing.T) {
+	dw, mem, clock := newTestDedup(t, DedupOptions{Window: time.Minute})
+	start := clock.Now()
+
+	for i := 0; i < 4; i++ {
+		e := errorEntry("db.query", "timeout")
+		e.Context["attempt"] = i
+		if err := dw.Write(e); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		clock.Advance(10 * time.Second)
+	}
+	if got := len(mem.written()); got != 1 {
+		t.Fatalf("期間内に書き込まれた件数 = %d, want 1", got)
+	}
+
+	// 期間が過ぎるまではロールアップを書き込まない
+	if err := dw.writeRollups(dw.collect(false)); err != nil {
+		t.Fatalf("writeRollups: %v", err)
+	}
+	if got := rollups(mem); len(got) != 0 {
+		t.Fatalf("期間内にロールアップが書き込まれました: %v", got)
+	}
+
+	clock.Advance(30 * time.Second)
+	if err := dw.writeRollups(dw.collect(false)); err != nil {
+		t.Fatalf("writeRollups: %v", err)
+	}
+	got := rollups(mem)
+	if len(got) != 1 {
+		t.Fatalf("ロールアップの件数 = %d, want 1", len(got))
+	}
+	rollup := got[0]
+	if rollup.Context["repeat_count"] != 3 {
+		t.Errorf("repeat_count = %v, want 3", rollup.Context["repeat_count"])
+	}
+	if first := rollup.Context["first_seen"].(time.Time); !first.Equal(start) {
+		t.Errorf("first_seen = %v, want %v", first, start)
+	}
+	if last := rollup.Context["last_seen"].(time.Time); !last.Equal(start.Add(30 * time.Second)) {
+		t.Errorf("last_seen = %v, want %v", last, start.Add(30*time.Second))
+	}
+	// ロールアップは最初のエントリの内容を引き継ぎ、元のエントリは変更しない
+	if rollup.Operation != "db.query" || rollup.Context["attempt"] != 0 {
+		t.Errorf("ロールアップ = %+v", rollup)
+	}
+	if _, ok := mem.written()[0].Context["repeat_count"]; ok {
+		t.Error("最初のエントリのContextが変更されました")
+	}
+
+	// 状態は取り除かれ、次のエントリはそのまま書き込まれる
+	if err := dw.Write(errorEntry("db.query", "timeout")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got := len(mem.written()); got != 3 {
+		t.Errorf("書き込まれた件数 = %d, want 3", got)
+	}
+}
+
+func TestDedupWriterWritesRollupOnNextEntry(t *testing.T) {
+	dw, mem, clock := newTestDedup(t, DedupOptions{Window: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		if err := dw.Write(errorEntry("op", "failed")); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	clock.Advance(time.Minute)
+	if err := dw.Write(errorEntry("op", "failed")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	written := mem.written()
+	if len(written) != 3 {
+		t.Fatalf("書き込まれた件数 = %d, want 3（最初のエントリ、ロールアップ、新しいエントリ）", len(written))
+	}
+	if written[1].Metadata["dedup_rollup"] != true || written[1].Context["repeat_count"] != 2 {
+		t.Errorf("2件目 = %+v, want repeat_count 2のロールアップ", written[1])
+	}
+	if written[2].Metadata["dedup_rollup"] == true {
+		t.Error("3件目がロールアップになっています")
+	}
+}
+
+func TestDedupWriterDefaultLevels(t *testing.T) {
+	dw, mem, _ := newTestDedup(t, DedupOptions{})
+
+	for _, level := range []LogLevel{DEBUG, INFO, WARN, ERROR, FATAL} {
+		for i := 0; i < 3; i++ {
+			if err := dw.Write(&Entry{Level: level, Operation: level.String()}); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+		}
+	}
+
+	// 既定ではERRORとFATALのみを抑制する
+	counts := make(map[string]int)
+	for _, e := range mem.written() {
+		counts[e.Operation]++
+	}
+	want := map[string]int{"DEBUG": 3, "INFO": 3, "WARN": 3, "ERROR": 1, "FATAL": 1}
+	for op, n := range want {
+		if counts[op] != n {
+			t.Errorf("%sの書き込み件数 = %d, want %d", op, counts[op], n)
+		}
+	}
+}
+
+func TestDedupWriterMaxKeys(t *testing.T) {
+	dw, mem, _ := newTestDedup(t, DedupOptions{MaxKeys: 1})
+
+	for i := 0; i < 2; i++ {
+		for _, op := range []string{"tracked", "untracked"} {
+			if err := dw.Write(errorEntry(op, "failed")); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+		}
+	}
+	// 上限を超えたフィンガープリントは抑制せずに書き込む
+	if got := mem.operations(); !equalStrings(got, []string{"tracked", "untracked", "untracked"}) {
+		t.Errorf("書き込まれた操作 = %v", got)
+	}
+}
+
+func TestDedupWriterFlushAndClose(t *testing.T) {
+	dw, mem, _ := newTestDedup(t, DedupOptions{})
+
+	for i := 0; i < 3; i++ {
+		if err := dw.Write(errorEntry("op", "failed")); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	// Flushは期間中のものも含めて書き込む
+	if err := dw.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got := rollups(mem); len(got) != 1 || got[0].Context["repeat_count"] != 2 {
+		t.Fatalf("Flush後のロールアップ = %v", got)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := dw.Write(errorEntry("op", "failed")); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := dw.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := rollups(mem); len(got) != 2 || got[1].Context["repeat_count"] != 1 {
+		t.Errorf("Close後のロールアップ = %v", got)
+	}
+	if mem.closeCount() != 1 {
+		t.Errorf("Closeの回数 = %d, want 1", mem.closeCount())
+	}
+}
+
+func TestDefaultFingerprint(t *testing.T) {
+	withError := func(message string, ctx map[string]interface{}) *Entry {
+		e := errorEntry("op", message)
+		e.Context = ctx
+		return e
+	}
+	handled := func(message string, ctx map[string]interface{}) *Entry {
+		c := map[string]interface{}{"error_type": "*errors.errorString", "error_message": message}
+		for k, v := range ctx {
+			c[k] = v
+		}
+		return &Entry{Level: ERROR, Operation: "op", Context: c}
+	}
+	plain := func(ctx map[string]interface{}) *Entry {
+		return &Entry{Level: ERROR, Operation: "op", Context: ctx}
+	}
+
+	tests := []struct {
+		name string
+		a, b *Entry
+		same bool
+	}{
+		{"エラーが同じならContextは無視", withError("timeout", map[string]interface{}{"id": 1}), withError("timeout", map[string]interface{}{"id": 2}), true},
+		{"エラーメッセージが異なる", withError("timeout", nil), withError("refused", nil), false},
+		{"ContextのエラーならContextの他の値は無視", handled("timeout", map[string]interface{}{"id": 1}), handled("timeout", map[string]interface{}{"id": 2}), true},
+		{"Contextのエラーメッセージが異なる", handled("timeout", nil), handled("refused", nil), false},
+		{"エラーがなければContextを含める", plain(map[string]interface{}{"id": 1}), plain(map[string]interface{}{"id": 2}), false},
+		{"エラーがなくContextが同じ", plain(map[string]interface{}{"id": 1, "x": "a"}), plain(map[string]interface{}{"x": "a", "id": 1}), true},
+		{"レベルが異なる", withError("timeout", nil), &Entry{Level: FATAL, Operation: "op", Error: &ErrorInfo{Type: "*errors.errorString", Message: "timeout"}}, false},
+		{
+			"呼び出し元が異なる",
+			&Entry{Level: ERROR, Operation: "op", Metadata: map[string]interface{}{"caller": "a.go:1"}},
+			&Entry{Level: ERROR, Operation: "op", Metadata: map[string]interface{}{"caller": "a.go:2"}},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefaultFingerprint(tt.a) == DefaultFingerprint(tt.b); got != tt.same {
+				t.Errorf("一致 = %v, want %v\n%q\n%q", got, tt.same, DefaultFingerprint(tt.a), DefaultFingerprint(tt.b))
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package logger
+
+import "time"
+
+// entryField はContextではなくEntryの専用フィールドへ値を設定するField値
+//
+// vibeLogger.log はField.Valueがこの型の場合、Contextへ格納する代わりに
+// 関数を適用してAction/Input/Output/Error/DurationなどをEntryへ直接設定する。
+type entryField func(*Entry)
+
+// actionField はEntry.Actionを設定するフィールドを作成する
+func actionField(action ActionType) Field {
+	return Field{Key: "action", Value: entryField(func(e *Entry) {
+		e.Action = action
+	})}
+}
+
+// inputField はEntry.Inputを設定するフィールドを作成する
+func inputField(input map[string]interface{}) Field {
+	return Field{Key: "input", Value: entryField(func(e *Entry) {
+		e.Input = input
+	})}
+}
+
+// outputField はEntry.Outputを設定するフィールドを作成する
+func outputField(output map[string]interface{}) Field {
+	return Field{Key: "output", Value: entryField(func(e *Entry) {
+		e.Output = output
+	})}
+}
+
+// errorInfoField はEntry.Errorを設定するフィールドを作成する
+func errorInfoField(errorInfo *ErrorInfo) Field {
+	return Field{Key: "error", Value: entryField(func(e *Entry) {
+		e.Error = errorInfo
+	})}
+}
+
+// durationField はEntry.Durationを設定するフィールドを作成する
+func durationField(duration time.Duration) Field {
+	return Field{Key: "duration", Value: entryField(func(e *Entry) {
+		e.Duration = duration
+	})}
+}
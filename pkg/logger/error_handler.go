@@ -1,9 +1,12 @@
 package logger
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -21,6 +24,12 @@ func NewErrorHandler(logger Logger) *ErrorHandler {
 
 // HandleError はエラーを処理し、適切なログを出力する
 func (eh *ErrorHandler) HandleError(err error, context map[string]interface{}, options ...ErrorOption) {
+	eh.handleError(err, context, options...)
+}
+
+// handleError はHandleErrorの処理を行う
+// 呼び出し元の情報とスタックトレースは、handleErrorを呼び出した関数のさらに呼び出し元を指す
+func (eh *ErrorHandler) handleError(err error, context map[string]interface{}, options ...ErrorOption) {
 	if err == nil {
 		return
 	}
@@ -30,7 +39,7 @@ func (eh *ErrorHandler) HandleError(err error, context map[string]interface{}, o
 		Type:      fmt.Sprintf("%T", err),
 		Retryable: false,
 		Context:   context,
-		Stack:     eh.getStackTrace(2),
+		Stack:     eh.getStackTrace(3),
 	}
 
 	// オプションを適用
@@ -45,7 +54,8 @@ func (eh *ErrorHandler) HandleError(err error, context map[string]interface{}, o
 		String("resolution", errorInfo.Resolution),
 		String("stack_trace", errorInfo.Stack),
 		Any("context", errorInfo.Context),
-		Any("retryable", errorInfo.Retryable))
+		Any("retryable", errorInfo.Retryable),
+		callerOf(3))
 }
 
 // HandlePanic はパニックを処理する
@@ -62,10 +72,13 @@ func (eh *ErrorHandler) HandlePanic(recovered interface{}, context map[string]in
 		Stack:     eh.getStackTrace(2),
 	}
 
+	// 回復済みのpanicのため、FatalBehaviorは適用しない
 	eh.logger.Fatal("panic_recovered",
 		String("panic_value", fmt.Sprintf("%v", recovered)),
 		String("stack_trace", errorInfo.Stack),
-		Any("context", errorInfo.Context))
+		Any("context", errorInfo.Context),
+		fatalLogOnlyField(),
+		callerOf(2))
 }
 
 // HandleRetryableError はリトライ可能なエラーを処理する
@@ -87,6 +100,7 @@ func (eh *ErrorHandler) HandleRetryableError(err error, attempt int, maxAttempts
 }
 
 // HandleFatalError は致命的なエラーを処理する
+// ログを出力してshutdownFuncを呼び出した後、ロガーのFatalOptionsに従って終了またはpanicする
 func (eh *ErrorHandler) HandleFatalError(err error, context map[string]interface{}, shutdownFunc func()) {
 	if err == nil {
 		return
@@ -104,11 +118,16 @@ func (eh *ErrorHandler) HandleFatalError(err error, context map[string]interface
 		String("error_type", errorInfo.Type),
 		String("error_message", errorInfo.Message),
 		String("stack_trace", errorInfo.Stack),
-		Any("context", errorInfo.Context))
+		Any("context", errorInfo.Context),
+		fatalLogOnlyField())
 
 	if shutdownFunc != nil {
 		shutdownFunc()
 	}
+
+	if t, ok := eh.logger.(fatalTerminator); ok {
+		t.terminateFatal("fatal_error")
+	}
 }
 
 // HandleRecovery はリカバリーを処理する
@@ -120,6 +139,17 @@ func (eh *ErrorHandler) HandleRecovery(originalErr error, recoveryAction string,
 		Any("context", context))
 }
 
+// callerOf は呼び出し元の情報を設定するフィールドを作成する
+// skipは0がcallerOf自身、1がcallerOfを呼び出した関数を指す
+// ロガーが記録する呼び出し元はエラーハンドラー内の行となるため、実際の呼び出し元で上書きする
+func callerOf(skip int) Field {
+	var pcs [1]uintptr
+	if runtime.Callers(skip+1, pcs[:]) == 0 {
+		return Field{Key: "caller", Value: entryField(func(*Entry) {})}
+	}
+	return callerField(pcs[0])
+}
+
 // getStackTrace はスタックトレースを取得する
 func (eh *ErrorHandler) getStackTrace(skip int) string {
 	var stack []string
@@ -173,6 +203,10 @@ func WithContext(key string, value interface{}) ErrorOption {
 type RetryHandler struct {
 	logger       Logger
 	errorHandler *ErrorHandler
+
+	// 操作名ごとのサーキットブレーカー
+	breakers   map[string]*CircuitBreaker
+	breakersMu sync.Mutex
 }
 
 // NewRetryHandler は新しいリトライハンドラーを作成する
@@ -180,60 +214,49 @@ func NewRetryHandler(logger Logger) *RetryHandler {
 	return &RetryHandler{
 		logger:       logger,
 		errorHandler: NewErrorHandler(logger),
+		breakers:     make(map[string]*CircuitBreaker),
 	}
 }
 
 // ExecuteWithRetry は指定した関数をリトライ付きで実行する
-func (rh *RetryHandler) ExecuteWithRetry(operation string, fn func() error, maxAttempts int, backoff time.Duration, context map[string]interface{}) error {
-	var lastErr error
-
-	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		rh.logger.Info(operation,
-			String("action", "ATTEMPT"),
-			Int("attempt", attempt),
-			Int("max_attempts", maxAttempts),
-			Any("context", context))
-
-		err := fn()
-		if err == nil {
-			// 成功
-			rh.logger.Info(operation,
-				String("action", "SUCCESS"),
-				Int("attempt", attempt),
-				Any("context", context))
-			return nil
-		}
-
-		lastErr = err
+// 待機時間はbackoff×試行回数の線形バックオフとなる
+// maxAttemptsが0以下の場合は関数を実行せずにnilを返す（RetryPolicyのデフォルト値は適用しない）
+// キャンセルやバックオフ戦略の指定が必要な場合はExecuteWithRetryContextを使用する
+func (rh *RetryHandler) ExecuteWithRetry(operation string, fn func() error, maxAttempts int, backoff time.Duration, fields map[string]interface{}) error {
+	if maxAttempts <= 0 {
+		return nil
+	}
 
-		if attempt < maxAttempts {
-			// リトライ
-			nextRetryIn := backoff * time.Duration(attempt)
-			rh.errorHandler.HandleRetryableError(err, attempt, maxAttempts, nextRetryIn, context)
-			time.Sleep(nextRetryIn)
-		} else {
-			// 最大試行回数に達した
-			rh.errorHandler.HandleError(err, context,
-				WithErrorCode("MAX_RETRIES_EXCEEDED"),
-				WithResolution("手動での対応が必要です"))
-		}
+	policy := RetryPolicy{
+		MaxAttempts: maxAttempts,
+		Backoff:     LinearBackoff{Initial: backoff, Increment: backoff},
 	}
 
-	return lastErr
+	return rh.ExecuteWithRetryContext(context.Background(), operation, func(context.Context) error {
+		return fn()
+	}, policy, fields)
 }
 
 // ExecuteWithCircuitBreaker はサーキットブレーカー付きで実行する
+// サーキットブレーカーは操作名ごとに保持され、連続してfailureThreshold回失敗すると
+// 一定時間呼び出しを遮断して*ErrCircuitOpenを返す
+// failureThresholdはその操作名で最初に呼び出したときの値が使われ、以降の呼び出しで異なる値を指定しても変更されない
+// （記録されるfailure_thresholdは実際に使われている値）
 func (rh *RetryHandler) ExecuteWithCircuitBreaker(operation string, fn func() error, failureThreshold int, context map[string]interface{}) error {
-	// シンプルなサーキットブレーカーの実装
-	// 実際の実装では、より洗練された状態管理が必要
+	cb := rh.CircuitBreaker(operation, CircuitBreakerOptions{FailureThreshold: failureThreshold})
 
 	rh.logger.Info(operation,
-		String("action", "CIRCUIT_BREAKER_EXECUTE"),
-		Int("failure_threshold", failureThreshold),
+		actionField(ActionCircuitExecute),
+		String("circuit_state", cb.State().String()),
+		Int("failure_threshold", cb.opts.FailureThreshold),
 		Any("context", context))
 
-	err := fn()
+	err := cb.Execute(fn)
 	if err != nil {
+		var openErr *ErrCircuitOpen
+		if errors.As(err, &openErr) {
+			return err
+		}
 		rh.errorHandler.HandleError(err, context,
 			WithErrorCode("CIRCUIT_BREAKER_FAILURE"),
 			WithResolution("サーキットブレーカーが作動しました"))
@@ -242,6 +265,21 @@ func (rh *RetryHandler) ExecuteWithCircuitBreaker(operation string, fn func() er
 	return err
 }
 
+// CircuitBreaker は操作名に対応するサーキットブレーカーを取得する
+// 存在しない場合は指定した設定で作成し、以降は同じインスタンスを返す
+func (rh *RetryHandler) CircuitBreaker(operation string, opts CircuitBreakerOptions) *CircuitBreaker {
+	rh.breakersMu.Lock()
+	defer rh.breakersMu.Unlock()
+
+	if cb, ok := rh.breakers[operation]; ok {
+		return cb
+	}
+
+	cb := NewCircuitBreaker(rh.logger, operation, opts)
+	rh.breakers[operation] = cb
+	return cb
+}
+
 // RecoveryHandler はリカバリー処理に特化したハンドラー
 type RecoveryHandler struct {
 	logger       Logger
@@ -328,7 +366,7 @@ func (veh *VibeErrorHandler) HandleCodingError(err error, codeFile string, lineN
 		"code_snippet":   codeSnippet,
 	}
 
-	veh.HandleError(err, context,
+	veh.handleError(err, context,
 		WithErrorCode("CODING_ERROR"),
 		WithResolution(resolution))
 }
@@ -343,7 +381,7 @@ func (veh *VibeErrorHandler) HandleTestError(err error, testName string, testOut
 		"expected_vs_actual": expectedVsActual,
 	}
 
-	veh.HandleError(err, context,
+	veh.handleError(err, context,
 		WithErrorCode("TEST_ERROR"),
 		WithResolution("テストコードまたは実装コードの修正が必要です"))
 }
@@ -357,7 +395,7 @@ func (veh *VibeErrorHandler) HandleBuildError(err error, buildOutput string, dep
 		"dependencies":   dependencies,
 	}
 
-	veh.HandleError(err, context,
+	veh.handleError(err, context,
 		WithErrorCode("BUILD_ERROR"),
 		WithResolution("依存関係の確認またはビルド設定の修正が必要です"))
 }
@@ -372,7 +410,7 @@ func (veh *VibeErrorHandler) HandleLogicError(err error, expectation string, act
 		"debug_info":     debugInfo,
 	}
 
-	veh.HandleError(err, context,
+	veh.handleError(err, context,
 		WithErrorCode("LOGIC_ERROR"),
 		WithResolution("アルゴリズムまたはロジックの見直しが必要です"))
 }
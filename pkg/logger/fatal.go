@@ -0,0 +1,132 @@
+package logger
+
+import (
+	"os"
+	"runtime"
+)
+
+// FatalBehavior はFATALレベルのログ出力後の挙動を表す
+type FatalBehavior int
+
+const (
+	// FatalLogOnly はログを出力するのみで処理を続ける（デフォルト）
+	FatalLogOnly FatalBehavior = iota
+	// FatalExit はすべてのWriterの内容を出力した後、ExitCodeで終了する
+	FatalExit
+	// FatalPanic はすべてのWriterの内容を出力した後、メッセージでpanicする
+	FatalPanic
+)
+
+// String はFatalBehaviorを文字列に変換する
+func (b FatalBehavior) String() string {
+	switch b {
+	case FatalLogOnly:
+		return "LOG_ONLY"
+	case FatalExit:
+		return "EXIT"
+	case FatalPanic:
+		return "PANIC"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// FatalOptions はFatalの挙動の設定です
+type FatalOptions struct {
+	Behavior FatalBehavior  // FATALレベルのログ出力後の挙動
+	ExitCode int            // FatalExit時の終了コード（0の場合は1）
+	ExitFunc func(code int) // FatalExit時に呼ばれる終了関数（nilの場合はos.Exit、テスト用に差し替え可能）
+
+	// IncludeGoroutineDump はすべてのゴルーチンのスタックをMetadata["goroutine_dump"]に記録する
+	IncludeGoroutineDump bool
+	// IncludeRuntimeStats はGetRuntimeStatsのスナップショットをRuntimeInfoに記録する
+	IncludeRuntimeStats bool
+}
+
+// maxGoroutineDumpSize はゴルーチンダンプの最大サイズ
+const maxGoroutineDumpSize = 1 << 20
+
+// fatalLogOnlyKey はFatalBehaviorを適用しないことを示すフィールドのキー
+const fatalLogOnlyKey = "__fatal_log_only"
+
+// fatalLogOnlyField はFATALレベルで記録するがFatalBehaviorを適用しないことを示すフィールドを作成する
+// 回復済みのpanicの記録や、終了前に後処理を挟む場合に使う
+func fatalLogOnlyField() Field {
+	return Field{Key: fatalLogOnlyKey, Value: entryField(func(*Entry) {})}
+}
+
+// hasFatalLogOnlyField はフィールドにfatalLogOnlyFieldが含まれるかどうかを判定する
+func hasFatalLogOnlyField(fields []Field) bool {
+	for _, field := range fields {
+		if field.Key == fatalLogOnlyKey {
+			if _, ok := field.Value.(entryField); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fatalTerminator はFatalBehaviorを適用できるロガー
+type fatalTerminator interface {
+	terminateFatal(msg string)
+}
+
+// SetFatalOptions はFatalの挙動を設定する
+func (l *vibeLogger) SetFatalOptions(opts FatalOptions) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.fatal = opts
+}
+
+// fatalFields はFatalOptionsに従ってFATALエントリに追加するフィールドを作成する
+func (l *vibeLogger) fatalFields(opts FatalOptions) []Field {
+	var fields []Field
+	if opts.IncludeGoroutineDump {
+		fields = append(fields, Field{Key: "goroutine_dump", Value: entryField(func(e *Entry) {
+			e.Metadata["goroutine_dump"] = goroutineDump()
+		})})
+	}
+	if opts.IncludeRuntimeStats {
+		fields = append(fields, Field{Key: "runtime_info", Value: entryField(func(e *Entry) {
+			e.RuntimeInfo = l.systemInfoCollector.GetRuntimeStats()
+		})})
+	}
+	return fields
+}
+
+// terminateFatal はすべてのWriterの内容を出力してからFatalBehaviorを適用する
+func (l *vibeLogger) terminateFatal(msg string) {
+	l.mu.RLock()
+	opts := l.fatal
+	l.mu.RUnlock()
+
+	switch opts.Behavior {
+	case FatalExit:
+		_ = l.Sync()
+		exit := opts.ExitFunc
+		if exit == nil {
+			exit = os.Exit
+		}
+		code := opts.ExitCode
+		if code == 0 {
+			code = 1
+		}
+		exit(code)
+	case FatalPanic:
+		_ = l.Sync()
+		panic(msg)
+	}
+}
+
+// goroutineDump はすべてのゴルーチンのスタックを取得する
+func goroutineDump() string {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= maxGoroutineDumpSize {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
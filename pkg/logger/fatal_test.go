@@ -0,0 +1,145 @@
+package logger
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// eventWriter は書き込み・Flush・終了の順序を記録するテスト用のWriter
+type eventWriter struct {
+	memoryWriter
+	eventsMu sync.Mutex
+	events   []string
+}
+
+func (w *eventWriter) record(event string) {
+	w.eventsMu.Lock()
+	defer w.eventsMu.Unlock()
+	w.events = append(w.events, event)
+}
+
+func (w *eventWriter) Write(entry *Entry) error {
+	w.record("write")
+	return w.memoryWriter.Write(entry)
+}
+
+func (w *eventWriter) Flush() error {
+	w.record("flush")
+	return nil
+}
+
+func (w *eventWriter) recorded() []string {
+	w.eventsMu.Lock()
+	defer w.eventsMu.Unlock()
+	return append([]string(nil), w.events...)
+}
+
+// newFatalLogger はFatalOptionsを設定したロガーを作成する
+// ExitFuncが未設定の場合は終了コードを記録する関数を設定する
+func newFatalLogger(t *testing.T, opts FatalOptions) (Logger, *eventWriter) {
+	t.Helper()
+	w := &eventWriter{}
+	l := New(DEBUG)
+	l.AddWriter(w)
+	if opts.ExitFunc == nil {
+		opts.ExitFunc = func(code int) { t.Fatalf("予期しない終了: %d", code) }
+	}
+	l.(FatalConfigurableLogger).SetFatalOptions(opts)
+	return l, w
+}
+
+func TestFatalLogOnly(t *testing.T) {
+	l, w := newFatalLogger(t, FatalOptions{})
+
+	l.Fatal("shutdown")
+	if got := w.operations(); !equalStrings(got, []string{"shutdown"}) {
+		t.Errorf("書き込まれた操作 = %v", got)
+	}
+	if got := w.written()[0].Level; got != FATAL {
+		t.Errorf("レベル = %s, want FATAL", got)
+	}
+}
+
+func TestFatalExit(t *testing.T) {
+	tests := []struct {
+		name     string
+		exitCode int
+		want     string
+	}{
+		{"ExitCode未設定は1", 0, "exit:1"},
+		{"ExitCode指定", 3, "exit:3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var w *eventWriter
+			var l Logger
+			l, w = newFatalLogger(t, FatalOptions{
+				Behavior: FatalExit,
+				ExitCode: tt.exitCode,
+				ExitFunc: func(code int) {
+					w.record("exit:" + strconv.Itoa(code))
+				},
+			})
+
+			l.Fatal("shutdown")
+			// 書き込んだ内容をFlushしてから終了する
+			if got := w.recorded(); !equalStrings(got, []string{"write", "flush", tt.want}) {
+				t.Errorf("順序 = %v, want [write flush %s]", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFatalPanic(t *testing.T) {
+	l, w := newFatalLogger(t, FatalOptions{Behavior: FatalPanic})
+
+	func() {
+		defer func() {
+			if r := recover(); r != "shutdown" {
+				t.Errorf("recover = %v, want shutdown", r)
+			}
+		}()
+		l.Fatal("shutdown")
+		t.Error("FatalPanicでpanicしませんでした")
+	}()
+	if got := w.recorded(); !equalStrings(got, []string{"write", "flush"}) {
+		t.Errorf("順序 = %v, want [write flush]", got)
+	}
+}
+
+func TestFatalLogOnlyFieldSkipsBehavior(t *testing.T) {
+	l, w := newFatalLogger(t, FatalOptions{Behavior: FatalPanic})
+
+	// 回復済みのpanicの記録などはFATALで出力するが、panicしない
+	l.Fatal("recovered", fatalLogOnlyField())
+	if got := w.operations(); !equalStrings(got, []string{"recovered"}) {
+		t.Errorf("書き込まれた操作 = %v", got)
+	}
+	if _, ok := w.written()[0].Context[fatalLogOnlyKey]; ok {
+		t.Error("内部用のフィールドがContextに出力されました")
+	}
+}
+
+func TestFatalAttachesDiagnostics(t *testing.T) {
+	l, w := newFatalLogger(t, FatalOptions{IncludeGoroutineDump: true, IncludeRuntimeStats: true})
+
+	l.Fatal("shutdown")
+	entry := w.written()[0]
+	dump, _ := entry.Metadata["goroutine_dump"].(string)
+	if !strings.Contains(dump, "goroutine ") || !strings.Contains(dump, "TestFatalAttachesDiagnostics") {
+		t.Errorf("goroutine_dumpに現在のゴルーチンのスタックが含まれていません: %.200s", dump)
+	}
+	for _, key := range []string{"goroutines", "heap_alloc", "gc_runs"} {
+		if _, ok := entry.RuntimeInfo[key]; !ok {
+			t.Errorf("RuntimeInfoに%sが含まれていません: %v", key, entry.RuntimeInfo)
+		}
+	}
+
+	// FATAL以外のエントリには付与しない
+	l.Error("failed")
+	if entry := w.written()[1]; entry.Metadata["goroutine_dump"] != nil || entry.RuntimeInfo != nil {
+		t.Errorf("ERRORエントリに診断情報が付与されました: %+v", entry)
+	}
+}
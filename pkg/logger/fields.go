@@ -0,0 +1,380 @@
+package logger
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// FieldType はFieldが値を保持している形式
+type FieldType uint8
+
+const (
+	// AnyType はValueに値を保持する（Field{Key: key, Value: value}で作成したフィールドを含む）
+	AnyType FieldType = iota
+	// StringType はStringに文字列を保持する
+	StringType
+	// IntType はIntegerにintを保持する
+	IntType
+	// Int64Type はIntegerにint64を保持する
+	Int64Type
+	// Uint64Type はIntegerにuint64のビット列を保持する
+	Uint64Type
+	// Float64Type はIntegerにfloat64のビット列を保持する
+	Float64Type
+	// BoolType はIntegerに真偽値を1または0で保持する
+	BoolType
+	// DurationType はIntegerにtime.Durationを保持する
+	DurationType
+	// TimeType はIntegerにUnixナノ秒、Valueに*time.Locationを保持する
+	TimeType
+)
+
+// Bool は真偽値フィールドを作成する
+func Bool(key string, value bool) Field {
+	var i int64
+	if value {
+		i = 1
+	}
+	return Field{Key: key, Type: BoolType, Integer: i}
+}
+
+// Int64 は64ビット整数フィールドを作成する
+func Int64(key string, value int64) Field {
+	return Field{Key: key, Type: Int64Type, Integer: value}
+}
+
+// Uint64 は符号なし64ビット整数フィールドを作成する
+func Uint64(key string, value uint64) Field {
+	return Field{Key: key, Type: Uint64Type, Integer: int64(value)}
+}
+
+// Float64 は浮動小数点数フィールドを作成する
+func Float64(key string, value float64) Field {
+	return Field{Key: key, Type: Float64Type, Integer: int64(math.Float64bits(value))}
+}
+
+// Time は時刻フィールドを作成する
+// UnixNanoで表せない時刻（1678年より前や2262年より後）はValueに保持する
+func Time(key string, value time.Time) Field {
+	if value.Before(minTimeNano) || value.After(maxTimeNano) {
+		return Field{Key: key, Value: value}
+	}
+	return Field{Key: key, Type: TimeType, Integer: value.UnixNano(), Value: value.Location()}
+}
+
+// Stringer はfmt.Stringerのフィールドを作成する
+// String()はレベルが有効な場合にのみ呼び出され、nilの場合はフィールドを出力しない
+func Stringer(key string, value fmt.Stringer) Field {
+	if value == nil {
+		return Skip()
+	}
+	return Field{Key: key, Value: lazyValue(func() interface{} {
+		return safeString(value)
+	})}
+}
+
+// Strings は文字列スライスのフィールドを作成する（スライスはコピーされる）
+func Strings(key string, values []string) Field {
+	copied := make([]string, len(values))
+	copy(copied, values)
+	return Field{Key: key, Value: copied}
+}
+
+// Bytes はUTF-8のバイト列を文字列として出力するフィールドを作成する
+func Bytes(key string, value []byte) Field {
+	return Field{Key: key, Type: StringType, String: string(value)}
+}
+
+// Binary はバイナリデータのフィールドを作成する（JSONではBase64で出力される、データはコピーされる）
+func Binary(key string, value []byte) Field {
+	copied := make([]byte, len(value))
+	copy(copied, value)
+	return Field{Key: key, Value: copied}
+}
+
+// NamedError は任意のキーでエラーフィールドを作成する（nilの場合はフィールドを出力しない）
+func NamedError(key string, err error) Field {
+	if err == nil {
+		return Skip()
+	}
+	return Field{Key: key, Type: StringType, String: err.Error()}
+}
+
+// Skip は何も出力しないフィールドを作成する
+func Skip() Field {
+	return Field{Value: entryField(func(*Entry) {})}
+}
+
+// Lazy は値をレベルが有効な場合にのみ計算するフィールドを作成する
+// 無効なレベルのログでは関数は呼び出されない
+func Lazy(key string, fn func() interface{}) Field {
+	return Field{Key: key, Value: lazyValue(fn)}
+}
+
+// Object はObjectMarshalerを構造化された値として出力するフィールドを作成する
+// MarshalLogObjectはレベルが有効な場合にのみ呼び出される
+func Object(key string, value ObjectMarshaler) Field {
+	if value == nil {
+		return Skip()
+	}
+	return Field{Key: key, Value: value}
+}
+
+// Group は複数のフィールドをkeyの下にネストしたフィールドを作成する
+func Group(key string, fields ...Field) Field {
+	return Field{Key: key, Value: groupValue(fields)}
+}
+
+// Namespace は以降のフィールドをkeyの下にネストするフィールドを作成する
+//
+//	logger.Info("request", logger.String("method", "GET"), logger.Namespace("user"), logger.Int("id", 1))
+//	// Context: {"method": "GET", "user": {"id": 1}}
+func Namespace(key string) Field {
+	return Field{Key: key, Value: namespaceMarker{}}
+}
+
+// UnixNanoで表せる時刻の範囲
+var (
+	minTimeNano = time.Unix(0, math.MinInt64)
+	maxTimeNano = time.Unix(0, math.MaxInt64)
+)
+
+// AddTo はフィールドをencに書き込む
+// 型付きのフィールドはinterface{}に変換せず、型ごとのメソッドで書き込む
+// ObjectMarshalerの実装から、受け取ったフィールドをそのまま書き込む場合などに使う
+func (f Field) AddTo(enc ObjectEncoder) {
+	switch f.Type {
+	case StringType:
+		enc.AddString(f.Key, f.String)
+	case IntType:
+		enc.AddInt(f.Key, int(f.Integer))
+	case Int64Type:
+		enc.AddInt64(f.Key, f.Integer)
+	case Uint64Type:
+		enc.AddUint64(f.Key, uint64(f.Integer))
+	case Float64Type:
+		enc.AddFloat64(f.Key, math.Float64frombits(uint64(f.Integer)))
+	case BoolType:
+		enc.AddBool(f.Key, f.Integer == 1)
+	case DurationType:
+		enc.AddDuration(f.Key, time.Duration(f.Integer))
+	case TimeType:
+		enc.AddTime(f.Key, f.time())
+	default:
+		switch v := f.Value.(type) {
+		case entryField, namespaceMarker:
+			// 専用フィールド向けの値とNamespaceはエンコーダーには書き込まない
+		default:
+			enc.AddAny(f.Key, resolveFieldValue(v))
+		}
+	}
+}
+
+// value はフィールドの値を返す（型付きのフィールドはここで初めてinterface{}に変換する）
+func (f Field) value() interface{} {
+	switch f.Type {
+	case StringType:
+		return f.String
+	case IntType:
+		return int(f.Integer)
+	case Int64Type:
+		return f.Integer
+	case Uint64Type:
+		return uint64(f.Integer)
+	case Float64Type:
+		return math.Float64frombits(uint64(f.Integer))
+	case BoolType:
+		return f.Integer == 1
+	case DurationType:
+		return time.Duration(f.Integer)
+	case TimeType:
+		return f.time()
+	default:
+		return f.Value
+	}
+}
+
+// time はTimeTypeのフィールドの時刻を返す
+func (f Field) time() time.Time {
+	t := time.Unix(0, f.Integer)
+	if loc, ok := f.Value.(*time.Location); ok {
+		t = t.In(loc)
+	}
+	return t
+}
+
+// ObjectMarshaler は構造化されたフィールドとして自身を出力できる型を定義する
+// Anyで渡した値がこのインターフェースを実装する場合もMarshalLogObjectで出力される
+type ObjectMarshaler interface {
+	MarshalLogObject(enc ObjectEncoder) error
+}
+
+// ObjectMarshalerFunc は関数をObjectMarshalerとして扱うためのアダプター
+type ObjectMarshalerFunc func(enc ObjectEncoder) error
+
+// MarshalLogObject は関数を呼び出す
+func (f ObjectMarshalerFunc) MarshalLogObject(enc ObjectEncoder) error {
+	return f(enc)
+}
+
+// ObjectEncoder はObjectMarshalerが値を書き込む先を定義する
+type ObjectEncoder interface {
+	AddString(key, value string)
+	AddBool(key string, value bool)
+	AddInt(key string, value int)
+	AddInt64(key string, value int64)
+	AddUint64(key string, value uint64)
+	AddFloat64(key string, value float64)
+	AddTime(key string, value time.Time)
+	AddDuration(key string, value time.Duration)
+	AddStrings(key string, values []string)
+	AddObject(key string, value ObjectMarshaler) error
+	AddAny(key string, value interface{})
+}
+
+// lazyValue はレベルが有効な場合にのみ計算される値
+type lazyValue func() interface{}
+
+// groupValue はネストしたフィールドの集まり
+type groupValue []Field
+
+// namespaceMarker は以降のフィールドをネストすることを示す値
+type namespaceMarker struct{}
+
+// mapObjectEncoder はマップに値を書き込むObjectEncoder
+type mapObjectEncoder map[string]interface{}
+
+func (m mapObjectEncoder) AddString(key, value string)                 { m[key] = value }
+func (m mapObjectEncoder) AddBool(key string, value bool)              { m[key] = value }
+func (m mapObjectEncoder) AddInt(key string, value int)                { m[key] = value }
+func (m mapObjectEncoder) AddInt64(key string, value int64)            { m[key] = value }
+func (m mapObjectEncoder) AddUint64(key string, value uint64)          { m[key] = value }
+func (m mapObjectEncoder) AddFloat64(key string, value float64)        { m[key] = value }
+func (m mapObjectEncoder) AddTime(key string, value time.Time)         { m[key] = value }
+func (m mapObjectEncoder) AddDuration(key string, value time.Duration) { m[key] = value }
+func (m mapObjectEncoder) AddAny(key string, value interface{})        { m[key] = resolveFieldValue(value) }
+
+// AddStrings は文字列スライスを書き込む（スライスはコピーされる）
+func (m mapObjectEncoder) AddStrings(key string, values []string) {
+	copied := make([]string, len(values))
+	copy(copied, values)
+	m[key] = copied
+}
+
+// AddObject はネストしたオブジェクトを書き込む
+func (m mapObjectEncoder) AddObject(key string, value ObjectMarshaler) error {
+	nested := make(mapObjectEncoder)
+	err := value.MarshalLogObject(nested)
+	m[key] = map[string]interface{}(nested)
+	return err
+}
+
+// applyFields はフィールドをエントリに適用する
+// 専用フィールド向けの値はEntryへ直接設定し、その他の値は遅延評価やObjectMarshalerを解決してContextに格納する
+func applyFields(entry *Entry, fields []Field) {
+	// ロガーに設定済みのフィールドに含まれる遅延評価の値などを解決する
+	for k, v := range entry.Context {
+		if needsResolve(v) {
+			entry.Context[k] = resolveFieldValue(v)
+		}
+	}
+
+	target := entry.Context
+	for _, field := range fields {
+		if field.Type != AnyType {
+			target[field.Key] = field.value()
+			continue
+		}
+		switch v := field.Value.(type) {
+		case entryField:
+			v(entry)
+		case namespaceMarker:
+			nested := make(map[string]interface{})
+			target[field.Key] = nested
+			target = nested
+		default:
+			target[field.Key] = resolveFieldValue(v)
+		}
+	}
+}
+
+// fieldsToMap はフィールドをマップに変換する（専用フィールド向けの値は無視する）
+func fieldsToMap(fields []Field) map[string]interface{} {
+	m := make(map[string]interface{}, len(fields))
+	target := m
+	for _, field := range fields {
+		if field.Type != AnyType {
+			target[field.Key] = field.value()
+			continue
+		}
+		switch v := field.Value.(type) {
+		case entryField:
+		case namespaceMarker:
+			nested := make(map[string]interface{})
+			target[field.Key] = nested
+			target = nested
+		default:
+			target[field.Key] = resolveFieldValue(v)
+		}
+	}
+	return m
+}
+
+// needsResolve は値の解決が必要かどうかを判定する
+func needsResolve(v interface{}) bool {
+	switch v.(type) {
+	case lazyValue, groupValue, ObjectMarshaler:
+		return true
+	default:
+		return false
+	}
+}
+
+// resolveFieldValue は遅延評価の値・グループ・ObjectMarshalerを出力用の値に変換する
+func resolveFieldValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case lazyValue:
+		return resolveFieldValue(safeLazy(t))
+	case groupValue:
+		return fieldsToMap(t)
+	case ObjectMarshaler:
+		enc := make(mapObjectEncoder)
+		if err := safeMarshal(t, enc); err != nil {
+			enc["_error"] = err.Error()
+		}
+		return map[string]interface{}(enc)
+	default:
+		return v
+	}
+}
+
+// safeLazy は遅延評価の関数を呼び出し、panicした場合はその内容を値とする
+func safeLazy(fn lazyValue) (value interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			value = fmt.Sprintf("<PANIC=%v>", r)
+		}
+	}()
+	return fn()
+}
+
+// safeMarshal はMarshalLogObjectを呼び出し、panicした場合はエラーに変換する
+func safeMarshal(m ObjectMarshaler, enc ObjectEncoder) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("MarshalLogObjectでpanicが発生しました: %v", r)
+		}
+	}()
+	return m.MarshalLogObject(enc)
+}
+
+// safeString はString()を呼び出し、panicした場合（nilポインタなど）はその内容を値とする
+func safeString(s fmt.Stringer) (str string) {
+	defer func() {
+		if r := recover(); r != nil {
+			str = fmt.Sprintf("<PANIC=%v>", r)
+		}
+	}()
+	return s.String()
+}
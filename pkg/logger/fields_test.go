@@ -0,0 +1,126 @@
+package logger
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// countingEncoder は型ごとのメソッドの呼び出し回数を数えるObjectEncoder（値を保持しないためアロケーションしない）
+type countingEncoder struct {
+	typed int
+	any   int
+}
+
+func (e *countingEncoder) AddString(string, string)                { e.typed++ }
+func (e *countingEncoder) AddBool(string, bool)                    { e.typed++ }
+func (e *countingEncoder) AddInt(string, int)                      { e.typed++ }
+func (e *countingEncoder) AddInt64(string, int64)                  { e.typed++ }
+func (e *countingEncoder) AddUint64(string, uint64)                { e.typed++ }
+func (e *countingEncoder) AddFloat64(string, float64)              { e.typed++ }
+func (e *countingEncoder) AddTime(string, time.Time)               { e.typed++ }
+func (e *countingEncoder) AddDuration(string, time.Duration)       { e.typed++ }
+func (e *countingEncoder) AddStrings(string, []string)             { e.typed++ }
+func (e *countingEncoder) AddObject(string, ObjectMarshaler) error { e.typed++; return nil }
+func (e *countingEncoder) AddAny(string, interface{})              { e.any++ }
+
+func TestTypedFieldValues(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	at := time.Date(2024, 1, 2, 3, 4, 5, 6, jst)
+	ancient := time.Date(1000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		field Field
+		want  interface{}
+	}{
+		{"String", String("k", "v"), "v"},
+		{"Int", Int("k", -1000), -1000},
+		{"Int64", Int64("k", math.MinInt64), int64(math.MinInt64)},
+		{"Uint64", Uint64("k", math.MaxUint64), uint64(math.MaxUint64)},
+		{"Float64", Float64("k", -0.25), -0.25},
+		{"Bool true", Bool("k", true), true},
+		{"Bool false", Bool("k", false), false},
+		{"Duration", Duration("k", 1500*time.Millisecond), 1500 * time.Millisecond},
+		{"Bytes", Bytes("k", []byte("text")), "text"},
+		{"NamedError", NamedError("k", errors.New("failed")), "failed"},
+		{"UnixNanoの範囲外の時刻", Time("k", ancient), ancient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.field.value(); got != tt.want {
+				t.Errorf("値 = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	// 時刻はタイムゾーンを保ったまま復元する
+	got, ok := Time("k", at).value().(time.Time)
+	if !ok || !got.Equal(at) || got.Location() != jst {
+		t.Errorf("時刻 = %v, want %v", got, at)
+	}
+}
+
+func TestTypedFieldsApplyToContext(t *testing.T) {
+	entry := &Entry{Context: make(map[string]interface{})}
+	applyFields(entry, []Field{
+		String("method", "GET"),
+		Namespace("user"),
+		Int("id", 1),
+		Bool("admin", true),
+	})
+
+	user, _ := entry.Context["user"].(map[string]interface{})
+	if entry.Context["method"] != "GET" || user["id"] != 1 || user["admin"] != true {
+		t.Errorf("Context = %v", entry.Context)
+	}
+}
+
+func TestFieldAddTo(t *testing.T) {
+	fields := []Field{
+		String("s", "v"), Int("i", 1), Int64("i64", 2), Uint64("u64", 3), Float64("f", 0.5),
+		Bool("b", true), Duration("d", time.Second), Time("t", time.Now()),
+	}
+	enc := &countingEncoder{}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	if enc.typed != len(fields) || enc.any != 0 {
+		t.Errorf("型ごとのメソッド = %d回, AddAny = %d回, want %d回と0回", enc.typed, enc.any, len(fields))
+	}
+
+	// 型付きでないフィールドは解決した値をAddAnyで書き込み、専用フィールド向けの値は書き込まない
+	enc = &countingEncoder{}
+	Any("a", []int{1}).AddTo(enc)
+	Lazy("l", func() interface{} { return 1 }).AddTo(enc)
+	Namespace("n").AddTo(enc)
+	Skip().AddTo(enc)
+	if enc.typed != 0 || enc.any != 2 {
+		t.Errorf("型ごとのメソッド = %d回, AddAny = %d回, want 0回と2回", enc.typed, enc.any)
+	}
+}
+
+func TestTypedFieldAllocs(t *testing.T) {
+	var fields [8]Field
+	enc := &countingEncoder{}
+	at := time.Now()
+
+	// 型付きのフィールドは作成時もエンコーダーへの書き込み時もinterface{}に変換しない
+	allocs := testing.AllocsPerRun(100, func() {
+		fields[0] = String("s", "value")
+		fields[1] = Int("i", 1<<20)
+		fields[2] = Int64("i64", math.MaxInt64)
+		fields[3] = Uint64("u64", math.MaxUint64)
+		fields[4] = Float64("f", 3.14)
+		fields[5] = Bool("b", true)
+		fields[6] = Duration("d", time.Minute)
+		fields[7] = Time("t", at)
+		for _, f := range fields {
+			f.AddTo(enc)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("アロケーション数 = %v, want 0", allocs)
+	}
+}
@@ -0,0 +1,170 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+)
+
+// ErrDropEntry はフックがエントリの出力を取り消す場合に返すエラー
+// このエラーを返したフック以降のフックは実行されず、エントリはどのWriterにも書き込まれない
+var ErrDropEntry = errors.New("エントリの出力が取り消されました")
+
+// Hook はエントリが構築されてからWriterに渡されるまでの間に実行される処理を定義する
+// フックは登録順に実行され、エントリへの情報の追加・書き換え・出力の取り消し（ErrDropEntry）ができる
+// フックはロガーのロックを解放してから実行されるため、フックの中で同じロガーに出力・設定してもデッドロックしない
+// （フックの中で出力したエントリにもフックが実行されるため、無限に出力しないよう注意する）
+type Hook interface {
+	// Levels はフックを実行するレベルを返す（nilの場合はすべてのレベル）
+	Levels() []LogLevel
+	// Fire はエントリに対して処理を行う
+	Fire(entry *Entry) error
+}
+
+// AllLevels はすべてのログレベル
+var AllLevels = []LogLevel{DEBUG, INFO, WARN, ERROR, FATAL}
+
+// LevelsFrom は指定したレベル以上のログレベルを返す
+func LevelsFrom(min LogLevel) []LogLevel {
+	levels := make([]LogLevel, 0, len(AllLevels))
+	for _, level := range AllLevels {
+		if level >= min {
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
+
+// hookFunc は関数をHookとして扱うためのアダプター
+type hookFunc struct {
+	levels []LogLevel
+	fire   func(entry *Entry) error
+}
+
+// NewHook は関数からフックを作成する（levelsがnilの場合はすべてのレベルで実行する）
+func NewHook(levels []LogLevel, fire func(entry *Entry) error) Hook {
+	return &hookFunc{levels: levels, fire: fire}
+}
+
+// Levels はフックを実行するレベルを返す
+func (h *hookFunc) Levels() []LogLevel {
+	return h.levels
+}
+
+// Fire はエントリに対して処理を行う
+func (h *hookFunc) Fire(entry *Entry) error {
+	return h.fire(entry)
+}
+
+// hookEnabled はフックが指定したレベルで実行対象かどうかを判定する
+func hookEnabled(hook Hook, level LogLevel) bool {
+	levels := hook.Levels()
+	if levels == nil {
+		return true
+	}
+	for _, l := range levels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+// runHooks はフックを登録順に実行し、エントリを出力するかどうかを返す
+func runHooks(hooks []Hook, entry *Entry) bool {
+	for _, hook := range hooks {
+		if !hookEnabled(hook, entry.Level) {
+			continue
+		}
+		if err := hook.Fire(entry); err != nil {
+			if errors.Is(err, ErrDropEntry) {
+				return false
+			}
+			// フックでエラーが発生した場合は記録して次のフックへ進む
+			fmt.Printf("Logger hook error: %v\n", err)
+		}
+	}
+	return true
+}
+
+// NewFieldsHook は固定のフィールドをエントリのContextに追加するフックを作成する
+// ビルドバージョンやデプロイ環境など、すべてのエントリに共通する情報の付与に使う
+func NewFieldsHook(fields map[string]interface{}) Hook {
+	copied := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		copied[k] = v
+	}
+	return NewHook(nil, func(entry *Entry) error {
+		if entry.Context == nil {
+			entry.Context = make(map[string]interface{}, len(copied))
+		}
+		for k, v := range copied {
+			if _, exists := entry.Context[k]; !exists {
+				entry.Context[k] = v
+			}
+		}
+		return nil
+	})
+}
+
+// NewHostnameHook はホスト名をMetadata["hostname"]に追加するフックを作成する
+// ホスト名は作成時に一度だけ取得する
+func NewHostnameHook() Hook {
+	hostname, _ := os.Hostname()
+	return NewHook(nil, func(entry *Entry) error {
+		if hostname != "" {
+			setMetadata(entry, "hostname", hostname)
+		}
+		return nil
+	})
+}
+
+// NewGitCommitHook はGitのコミットハッシュをMetadata["git_commit"]に追加するフックを作成する
+// コミットハッシュは作成時に一度だけ取得し、取得できない場合は何もしない
+func NewGitCommitHook() Hook {
+	commit := GetEnvironmentInfo().GitCommit
+	return NewHook(nil, func(entry *Entry) error {
+		if commit != "" {
+			setMetadata(entry, "git_commit", commit)
+		}
+		return nil
+	})
+}
+
+// NewGoroutineIDHook はログを出力したゴルーチンのIDをMetadata["goroutine_id"]に追加するフックを作成する
+// ゴルーチンIDはデバッグ用途の情報であり、取得にはruntime.Stackを使うため出力ごとにコストがかかる
+func NewGoroutineIDHook() Hook {
+	return NewHook(nil, func(entry *Entry) error {
+		if id := goroutineID(); id != 0 {
+			setMetadata(entry, "goroutine_id", id)
+		}
+		return nil
+	})
+}
+
+// setMetadata はエントリのMetadataに値を設定する
+func setMetadata(entry *Entry, key string, value interface{}) {
+	if entry.Metadata == nil {
+		entry.Metadata = make(map[string]interface{})
+	}
+	entry.Metadata[key] = value
+}
+
+// goroutineID は現在のゴルーチンのIDを取得する（取得できない場合は0）
+func goroutineID() uint64 {
+	var buf [64]byte
+	n := runtime.Stack(buf[:], false)
+	// 先頭行は "goroutine 123 [running]:" の形式
+	b := bytes.TrimPrefix(buf[:n], []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, err := strconv.ParseUint(string(b), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
@@ -0,0 +1,195 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+// orderHook はMetadata["order"]に名前を追加するフックを作成する
+func orderHook(name string, levels []LogLevel) Hook {
+	return NewHook(levels, func(entry *Entry) error {
+		order, _ := entry.Metadata["order"].([]string)
+		entry.Metadata["order"] = append(order, name)
+		return nil
+	})
+}
+
+// hookOrder はエントリで実行されたフックの名前を順に返す
+func hookOrder(e *Entry) []string {
+	order, _ := e.Metadata["order"].([]string)
+	return order
+}
+
+// newHookLogger はメモリに記録するロガーにフックを登録する
+func newHookLogger(hooks ...Hook) (Logger, *memoryWriter) {
+	mem := &memoryWriter{}
+	l := New(DEBUG)
+	l.AddWriter(mem)
+	for _, hook := range hooks {
+		l.AddHook(hook)
+	}
+	return l, mem
+}
+
+func TestHooksRunInRegistrationOrder(t *testing.T) {
+	l, mem := newHookLogger(orderHook("first", nil), orderHook("second", nil), orderHook("third", nil))
+
+	l.Info("op")
+	if got := hookOrder(lastEntry(t, mem)); !equalStrings(got, []string{"first", "second", "third"}) {
+		t.Errorf("実行順 = %v", got)
+	}
+}
+
+func TestHookLevelFilter(t *testing.T) {
+	l, mem := newHookLogger(
+		orderHook("all", nil),
+		orderHook("warn+", LevelsFrom(WARN)),
+		orderHook("debug", []LogLevel{DEBUG}),
+		orderHook("none", []LogLevel{}),
+	)
+
+	l.Debug("debug")
+	l.Info("info")
+	l.Error("error")
+
+	want := map[string][]string{
+		"debug": {"all", "debug"},
+		"info":  {"all"},
+		"error": {"all", "warn+"},
+	}
+	for _, e := range mem.written() {
+		if got := hookOrder(e); !equalStrings(got, want[e.Operation]) {
+			t.Errorf("%sで実行されたフック = %v, want %v", e.Operation, got, want[e.Operation])
+		}
+	}
+}
+
+func TestLevelsFrom(t *testing.T) {
+	got := LevelsFrom(WARN)
+	if len(got) != 3 || got[0] != WARN || got[1] != ERROR || got[2] != FATAL {
+		t.Errorf("LevelsFrom(WARN) = %v", got)
+	}
+	if got := LevelsFrom(DEBUG); len(got) != len(AllLevels) {
+		t.Errorf("LevelsFrom(DEBUG) = %v", got)
+	}
+}
+
+func TestHookRewritesEntry(t *testing.T) {
+	l, mem := newHookLogger(NewHook(nil, func(entry *Entry) error {
+		entry.Operation = "rewritten"
+		entry.Tags = append(entry.Tags, "hooked")
+		return nil
+	}))
+
+	l.Info("original")
+	e := lastEntry(t, mem)
+	if e.Operation != "rewritten" || !equalStrings(e.Tags, []string{"hooked"}) {
+		t.Errorf("エントリ = %s %v", e.Operation, e.Tags)
+	}
+}
+
+func TestHookDropsEntry(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"ErrDropEntry", ErrDropEntry},
+		{"ラップしたErrDropEntry", fmt.Errorf("sampling: %w", ErrDropEntry)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after := false
+			l, mem := newHookLogger(
+				NewHook(nil, func(*Entry) error { return tt.err }),
+				NewHook(nil, func(*Entry) error { after = true; return nil }),
+			)
+
+			l.Info("dropped")
+			if got := mem.operations(); len(got) != 0 {
+				t.Errorf("書き込まれた操作 = %v, want なし", got)
+			}
+			if after {
+				t.Error("出力を取り消したフックより後のフックが実行されました")
+			}
+		})
+	}
+}
+
+func TestHookErrorContinues(t *testing.T) {
+	l, mem := newHookLogger(
+		NewHook(nil, func(*Entry) error { return errors.New("enrichment failed") }),
+		orderHook("next", nil),
+	)
+
+	// ErrDropEntry以外のエラーでは後続のフックを実行し、エントリを出力する
+	l.Info("op")
+	if got := hookOrder(lastEntry(t, mem)); !equalStrings(got, []string{"next"}) {
+		t.Errorf("実行されたフック = %v", got)
+	}
+}
+
+func TestHooksInheritedByChildLoggers(t *testing.T) {
+	l, mem := newHookLogger(orderHook("parent", nil))
+
+	child := l.WithField("k", "v")
+	child.AddHook(orderHook("child", nil))
+
+	child.Info("child")
+	if got := hookOrder(lastEntry(t, mem)); !equalStrings(got, []string{"parent", "child"}) {
+		t.Errorf("子ロガーのフック = %v", got)
+	}
+	// 子ロガーに追加したフックは親ロガーで実行されない
+	l.Info("parent")
+	if got := hookOrder(lastEntry(t, mem)); !equalStrings(got, []string{"parent"}) {
+		t.Errorf("親ロガーのフック = %v", got)
+	}
+}
+
+func TestFieldsHook(t *testing.T) {
+	fields := map[string]interface{}{"version": "1.2.3", "env": "prod"}
+	hook := NewFieldsHook(fields)
+	// 作成後に元のマップを変更しても影響しない
+	fields["version"] = "changed"
+
+	l, mem := newHookLogger(hook)
+	l.Info("op", String("env", "staging"))
+
+	e := lastEntry(t, mem)
+	// エントリに既にあるフィールドは上書きしない
+	if e.Context["version"] != "1.2.3" || e.Context["env"] != "staging" {
+		t.Errorf("Context = %v", e.Context)
+	}
+}
+
+func TestBuiltinEnrichmentHooks(t *testing.T) {
+	l, mem := newHookLogger(NewHostnameHook(), NewGitCommitHook(), NewGoroutineIDHook())
+
+	l.Info("op")
+	e := lastEntry(t, mem)
+
+	if hostname, _ := os.Hostname(); e.Metadata["hostname"] != hostname {
+		t.Errorf("hostname = %v, want %s", e.Metadata["hostname"], hostname)
+	}
+	// コミットハッシュが取得できない環境では追加しない
+	if commit := GetEnvironmentInfo().GitCommit; commit != "" && e.Metadata["git_commit"] != commit {
+		t.Errorf("git_commit = %v, want %s", e.Metadata["git_commit"], commit)
+	} else if _, ok := e.Metadata["git_commit"]; commit == "" && ok {
+		t.Errorf("取得できないgit_commitが追加されました: %v", e.Metadata["git_commit"])
+	}
+
+	if e.Metadata["goroutine_id"] != goroutineID() {
+		t.Errorf("goroutine_id = %v, want %d", e.Metadata["goroutine_id"], goroutineID())
+	}
+	// 別のゴルーチンから出力したエントリには別のIDが付与される
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.Info("other")
+	}()
+	<-done
+	if other := lastEntry(t, mem).Metadata["goroutine_id"]; other == e.Metadata["goroutine_id"] || other == nil {
+		t.Errorf("別のゴルーチンのgoroutine_id = %v", other)
+	}
+}
@@ -2,6 +2,7 @@ package logger
 
 import (
 	"context"
+	"sync"
 	"time"
 )
 
@@ -43,6 +44,13 @@ const (
 	ActionError    ActionType = "ERROR"
 	ActionRetry    ActionType = "RETRY"
 	ActionSkip     ActionType = "SKIP"
+	ActionRecovery ActionType = "RECOVERY"
+
+	// サーキットブレーカーの状態遷移
+	ActionCircuitOpen     ActionType = "CIRCUIT_OPEN"
+	ActionCircuitHalfOpen ActionType = "CIRCUIT_HALF_OPEN"
+	ActionCircuitClose    ActionType = "CIRCUIT_CLOSE"
+	ActionCircuitExecute  ActionType = "CIRCUIT_BREAKER_EXECUTE"
 )
 
 // Entry はログエントリの構造を表す
@@ -79,6 +87,12 @@ type ErrorInfo struct {
 	Context    map[string]interface{} `json:"context,omitempty"`
 }
 
+// Error はエラーメッセージを返す
+// ErrorInfoをerrorとして返すことで、RetryPolicyはerrors.AsでRetryableを参照できる
+func (e *ErrorInfo) Error() string {
+	return e.Message
+}
+
 // OperationTracker は操作の追跡を行う
 type OperationTracker struct {
 	ID        string
@@ -88,6 +102,16 @@ type OperationTracker struct {
 	Context   map[string]interface{}
 	Logger    Logger
 	parent    *OperationTracker
+
+	// スパン情報（Loggerはこれらのスパン情報でスコープされている）
+	TraceID  string
+	SpanID   string
+	ParentID string
+
+	// 操作の状態（muで保護）
+	mu       sync.Mutex
+	status   OperationStatus
+	duration time.Duration
 }
 
 // Logger はロギングのインターフェースを定義する
@@ -126,6 +150,11 @@ type Logger interface {
 	GetLevel() LogLevel
 	AddWriter(writer Writer)
 	SetFormatter(formatter Formatter)
+	AddHook(hook Hook)
+
+	// ライフサイクル
+	Sync() error
+	Close() error
 
 	// システム情報設定
 	EnableSystemInfo(enabled bool)
@@ -134,21 +163,67 @@ type Logger interface {
 	IsRuntimeInfoEnabled() bool
 }
 
+// RedactableLogger は機密情報をマスクするRedactorを設定できるLoggerを定義する
+// New・Defaultが返すLoggerは実装しているため、型アサーションで取り出して使う
+//
+//	if rl, ok := log.(logger.RedactableLogger); ok {
+//		rl.SetRedactor(logger.DefaultRedactor())
+//	}
+type RedactableLogger interface {
+	SetRedactor(redactor *Redactor)
+}
+
+// FatalConfigurableLogger はFatalの挙動を設定できるLoggerを定義する
+// New・Defaultが返すLoggerは実装しているため、型アサーションで取り出して使う
+type FatalConfigurableLogger interface {
+	SetFatalOptions(opts FatalOptions)
+}
+
+// ReopenableLogger は出力先のファイルを開き直すことができるLoggerを定義する
+// New・Defaultが返すLoggerは実装しているため、型アサーションで取り出して使う
+type ReopenableLogger interface {
+	Reopen() error
+}
+
 // Writer はログの出力先を定義する
 type Writer interface {
 	Write(entry *Entry) error
 	Close() error
 }
 
+// FormattableWriter はフォーマッターを設定可能なWriterを定義する
+// Logger.SetFormatterで設定したフォーマッターはこのインターフェースを実装するすべてのWriterに適用される
+type FormattableWriter interface {
+	Writer
+	SetFormatter(formatter Formatter)
+}
+
+// FlushableWriter はバッファの内容を明示的に出力できるWriterを定義する
+type FlushableWriter interface {
+	Writer
+	Flush() error
+}
+
+// ReopenableWriter は出力先のファイルを開き直すことができるWriterを定義する
+type ReopenableWriter interface {
+	Writer
+	Reopen() error
+}
+
 // Formatter はログのフォーマットを定義する
 type Formatter interface {
 	Format(entry *Entry) ([]byte, error)
 }
 
 // Field はログフィールドを表す
+// 型付きのコンストラクタで作成したフィールドは、値をTypeに応じてIntegerまたはStringに保持し、interface{}に変換しない
+// Typeがゼロ値（AnyType）の場合はValueの値を出力する
 type Field struct {
-	Key   string
-	Value interface{}
+	Key     string
+	Type    FieldType
+	Integer int64
+	String  string
+	Value   interface{}
 }
 
 // NewField は新しいフィールドを作成する
@@ -158,22 +233,22 @@ func NewField(key string, value interface{}) Field {
 
 // String は文字列フィールドを作成する
 func String(key, value string) Field {
-	return Field{Key: key, Value: value}
+	return Field{Key: key, Type: StringType, String: value}
 }
 
 // Int は整数フィールドを作成する
 func Int(key string, value int) Field {
-	return Field{Key: key, Value: value}
+	return Field{Key: key, Type: IntType, Integer: int64(value)}
 }
 
 // Duration は期間フィールドを作成する
 func Duration(key string, value time.Duration) Field {
-	return Field{Key: key, Value: value}
+	return Field{Key: key, Type: DurationType, Integer: int64(value)}
 }
 
-// Error はエラーフィールドを作成する
+// Error はエラーフィールドを作成する（nilの場合はフィールドを出力しない）
 func Error(key string, err error) Field {
-	return Field{Key: key, Value: err.Error()}
+	return NamedError(key, err)
 }
 
 // Any は任意の値のフィールドを作成する
@@ -0,0 +1,288 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrSyncTimeout はシグナル受信時の出力とクローズが時間内に完了しなかった場合のエラー
+var ErrSyncTimeout = errors.New("ロガーの出力とクローズが時間内に完了しませんでした")
+
+// loggerLifecycle はロガーとそのクローンで共有されるライフサイクルの状態
+// クローンは同じWriterを共有するため、Closeがどのロガーから呼ばれてもWriterは一度だけ閉じられる
+type loggerLifecycle struct {
+	// closed はCloseの開始後にtrueとなり、active は出力中のログの数（いずれもwriteMuで保護）
+	// ロックを保持せずに数えるため、フックやWriterの中から同じロガーでログを出力してもデッドロックしない
+	closed    bool
+	active    int
+	writeMu   sync.Mutex
+	writeDone *sync.Cond
+
+	mu            sync.Mutex
+	closedWriters map[Writer]struct{}
+}
+
+// newLoggerLifecycle は新しいライフサイクルの状態を作成する
+func newLoggerLifecycle() *loggerLifecycle {
+	lc := &loggerLifecycle{closedWriters: make(map[Writer]struct{})}
+	lc.writeDone = sync.NewCond(&lc.writeMu)
+	return lc
+}
+
+// enter はログ出力の開始を記録する（Close済みの場合はfalseを返し、出力しない）
+func (lc *loggerLifecycle) enter() bool {
+	lc.writeMu.Lock()
+	defer lc.writeMu.Unlock()
+	if lc.closed {
+		return false
+	}
+	lc.active++
+	return true
+}
+
+// exit はログ出力の完了を記録する
+func (lc *loggerLifecycle) exit() {
+	lc.writeMu.Lock()
+	defer lc.writeMu.Unlock()
+	if lc.active--; lc.active == 0 {
+		lc.writeDone.Broadcast()
+	}
+}
+
+// closeAndWait は以降のログ出力を止め、出力中のログがすべて完了するまで待機する
+// Close中にフックやWriterから出力されたログはenterで破棄されるため、待機は必ず終わる
+func (lc *loggerLifecycle) closeAndWait() {
+	lc.writeMu.Lock()
+	defer lc.writeMu.Unlock()
+	lc.closed = true
+	for lc.active > 0 {
+		lc.writeDone.Wait()
+	}
+}
+
+// markClosed はWriterを閉じたものとして記録し、まだ閉じていなかった場合はtrueを返す
+// 比較できない型のWriterは記録できないため、常にtrueを返す
+func (lc *loggerLifecycle) markClosed(writer Writer) bool {
+	if !reflect.TypeOf(writer).Comparable() {
+		return true
+	}
+
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	if _, done := lc.closedWriters[writer]; done {
+		return false
+	}
+	lc.closedWriters[writer] = struct{}{}
+	return true
+}
+
+// Sync はすべてのWriterのうちFlushableWriterのバッファを出力する
+func (l *vibeLogger) Sync() error {
+	l.mu.RLock()
+	writers := make([]Writer, len(l.writers))
+	copy(writers, l.writers)
+	l.mu.RUnlock()
+
+	return syncWriters(writers)
+}
+
+// Close はすべてのWriterのバッファを出力してから閉じる
+// クローンと共有しているWriterも含めて各Writerは一度だけ閉じられ、以降のログ出力はすべてのクローンで破棄される
+// 他のゴルーチンで出力中のログは、その書き込みが完了するまで待ってからWriterを閉じる
+func (l *vibeLogger) Close() error {
+	l.mu.RLock()
+	writers := make([]Writer, len(l.writers))
+	copy(writers, l.writers)
+	l.mu.RUnlock()
+
+	l.lifecycle.closeAndWait()
+
+	// 他のロガー（クローン）から既に閉じられたWriterは対象外とする
+	pending := make([]Writer, 0, len(writers))
+	for _, writer := range writers {
+		if l.lifecycle.markClosed(writer) {
+			pending = append(pending, writer)
+		}
+	}
+
+	var errs []error
+	if err := syncWriters(pending); err != nil {
+		errs = append(errs, err)
+	}
+	for _, writer := range pending {
+		if err := writer.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// syncWriters はFlushableWriterのバッファを出力する
+func syncWriters(writers []Writer) error {
+	var errs []error
+	for _, writer := range writers {
+		if fw, ok := writer.(FlushableWriter); ok {
+			if err := fw.Flush(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Reopen はすべてのWriterのうちReopenableWriterのファイルを開き直す
+// logrotateなどでファイルを移動した後に呼び出すと、新しいファイルへの書き込みに切り替わる
+func (l *vibeLogger) Reopen() error {
+	l.mu.RLock()
+	writers := make([]Writer, len(l.writers))
+	copy(writers, l.writers)
+	l.mu.RUnlock()
+
+	var errs []error
+	for _, writer := range writers {
+		if err := reopenWriter(writer); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// reopenWriter はWriterがReopenableWriterであればファイルを開き直す
+func reopenWriter(writer Writer) error {
+	if rw, ok := writer.(ReopenableWriter); ok {
+		return rw.Reopen()
+	}
+	return nil
+}
+
+// InstallReopenHandler はシグナル受信時にロガーのファイルを開き直すハンドラーを登録する
+// signalsを省略した場合はSIGHUPを捕捉する（SIGHUPのない環境では何もしない）
+// logrotateのpostrotateで kill -HUP を送る運用に使う
+// loggerがReopenableLoggerを実装していない場合は何もしない
+// 返される関数を呼び出すとハンドラーを解除する
+func InstallReopenHandler(logger Logger, signals ...os.Signal) (stop func()) {
+	reopener, ok := logger.(ReopenableLogger)
+	if !ok {
+		return func() {}
+	}
+	if len(signals) == 0 {
+		signals = defaultReopenSignals
+	}
+	if len(signals) == 0 {
+		return func() {}
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, signals...)
+
+	go func() {
+		for {
+			select {
+			case <-ch:
+				if err := reopener.Reopen(); err != nil {
+					fmt.Fprintf(os.Stderr, "Logger reopen error: %v\n", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// SignalHandlerOptions はシグナルハンドラーの設定です
+type SignalHandlerOptions struct {
+	Signals []os.Signal   // 捕捉するシグナル（nilの場合はSIGINTとSIGTERM）
+	Timeout time.Duration // 出力とクローズの最大待機時間（0の場合は5秒）
+	// OnSignal はシグナル受信時、ロガーを閉じる前に呼ばれる関数（nilの場合は何もしない）
+	OnSignal func(sig os.Signal)
+}
+
+// InstallSignalHandler はシグナル受信時にロガーのバッファを出力して閉じるハンドラーを登録する
+// 出力とクローズはTimeoutまで待機し、その後シグナルの既定の動作に戻して同じシグナルを再送する
+// 返される関数を呼び出すとハンドラーを解除する
+func InstallSignalHandler(logger Logger, opts SignalHandlerOptions) (stop func()) {
+	if opts.Signals == nil {
+		opts.Signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, opts.Signals...)
+
+	go func() {
+		select {
+		case sig := <-ch:
+			if opts.OnSignal != nil {
+				opts.OnSignal(sig)
+			}
+			if err := closeWithTimeout(logger, opts.Timeout); err != nil {
+				fmt.Fprintf(os.Stderr, "Logger shutdown error: %v\n", err)
+			}
+			signal.Reset(opts.Signals...)
+			reraiseSignal(sig)
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+// closeWithTimeout はロガーの出力とクローズを行い、timeoutを過ぎた場合はErrSyncTimeoutを返す
+func closeWithTimeout(logger Logger, timeout time.Duration) error {
+	result := make(chan error, 1)
+	go func() {
+		result <- logger.Close()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-result:
+		return err
+	case <-timer.C:
+		return ErrSyncTimeout
+	}
+}
+
+// reraiseSignal は自プロセスにシグナルを再送する
+// 再送できない環境では、シグナルによる終了と同じ終了コードで終了する
+func reraiseSignal(sig os.Signal) {
+	if p, err := os.FindProcess(os.Getpid()); err == nil {
+		if err := p.Signal(sig); err == nil {
+			// 既定の動作で終了するまで待つ
+			time.Sleep(time.Second)
+		}
+	}
+
+	code := 1
+	if s, ok := sig.(syscall.Signal); ok {
+		code = 128 + int(s)
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,158 @@
+package logger
+
+import (
+	"testing"
+	"time"
+)
+
+// waitDone はチャネルが閉じられるか値を受け取るまで待機し、時間内に終わらなければテストを失敗させる
+func waitDone(t *testing.T, done <-chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%sが終わりませんでした", what)
+	}
+}
+
+func TestCloseWaitsForInFlightLogs(t *testing.T) {
+	bw := newBlockingWriter()
+	l := New(DEBUG)
+	l.AddWriter(bw)
+
+	logged := make(chan struct{})
+	go func() {
+		l.Info("in_flight")
+		close(logged)
+	}()
+	bw.waitStarted(t)
+
+	closed := make(chan struct{})
+	go func() {
+		if err := l.Close(); err != nil {
+			t.Errorf("Close: %v", err)
+		}
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Fatal("出力中のログを待たずにCloseが戻りました")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if bw.closeCount() != 0 {
+		t.Fatal("書き込み中にWriterが閉じられました")
+	}
+
+	close(bw.release)
+	waitDone(t, logged, "ログ出力")
+	waitDone(t, closed, "Close")
+	if got := bw.operations(); !equalStrings(got, []string{"in_flight"}) {
+		t.Errorf("書き込まれた操作 = %v", got)
+	}
+	if bw.closeCount() != 1 {
+		t.Errorf("Closeの回数 = %d, want 1", bw.closeCount())
+	}
+
+	// Close後のログは破棄される
+	l.Info("after_close")
+	if got := len(bw.written()); got != 1 {
+		t.Errorf("書き込まれた件数 = %d, want 1（Close後のログは破棄される）", got)
+	}
+}
+
+func TestHookLoggingDuringCloseDoesNotDeadlock(t *testing.T) {
+	mem := &memoryWriter{}
+	l := New(DEBUG)
+	l.AddWriter(mem)
+
+	inHook := make(chan struct{})
+	closing := make(chan struct{})
+	l.AddHook(NewHook(nil, func(entry *Entry) error {
+		if entry.Operation != "outer" {
+			return nil
+		}
+		close(inHook)
+		<-closing
+		// Closeが出力中のログを待っている間に、同じロガーで出力する
+		l.Info("nested")
+		return nil
+	}))
+
+	logged := make(chan struct{})
+	go func() {
+		l.Info("outer")
+		close(logged)
+	}()
+	waitDone(t, inHook, "フックの呼び出し")
+
+	closed := make(chan struct{})
+	go func() {
+		_ = l.Close()
+		close(closed)
+	}()
+	time.Sleep(20 * time.Millisecond) // Closeが待機を始めるまで待つ
+	close(closing)
+
+	waitDone(t, logged, "ログ出力")
+	waitDone(t, closed, "Close")
+	if got := mem.operations(); !equalStrings(got, []string{"outer"}) {
+		t.Errorf("書き込まれた操作 = %v, want Close中の出力は破棄される", got)
+	}
+}
+
+func TestHookCanConfigureLogger(t *testing.T) {
+	mem := &memoryWriter{}
+	l := New(DEBUG)
+	l.AddWriter(mem)
+
+	added := false
+	l.AddHook(NewHook(nil, func(entry *Entry) error {
+		// フックの中からロガーの設定を変更してもデッドロックしない
+		if !added {
+			added = true
+			l.AddHook(NewHook(nil, func(e *Entry) error {
+				e.Tags = append(e.Tags, "late")
+				return nil
+			}))
+		}
+		return nil
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		l.Info("first")
+		l.Info("second")
+		close(done)
+	}()
+	waitDone(t, done, "ログ出力")
+
+	written := mem.written()
+	if len(written) != 2 {
+		t.Fatalf("書き込まれた件数 = %d, want 2", len(written))
+	}
+	if !equalStrings(written[1].Tags, []string{"late"}) {
+		t.Errorf("後から追加したフックが適用されていません: %v", written[1].Tags)
+	}
+}
+
+func TestCloseClosesSharedWritersOnce(t *testing.T) {
+	mem := &memoryWriter{}
+	l := New(DEBUG)
+	l.AddWriter(mem)
+	clone := l.WithField("component", "worker")
+
+	if err := clone.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if mem.closeCount() != 1 {
+		t.Errorf("Closeの回数 = %d, want 1", mem.closeCount())
+	}
+
+	l.Info("dropped")
+	if got := len(mem.written()); got != 0 {
+		t.Errorf("Close後に書き込まれた件数 = %d, want 0", got)
+	}
+}
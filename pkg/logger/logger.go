@@ -28,6 +28,18 @@ type vibeLogger struct {
 	includeSystemInfo   bool
 	includeRuntimeInfo  bool
 	systemInfoCollector *SystemInfoCollector
+
+	// エントリの構築後、Writerに渡す前に実行するフック（登録順）
+	hooks []Hook
+
+	// フォーマット前に機密情報をマスクするRedactor（nilの場合はマスクしない）
+	redactor *Redactor
+
+	// クローンと共有するライフサイクルの状態（Close済みかどうか、閉じたWriter）
+	lifecycle *loggerLifecycle
+
+	// Fatal出力後の挙動
+	fatal FatalOptions
 }
 
 // New は新しいloggerを作成する
@@ -40,6 +52,8 @@ func New(level LogLevel) Logger {
 		includeSystemInfo:   true,  // デフォルトで有効
 		includeRuntimeInfo:  false, // パフォーマンスを考慮してデフォルトで無効
 		systemInfoCollector: NewSystemInfoCollector(),
+		redactor:            nil, // 機密情報のマスクはSetRedactorで明示的に有効にする
+		lifecycle:           newLoggerLifecycle(),
 	}
 }
 
@@ -79,18 +93,35 @@ func (l *vibeLogger) Error(msg string, fields ...Field) {
 	}
 }
 
-// Fatal は致命的エラーレベルのログを出力する
+// Fatal は致命的エラーレベルのログを出力し、FatalOptionsに従って終了またはpanicする
 func (l *vibeLogger) Fatal(msg string, fields ...Field) {
 	if l.level <= FATAL {
+		l.mu.RLock()
+		extra := l.fatalFields(l.fatal)
+		l.mu.RUnlock()
+
+		if len(extra) > 0 {
+			fields = append(append(make([]Field, 0, len(fields)+len(extra)), fields...), extra...)
+		}
 		l.log(FATAL, msg, fields...)
 	}
+
+	if !hasFatalLogOnlyField(fields) {
+		l.terminateFatal(msg)
+	}
 }
 
 // log は実際のログ出力を行う
 func (l *vibeLogger) log(level LogLevel, msg string, fields ...Field) {
-	l.mu.RLock()
-	defer l.mu.RUnlock()
+	// Close済みのロガーからの出力は破棄し、書き込みが終わるまではCloseにWriterを閉じさせない
+	if !l.lifecycle.enter() {
+		return
+	}
+	defer l.lifecycle.exit()
 
+	// ロガーの状態はロックを保持して取り出し、フックやWriterなどの利用者のコードはロックを解放してから実行する
+	// （フックやWriterが同じロガーに出力・設定しても、ロックの再取得でデッドロックしない）
+	l.mu.RLock()
 	entry := &Entry{
 		ID:        uuid.New().String(),
 		Timestamp: time.Now(),
@@ -104,6 +135,9 @@ func (l *vibeLogger) log(level LogLevel, msg string, fields ...Field) {
 		Metadata:  make(map[string]interface{}),
 	}
 
+	// コンテキストに格納されたトレース情報・タグ・フィールドの追加
+	applyContextValues(entry, l.context)
+
 	// システム情報の追加
 	if l.includeSystemInfo {
 		entry.SystemInfo = l.systemInfoCollector.GetCompactSystemInfo()
@@ -114,12 +148,10 @@ func (l *vibeLogger) log(level LogLevel, msg string, fields ...Field) {
 		entry.RuntimeInfo = l.systemInfoCollector.GetRuntimeStats()
 	}
 
-	// フィールドを追加
-	for _, field := range fields {
-		entry.Context[field.Key] = field.Value
-	}
+	hooks, redactor, writers := l.hooks, l.redactor, l.writers
+	l.mu.RUnlock()
 
-	// 呼び出し元の情報を追加
+	// 呼び出し元の情報を追加（フィールドで上書き可能）
 	if pc, file, line, ok := runtime.Caller(2); ok {
 		entry.Metadata["caller"] = fmt.Sprintf("%s:%d", file, line)
 		if fn := runtime.FuncForPC(pc); fn != nil {
@@ -127,44 +159,56 @@ func (l *vibeLogger) log(level LogLevel, msg string, fields ...Field) {
 		}
 	}
 
+	// フィールドを追加（専用フィールド向けの値はEntryへ直接設定する）
+	applyFields(entry, fields)
+
+	// フックの実行（取り消された場合は出力しない）
+	if !runHooks(hooks, entry) {
+		return
+	}
+
+	// 機密情報のマスク
+	redactor.Redact(entry)
+
 	// 書き込み
-	l.writeEntry(entry)
+	writeEntry(writers, entry)
 }
 
 // StartOperation は操作の開始を記録する
+// ロガーにスパンIDが設定されている場合（トラッカーのLoggerなど）は、その子スパンとして操作を開始する
 func (l *vibeLogger) StartOperation(operation string, input map[string]interface{}) *OperationTracker {
-	tracker := &OperationTracker{
-		ID:        uuid.New().String(),
-		Operation: operation,
-		StartTime: time.Now(),
-		Input:     input,
-		Context:   l.copyFields(),
-		Logger:    l,
-	}
+	traceID, parentID := currentSpan(l)
+	tracker := newOperationTracker(l, operation, input, traceID, parentID)
+
+	l.mu.RLock()
+	tracker.Context = l.copyFields()
+	l.mu.RUnlock()
 
 	l.log(INFO, operation,
-		String("action", string(ActionStart)),
+		actionField(ActionStart),
 		String("operation_id", tracker.ID),
-		Any("input", input))
+		inputField(input),
+		spanField(tracker))
 
 	return tracker
 }
 
 // CompleteOperation は操作の完了を記録する
 func (l *vibeLogger) CompleteOperation(tracker *OperationTracker, output map[string]interface{}) {
-	duration := time.Since(tracker.StartTime)
+	duration := tracker.finish(OperationCompleted)
 
 	l.log(INFO, tracker.Operation,
-		String("action", string(ActionComplete)),
+		actionField(ActionComplete),
 		String("operation_id", tracker.ID),
-		Any("input", tracker.Input),
-		Any("output", output),
-		Duration("duration", duration))
+		inputField(tracker.Input),
+		outputField(output),
+		durationField(duration),
+		spanField(tracker))
 }
 
 // ErrorOperation は操作のエラーを記録する
 func (l *vibeLogger) ErrorOperation(tracker *OperationTracker, err error, resolution string) {
-	duration := time.Since(tracker.StartTime)
+	duration := tracker.finish(OperationFailed)
 
 	errorInfo := &ErrorInfo{
 		Message:    err.Error(),
@@ -174,11 +218,12 @@ func (l *vibeLogger) ErrorOperation(tracker *OperationTracker, err error, resolu
 	}
 
 	l.log(ERROR, tracker.Operation,
-		String("action", string(ActionError)),
+		actionField(ActionError),
 		String("operation_id", tracker.ID),
-		Any("input", tracker.Input),
-		Any("error", errorInfo),
-		Duration("duration", duration))
+		inputField(tracker.Input),
+		errorInfoField(errorInfo),
+		durationField(duration),
+		spanField(tracker))
 }
 
 // LogError はエラーをログに記録する
@@ -194,23 +239,30 @@ func (l *vibeLogger) LogError(err error, context map[string]interface{}, retryab
 	errorInfo.Stack = l.getStackTrace()
 
 	l.log(ERROR, "error_occurred",
-		Any("error", errorInfo),
+		actionField(ActionError),
+		errorInfoField(errorInfo),
 		Any("context", context))
 }
 
 // LogRetry はリトライの記録を行う
 func (l *vibeLogger) LogRetry(operation string, attempt int, err error, nextRetryIn time.Duration) {
+	errorInfo := &ErrorInfo{
+		Message:   err.Error(),
+		Type:      fmt.Sprintf("%T", err),
+		Retryable: true,
+	}
+
 	l.log(WARN, operation,
-		String("action", string(ActionRetry)),
+		actionField(ActionRetry),
 		Int("attempt", attempt),
-		Error("error", err),
+		errorInfoField(errorInfo),
 		Duration("next_retry_in", nextRetryIn))
 }
 
 // LogRecovery はリカバリーの記録を行う
 func (l *vibeLogger) LogRecovery(operation string, originalErr error, recoveryAction string) {
 	l.log(INFO, operation,
-		String("action", "RECOVERY"),
+		actionField(ActionRecovery),
 		Error("original_error", originalErr),
 		String("recovery_action", recoveryAction))
 }
@@ -312,17 +364,24 @@ func (l *vibeLogger) GetLevel() LogLevel {
 }
 
 // AddWriter はライターを追加する
+// ロガーにフォーマッターが設定済みの場合、FormattableWriterにはそのフォーマッターを適用する
 func (l *vibeLogger) AddWriter(writer Writer) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	if l.formatter != nil {
+		applyFormatter(writer, l.formatter)
+	}
 	l.writers = append(l.writers, writer)
 }
 
-// SetFormatter はフォーマッターを設定する
+// SetFormatter はフォーマッターを設定し、追加済みのすべてのFormattableWriterに適用する
 func (l *vibeLogger) SetFormatter(formatter Formatter) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.formatter = formatter
+	for _, writer := range l.writers {
+		applyFormatter(writer, formatter)
+	}
 }
 
 // EnableSystemInfo はシステム情報の記録を有効/無効にする
@@ -353,6 +412,22 @@ func (l *vibeLogger) IsRuntimeInfoEnabled() bool {
 	return l.includeRuntimeInfo
 }
 
+// AddHook はフックを追加する
+// フックは登録順に、フックのLevelsに含まれるレベルのエントリに対してのみ実行される
+func (l *vibeLogger) AddHook(hook Hook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, hook)
+}
+
+// SetRedactor は機密情報をマスクするRedactorを設定する（nilの場合はマスクを無効にする）
+// デフォルトではマスクしないため、DefaultRedactor()などを設定して有効にする
+func (l *vibeLogger) SetRedactor(redactor *Redactor) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.redactor = redactor
+}
+
 // clone はロガーのクローンを作成する
 func (l *vibeLogger) clone() *vibeLogger {
 	newLogger := &vibeLogger{
@@ -368,7 +443,12 @@ func (l *vibeLogger) clone() *vibeLogger {
 		includeSystemInfo:   l.includeSystemInfo,
 		includeRuntimeInfo:  l.includeRuntimeInfo,
 		systemInfoCollector: l.systemInfoCollector,
+		hooks:               make([]Hook, len(l.hooks)),
+		redactor:            l.redactor,
+		lifecycle:           l.lifecycle,
+		fatal:               l.fatal,
 	}
+	copy(newLogger.hooks, l.hooks)
 
 	// フィールドをコピー
 	for k, v := range l.fields {
@@ -397,9 +477,16 @@ func (l *vibeLogger) copyTags() []string {
 	return tags
 }
 
-// writeEntry はエントリを書き込む
-func (l *vibeLogger) writeEntry(entry *Entry) {
-	for _, writer := range l.writers {
+// applyFormatter はWriterがFormattableWriterであればフォーマッターを設定する
+func applyFormatter(writer Writer, formatter Formatter) {
+	if fw, ok := writer.(FormattableWriter); ok && formatter != nil {
+		fw.SetFormatter(formatter)
+	}
+}
+
+// writeEntry はエントリをすべてのWriterに書き込む
+func writeEntry(writers []Writer, entry *Entry) {
+	for _, writer := range writers {
 		if err := writer.Write(entry); err != nil {
 			// ライターでエラーが発生した場合の処理
 			fmt.Printf("Logger write error: %v\n", err)
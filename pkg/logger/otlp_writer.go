@@ -0,0 +1,812 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrOTLPWriterClosed は閉じられたOTLPWriterへの書き込み時に返されるエラー
+var ErrOTLPWriterClosed = errors.New("OTLPライターは既に閉じられています")
+
+// otlpScopeName はOTLPのインストルメンテーションスコープ名
+const otlpScopeName = "vibe-coding-logger"
+
+// OTLPWriterOptions はOTLP/HTTP(JSON)エクスポーターの設定です
+type OTLPWriterOptions struct {
+	Endpoint           string                 // コレクターのベースURL（例: http://localhost:4318）
+	LogsPath           string                 // ログの送信先パス（空の場合は/v1/logs）
+	TracesPath         string                 // スパンの送信先パス（空の場合は/v1/traces）
+	Headers            map[string]string      // リクエストに付与するHTTPヘッダー
+	ServiceName        string                 // リソース属性service.nameの値（空の場合はvibe-coding-logger）
+	ResourceAttributes map[string]interface{} // 追加のリソース属性
+	BatchSize          int                    // 1回の送信にまとめる最大件数（0の場合は512）
+	MaxBufferSize      int                    // 送信待ちとして保持するログ・スパンそれぞれの最大件数（0の場合は2048とBatchSizeの大きい方）
+	FlushInterval      time.Duration          // 定期送信の間隔（0の場合は5秒）
+	Timeout            time.Duration          // 1回のHTTPリクエストのタイムアウト（0の場合は10秒）
+	ShutdownTimeout    time.Duration          // Flush・Closeで送信を待つ時間の上限（0の場合は10秒、超えた分は破棄してDroppedに計上する）
+	Retry              RetryPolicy            // 送信失敗時のリトライポリシー（MaxAttemptsが0の場合は5回）
+	HTTPClient         *http.Client           // 使用するHTTPクライアント（nilの場合は新規作成）
+	DisableLogs        bool                   // ログレコードを送信しない
+	DisableSpans       bool                   // 完了した操作をスパンとして送信しない
+	ErrorHandler       func(error)            // バックグラウンド送信時のエラーの通知先（nilの場合は標準出力）
+}
+
+// OTLPWriter はエントリをOTLPのLogRecordに、完了した操作をSpanに変換し、
+// OTLP/HTTP(JSON)でコレクターへバッチ送信するWriter
+//
+// START/COMPLETEまたはSTART/ERRORの組で記録された操作は、COMPLETE/ERRORエントリの
+// タイムスタンプとDurationから開始・終了時刻を求めてSpanに変換されます。
+type OTLPWriter struct {
+	opts   OTLPWriterOptions
+	client *http.Client
+
+	mu     sync.Mutex
+	logs   []otlpResourceLog
+	spans  []otlpResourceSpan
+	closed bool
+
+	// dropped は送信待ちのバッファが満杯、または送信の期限を過ぎたため破棄したログ・スパンの件数
+	dropped atomic.Uint64
+
+	exportSem chan struct{}      // 送信処理を直列化する（容量1のセマフォ）
+	ctx       context.Context    // バックグラウンド送信のコンテキスト（Closeの期限を過ぎると取り消される）
+	cancel    context.CancelFunc // ctxを取り消す
+	kick      chan struct{}
+	done      chan struct{}
+	stopped   chan struct{}
+	abortErr  error // Closeの期限により打ち切られたバックグラウンド送信のエラー（Closeで返す）
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewOTLPWriter は新しいOTLPライターを作成し、定期送信のゴルーチンを開始する
+func NewOTLPWriter(opts OTLPWriterOptions) (*OTLPWriter, error) {
+	if opts.Endpoint == "" {
+		return nil, errors.New("OTLPエンドポイントが指定されていません")
+	}
+	opts.Endpoint = strings.TrimRight(opts.Endpoint, "/")
+	if opts.LogsPath == "" {
+		opts.LogsPath = "/v1/logs"
+	}
+	if opts.TracesPath == "" {
+		opts.TracesPath = "/v1/traces"
+	}
+	if opts.ServiceName == "" {
+		opts.ServiceName = otlpScopeName
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 512
+	}
+	if opts.MaxBufferSize <= 0 {
+		opts.MaxBufferSize = max(2048, opts.BatchSize)
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 5 * time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.ShutdownTimeout <= 0 {
+		opts.ShutdownTimeout = 10 * time.Second
+	}
+	if opts.Retry.MaxAttempts <= 0 {
+		opts.Retry.MaxAttempts = 5
+	}
+	opts.Retry = opts.Retry.withDefaults()
+
+	client := opts.HTTPClient
+	if client == nil {
+		client = &http.Client{}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &OTLPWriter{
+		opts:      opts,
+		client:    client,
+		exportSem: make(chan struct{}, 1),
+		ctx:       ctx,
+		cancel:    cancel,
+		kick:      make(chan struct{}, 1),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	go w.run()
+
+	return w, nil
+}
+
+// Write はエントリをOTLP形式に変換してバッファに追加する
+// バッファがBatchSizeに達した場合はバックグラウンドで送信する
+// コレクターが応答せずリトライ中などでバッファがMaxBufferSizeに達している場合は破棄し、Droppedに計上する
+func (w *OTLPWriter) Write(entry *Entry) error {
+	var span *otlpSpan
+	if !w.opts.DisableSpans {
+		span = entryToOTLPSpan(entry)
+	}
+
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrOTLPWriterClosed
+	}
+
+	resource := w.resourceFor(entry)
+	if !w.opts.DisableLogs {
+		if len(w.logs) < w.opts.MaxBufferSize {
+			w.logs = append(w.logs, otlpResourceLog{resource: resource, record: entryToOTLPLogRecord(entry)})
+		} else {
+			w.dropped.Add(1)
+		}
+	}
+	if span != nil {
+		if len(w.spans) < w.opts.MaxBufferSize {
+			w.spans = append(w.spans, otlpResourceSpan{resource: resource, span: *span})
+		} else {
+			w.dropped.Add(1)
+		}
+	}
+	full := len(w.logs) >= w.opts.BatchSize || len(w.spans) >= w.opts.BatchSize
+	w.mu.Unlock()
+
+	if full {
+		select {
+		case w.kick <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Dropped は送信待ちのバッファが満杯、またはFlush・Closeの期限を過ぎたため破棄したログ・スパンの件数を取得する
+func (w *OTLPWriter) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+// Flush はバッファ内のログとスパンを直ちに送信する
+// ShutdownTimeoutまでに送信できなかったログ・スパンは破棄し、Droppedに計上する
+func (w *OTLPWriter) Flush() error {
+	ctx, cancel := context.WithTimeout(context.Background(), w.opts.ShutdownTimeout)
+	defer cancel()
+	return w.export(ctx)
+}
+
+// Close は定期送信を停止し、残りのバッファを送信する
+// コレクターが応答しない場合も、実行中のバックグラウンド送信を含めてShutdownTimeoutで打ち切り、
+// 送信できなかったログ・スパンは破棄してDroppedに計上する
+func (w *OTLPWriter) Close() error {
+	w.closeOnce.Do(func() {
+		w.mu.Lock()
+		w.closed = true
+		w.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), w.opts.ShutdownTimeout)
+		defer cancel()
+		stop := context.AfterFunc(ctx, w.cancel)
+		defer stop()
+
+		close(w.done)
+		<-w.stopped
+		w.closeErr = errors.Join(w.abortErr, w.export(ctx))
+		w.cancel()
+	})
+	return w.closeErr
+}
+
+// run は定期的に、またはバッファが満杯になったときに送信する
+func (w *OTLPWriter) run() {
+	defer close(w.stopped)
+
+	ticker := time.NewTicker(w.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-w.kick:
+		case <-w.done:
+			return
+		}
+		if err := w.export(w.ctx); err != nil {
+			if w.ctx.Err() != nil {
+				// Closeの期限で打ち切られた場合はCloseの戻り値として返す
+				w.abortErr = err
+				continue
+			}
+			w.handleError(err)
+		}
+	}
+}
+
+// handleError はバックグラウンド送信のエラーを通知する
+func (w *OTLPWriter) handleError(err error) {
+	if w.opts.ErrorHandler != nil {
+		w.opts.ErrorHandler(err)
+		return
+	}
+	fmt.Printf("OTLPWriter export error: %v\n", err)
+}
+
+// export はバッファ内のログとスパンをBatchSizeごとに送信する
+// ctxが終了した後に残っていたバッチと、ctxの終了により送信できなかったバッチは破棄し、Droppedに計上する
+func (w *OTLPWriter) export(ctx context.Context) error {
+	// 実行中の送信がctxの終了までに終わらなければ、送信せずにバッファを破棄する
+	select {
+	case w.exportSem <- struct{}{}:
+		defer func() { <-w.exportSem }()
+	case <-ctx.Done():
+	}
+
+	w.mu.Lock()
+	logs, spans := w.logs, w.spans
+	w.logs, w.spans = nil, nil
+	w.mu.Unlock()
+
+	var errs []error
+	var discarded int
+	for start := 0; start < len(logs); start += w.opts.BatchSize {
+		end := min(start+w.opts.BatchSize, len(logs))
+		if ctx.Err() != nil {
+			discarded += len(logs) - start
+			break
+		}
+		payload := buildOTLPLogsRequest(logs[start:end])
+		if err := w.send(ctx, w.opts.LogsPath, payload); err != nil {
+			if ctx.Err() != nil {
+				discarded += end - start
+				continue
+			}
+			errs = append(errs, err)
+		}
+	}
+	for start := 0; start < len(spans); start += w.opts.BatchSize {
+		end := min(start+w.opts.BatchSize, len(spans))
+		if ctx.Err() != nil {
+			discarded += len(spans) - start
+			break
+		}
+		payload := buildOTLPTracesRequest(spans[start:end])
+		if err := w.send(ctx, w.opts.TracesPath, payload); err != nil {
+			if ctx.Err() != nil {
+				discarded += end - start
+				continue
+			}
+			errs = append(errs, err)
+		}
+	}
+
+	if discarded > 0 {
+		w.dropped.Add(uint64(discarded))
+		errs = append(errs, fmt.Errorf("OTLPの送信が期限内に終わらなかったため%d件を破棄しました: %w", discarded, ctx.Err()))
+	}
+	return errors.Join(errs...)
+}
+
+// otlpHTTPError はコレクターがエラーを返した場合のエラー
+type otlpHTTPError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *otlpHTTPError) Error() string {
+	return fmt.Sprintf("OTLPコレクターがエラーを返しました: status=%d body=%s", e.StatusCode, e.Body)
+}
+
+// Retryable はOTLP/HTTPの仕様に従いリトライ可能なステータスかどうかを返す
+func (e *otlpHTTPError) Retryable() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// send はペイロードをJSONで送信し、リトライポリシーに従って再送する
+func (w *OTLPWriter) send(ctx context.Context, path string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	policy := w.opts.Retry
+	var lastErr error
+	var delay time.Duration
+
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		lastErr = w.post(ctx, w.opts.Endpoint+path, body)
+		if lastErr == nil {
+			return nil
+		}
+		// リクエスト単位のタイムアウトは呼び出し元のコンテキストが有効な限りリトライする
+		retryable := policy.IsRetryable(lastErr) ||
+			(ctx.Err() == nil && errors.Is(lastErr, context.DeadlineExceeded))
+		if attempt == policy.MaxAttempts || !retryable {
+			break
+		}
+
+		delay = policy.Backoff.NextDelay(attempt, delay)
+		select {
+		case <-policy.Clock.After(delay):
+		case <-ctx.Done():
+			return errors.Join(ctx.Err(), lastErr)
+		}
+	}
+	return lastErr
+}
+
+// post は1回分のHTTPリクエストを送信する
+func (w *OTLPWriter) post(ctx context.Context, url string, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, w.opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.opts.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &otlpHTTPError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+	return nil
+}
+
+// resourceFor はエントリのリソース属性を作成する（w.muを保持した状態で呼び出すこと）
+// service.nameと設定されたリソース属性に、エントリのSystemInfoを加える
+func (w *OTLPWriter) resourceFor(entry *Entry) []otlpKeyValue {
+	attrs := map[string]interface{}{
+		"service.name": w.opts.ServiceName,
+	}
+	for k, v := range w.opts.ResourceAttributes {
+		attrs[k] = v
+	}
+	for k, v := range entry.SystemInfo {
+		attrs[systemInfoAttributeKey(k)] = v
+	}
+	return toOTLPAttributes(attrs)
+}
+
+// systemInfoAttributeKey はSystemInfoのキーをOpenTelemetryのセマンティック規約の属性名に変換する
+func systemInfoAttributeKey(key string) string {
+	switch key {
+	case "hostname":
+		return "host.name"
+	case "os":
+		return "os.type"
+	case "arch":
+		return "host.arch"
+	case "pid":
+		return "process.pid"
+	case "go_version":
+		return "process.runtime.version"
+	case "working_dir":
+		return "process.working_directory"
+	default:
+		return "vibe.system." + key
+	}
+}
+
+// OTLP/JSONのデータ構造
+type (
+	otlpAnyValue struct {
+		StringValue *string           `json:"stringValue,omitempty"`
+		BoolValue   *bool             `json:"boolValue,omitempty"`
+		IntValue    *string           `json:"intValue,omitempty"`
+		DoubleValue *float64          `json:"doubleValue,omitempty"`
+		ArrayValue  *otlpArrayValue   `json:"arrayValue,omitempty"`
+		KvlistValue *otlpKeyValueList `json:"kvlistValue,omitempty"`
+	}
+	otlpArrayValue struct {
+		Values []otlpAnyValue `json:"values"`
+	}
+	otlpKeyValueList struct {
+		Values []otlpKeyValue `json:"values"`
+	}
+	otlpKeyValue struct {
+		Key   string       `json:"key"`
+		Value otlpAnyValue `json:"value"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpLogRecord struct {
+		TimeUnixNano         string         `json:"timeUnixNano"`
+		ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+		SeverityNumber       int            `json:"severityNumber"`
+		SeverityText         string         `json:"severityText"`
+		Body                 otlpAnyValue   `json:"body"`
+		Attributes           []otlpKeyValue `json:"attributes,omitempty"`
+		TraceID              string         `json:"traceId,omitempty"`
+		SpanID               string         `json:"spanId,omitempty"`
+	}
+	otlpSpanStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Name              string         `json:"name"`
+		Kind              int            `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Status            otlpSpanStatus `json:"status"`
+	}
+	otlpScopeLogs struct {
+		Scope      otlpScope       `json:"scope"`
+		LogRecords []otlpLogRecord `json:"logRecords"`
+	}
+	otlpResourceLogs struct {
+		Resource  otlpResource    `json:"resource"`
+		ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+	}
+	otlpLogsRequest struct {
+		ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpTracesRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+
+	// otlpResourceLog はリソース属性付きのログレコード（バッファ用）
+	otlpResourceLog struct {
+		resource []otlpKeyValue
+		record   otlpLogRecord
+	}
+	// otlpResourceSpan はリソース属性付きのスパン（バッファ用）
+	otlpResourceSpan struct {
+		resource []otlpKeyValue
+		span     otlpSpan
+	}
+)
+
+// OTLPのスパンステータスコードとスパン種別
+const (
+	otlpStatusOK         = 1
+	otlpStatusError      = 2
+	otlpSpanKindInternal = 1
+)
+
+// otlpSeverityNumber はLogLevelをOTLPのSeverityNumberに変換する
+func otlpSeverityNumber(level LogLevel) int {
+	switch level {
+	case DEBUG:
+		return 5
+	case INFO:
+		return 9
+	case WARN:
+		return 13
+	case ERROR:
+		return 17
+	case FATAL:
+		return 21
+	default:
+		return 0
+	}
+}
+
+// entryToOTLPLogRecord はエントリをOTLPのLogRecordに変換する
+func entryToOTLPLogRecord(entry *Entry) otlpLogRecord {
+	record := otlpLogRecord{
+		TimeUnixNano:         unixNano(entry.Timestamp),
+		ObservedTimeUnixNano: unixNano(time.Now()),
+		SeverityNumber:       otlpSeverityNumber(entry.Level),
+		SeverityText:         entry.Level.String(),
+		Body:                 toOTLPAnyValue(entry.Operation),
+		Attributes:           toOTLPAttributes(entryAttributes(entry)),
+	}
+	if isHexID(entry.TraceID, 32) {
+		record.TraceID = entry.TraceID
+	}
+	if isHexID(entry.SpanID, 16) {
+		record.SpanID = entry.SpanID
+	}
+	return record
+}
+
+// entryToOTLPSpan は操作のCOMPLETE/ERRORエントリをOTLPのSpanに変換する
+// 完了した操作のエントリでない場合、または有効なトレースID・スパンIDを持たない場合はnilを返す
+func entryToOTLPSpan(entry *Entry) *otlpSpan {
+	if entry.Action != ActionComplete && entry.Action != ActionError {
+		return nil
+	}
+	if !isHexID(entry.TraceID, 32) || !isHexID(entry.SpanID, 16) {
+		return nil
+	}
+
+	span := &otlpSpan{
+		TraceID:           entry.TraceID,
+		SpanID:            entry.SpanID,
+		Name:              entry.Operation,
+		Kind:              otlpSpanKindInternal,
+		StartTimeUnixNano: unixNano(entry.Timestamp.Add(-entry.Duration)),
+		EndTimeUnixNano:   unixNano(entry.Timestamp),
+		Attributes:        toOTLPAttributes(entryAttributes(entry)),
+		Status:            otlpSpanStatus{Code: otlpStatusOK},
+	}
+	if isHexID(entry.ParentID, 16) {
+		span.ParentSpanID = entry.ParentID
+	}
+	if entry.Action == ActionError {
+		span.Status.Code = otlpStatusError
+		if entry.Error != nil {
+			span.Status.Message = entry.Error.Message
+		}
+	}
+	return span
+}
+
+// entryAttributes はエントリのContext・Tags・入出力・エラー情報を属性に変換する
+func entryAttributes(entry *Entry) map[string]interface{} {
+	attrs := make(map[string]interface{}, len(entry.Context)+8)
+	for k, v := range entry.Context {
+		attrs[k] = v
+	}
+
+	attrs["vibe.entry_id"] = entry.ID
+	if entry.Action != "" {
+		attrs["vibe.action"] = string(entry.Action)
+	}
+	if len(entry.Tags) > 0 {
+		attrs["vibe.tags"] = entry.Tags
+	}
+	if len(entry.Input) > 0 {
+		attrs["vibe.input"] = entry.Input
+	}
+	if len(entry.Output) > 0 {
+		attrs["vibe.output"] = entry.Output
+	}
+	if entry.Duration > 0 {
+		attrs["vibe.duration_ms"] = entry.Duration.Milliseconds()
+	}
+	if caller, ok := entry.Metadata["caller"]; ok {
+		attrs["code.caller"] = caller
+	}
+	if fn, ok := entry.Metadata["function"]; ok {
+		attrs["code.function"] = fn
+	}
+	if entry.Error != nil {
+		attrs["exception.message"] = entry.Error.Message
+		attrs["exception.type"] = entry.Error.Type
+		if entry.Error.Stack != "" {
+			attrs["exception.stacktrace"] = entry.Error.Stack
+		}
+		if entry.Error.Code != "" {
+			attrs["vibe.error.code"] = entry.Error.Code
+		}
+		if entry.Error.Resolution != "" {
+			attrs["vibe.error.resolution"] = entry.Error.Resolution
+		}
+		attrs["vibe.error.retryable"] = entry.Error.Retryable
+	}
+	return attrs
+}
+
+// buildOTLPLogsRequest はログレコードをリソースごとにまとめたリクエストを作成する
+func buildOTLPLogsRequest(logs []otlpResourceLog) otlpLogsRequest {
+	req := otlpLogsRequest{ResourceLogs: make([]otlpResourceLogs, 0)}
+	index := make(map[string]int)
+
+	for _, l := range logs {
+		key := resourceKey(l.resource)
+		i, ok := index[key]
+		if !ok {
+			i = len(req.ResourceLogs)
+			index[key] = i
+			req.ResourceLogs = append(req.ResourceLogs, otlpResourceLogs{
+				Resource:  otlpResource{Attributes: l.resource},
+				ScopeLogs: []otlpScopeLogs{{Scope: otlpScope{Name: otlpScopeName}}},
+			})
+		}
+		scope := &req.ResourceLogs[i].ScopeLogs[0]
+		scope.LogRecords = append(scope.LogRecords, l.record)
+	}
+	return req
+}
+
+// buildOTLPTracesRequest はスパンをリソースごとにまとめたリクエストを作成する
+func buildOTLPTracesRequest(spans []otlpResourceSpan) otlpTracesRequest {
+	req := otlpTracesRequest{ResourceSpans: make([]otlpResourceSpans, 0)}
+	index := make(map[string]int)
+
+	for _, s := range spans {
+		key := resourceKey(s.resource)
+		i, ok := index[key]
+		if !ok {
+			i = len(req.ResourceSpans)
+			index[key] = i
+			req.ResourceSpans = append(req.ResourceSpans, otlpResourceSpans{
+				Resource:   otlpResource{Attributes: s.resource},
+				ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: otlpScopeName}}},
+			})
+		}
+		scope := &req.ResourceSpans[i].ScopeSpans[0]
+		scope.Spans = append(scope.Spans, s.span)
+	}
+	return req
+}
+
+// resourceKey はリソース属性を比較するためのキーを作成する
+func resourceKey(attrs []otlpKeyValue) string {
+	b, _ := json.Marshal(attrs)
+	return string(b)
+}
+
+// toOTLPAttributes はマップをキー順にソートしたOTLPの属性に変換する
+func toOTLPAttributes(m map[string]interface{}) []otlpKeyValue {
+	if len(m) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]otlpKeyValue, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, otlpKeyValue{Key: k, Value: toOTLPAnyValue(m[k])})
+	}
+	return attrs
+}
+
+// toOTLPAnyValue は任意の値をOTLPのAnyValueに変換する
+func toOTLPAnyValue(v interface{}) otlpAnyValue {
+	switch val := v.(type) {
+	case nil:
+		return otlpString("")
+	case string:
+		return otlpString(val)
+	case bool:
+		return otlpAnyValue{BoolValue: &val}
+	case int:
+		return otlpInt(int64(val))
+	case int8:
+		return otlpInt(int64(val))
+	case int16:
+		return otlpInt(int64(val))
+	case int32:
+		return otlpInt(int64(val))
+	case int64:
+		return otlpInt(val)
+	case uint:
+		return otlpUint(uint64(val))
+	case uint8:
+		return otlpUint(uint64(val))
+	case uint16:
+		return otlpUint(uint64(val))
+	case uint32:
+		return otlpUint(uint64(val))
+	case uint64:
+		return otlpUint(val)
+	case float32:
+		f := float64(val)
+		return otlpAnyValue{DoubleValue: &f}
+	case float64:
+		return otlpAnyValue{DoubleValue: &val}
+	case time.Duration:
+		return otlpString(val.String())
+	case time.Time:
+		return otlpString(val.Format(time.RFC3339Nano))
+	case error:
+		return otlpString(val.Error())
+	case fmt.Stringer:
+		return otlpString(val.String())
+	case map[string]interface{}:
+		return otlpAnyValue{KvlistValue: &otlpKeyValueList{Values: toOTLPAttributes(val)}}
+	case []interface{}:
+		values := make([]otlpAnyValue, 0, len(val))
+		for _, item := range val {
+			values = append(values, toOTLPAnyValue(item))
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: values}}
+	}
+
+	// その他のスライス・マップはリフレクションで変換する
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		values := make([]otlpAnyValue, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			values = append(values, toOTLPAnyValue(rv.Index(i).Interface()))
+		}
+		return otlpAnyValue{ArrayValue: &otlpArrayValue{Values: values}}
+	case reflect.Map:
+		m := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			m[fmt.Sprint(iter.Key().Interface())] = iter.Value().Interface()
+		}
+		return otlpAnyValue{KvlistValue: &otlpKeyValueList{Values: toOTLPAttributes(m)}}
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return otlpString("")
+		}
+		return toOTLPAnyValue(rv.Elem().Interface())
+	}
+
+	// 構造体などはJSON文字列として送信する
+	if b, err := json.Marshal(v); err == nil {
+		return otlpString(string(b))
+	}
+	return otlpString(fmt.Sprintf("%v", v))
+}
+
+func otlpString(s string) otlpAnyValue {
+	return otlpAnyValue{StringValue: &s}
+}
+
+func otlpInt(i int64) otlpAnyValue {
+	s := strconv.FormatInt(i, 10)
+	return otlpAnyValue{IntValue: &s}
+}
+
+// otlpUint は符号なし整数をAnyValueに変換する
+// OTLPのintValueはint64のため、math.MaxInt64を超える値は精度を保つためstringValueとして送信する
+func otlpUint(u uint64) otlpAnyValue {
+	if u > math.MaxInt64 {
+		return otlpString(strconv.FormatUint(u, 10))
+	}
+	return otlpInt(int64(u))
+}
+
+// unixNano は時刻をOTLP/JSON形式のナノ秒文字列に変換する
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// isHexID は文字列が指定した長さの16進数IDかどうかを判定する
+func isHexID(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	allZero := true
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9', c >= 'a' && c <= 'f':
+			if c != '0' {
+				allZero = false
+			}
+		default:
+			return false
+		}
+	}
+	return !allZero
+}
@@ -0,0 +1,303 @@
+package logger
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// otlpCollector はOTLP/HTTP(JSON)のリクエストを受け取って記録するテスト用のコレクター
+type otlpCollector struct {
+	mu       sync.Mutex
+	logs     []map[string]interface{}
+	spans    []map[string]interface{}
+	headers  []http.Header
+	failures int // 先頭から503を返すリクエスト数
+	requests int
+}
+
+func (c *otlpCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requests++
+	if c.failures > 0 {
+		c.failures--
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	c.headers = append(c.headers, r.Header.Clone())
+
+	var body struct {
+		ResourceLogs []struct {
+			ScopeLogs []struct {
+				LogRecords []map[string]interface{} `json:"logRecords"`
+			} `json:"scopeLogs"`
+		} `json:"resourceLogs"`
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []map[string]interface{} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/v1/logs":
+		for _, rl := range body.ResourceLogs {
+			for _, sl := range rl.ScopeLogs {
+				c.logs = append(c.logs, sl.LogRecords...)
+			}
+		}
+	case "/v1/traces":
+		for _, rs := range body.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				c.spans = append(c.spans, ss.Spans...)
+			}
+		}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (c *otlpCollector) counts() (logs, spans, requests int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.logs), len(c.spans), c.requests
+}
+
+// newTestOTLPWriter はテスト用のコレクターに送信するOTLPライターを作成する
+func newTestOTLPWriter(t *testing.T, collector *otlpCollector, opts OTLPWriterOptions) *OTLPWriter {
+	t.Helper()
+
+	server := httptest.NewServer(collector)
+	t.Cleanup(server.Close)
+
+	opts.Endpoint = server.URL
+	if opts.FlushInterval == 0 {
+		opts.FlushInterval = time.Hour // テストではFlushで送信する
+	}
+	if opts.Retry.Clock == nil {
+		opts.Retry.Clock = NewFakeClock(time.Now())
+	}
+
+	w, err := NewOTLPWriter(opts)
+	if err != nil {
+		t.Fatalf("NewOTLPWriter: %v", err)
+	}
+	t.Cleanup(func() { w.Close() })
+	return w
+}
+
+func TestOTLPWriterExportsLogsAndSpans(t *testing.T) {
+	collector := &otlpCollector{}
+	w := newTestOTLPWriter(t, collector, OTLPWriterOptions{
+		Headers: map[string]string{"Authorization": "Bearer test"},
+	})
+
+	now := time.Now()
+	entries := []*Entry{
+		{ID: "1", Timestamp: now, Level: INFO, Action: ActionStart, Operation: "build",
+			TraceID: "0123456789abcdef0123456789abcdef", SpanID: "0123456789abcdef"},
+		{ID: "2", Timestamp: now.Add(time.Second), Level: INFO, Action: ActionComplete, Operation: "build",
+			TraceID: "0123456789abcdef0123456789abcdef", SpanID: "0123456789abcdef", Duration: time.Second},
+		{ID: "3", Timestamp: now, Level: WARN, Operation: "plain"},
+	}
+	for _, e := range entries {
+		if err := w.Write(e); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	logs, spans, _ := collector.counts()
+	if logs != 3 {
+		t.Errorf("ログレコード数 = %d, want 3", logs)
+	}
+	if spans != 1 {
+		t.Fatalf("スパン数 = %d, want 1", spans)
+	}
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	span := collector.spans[0]
+	if span["name"] != "build" || span["traceId"] != "0123456789abcdef0123456789abcdef" {
+		t.Errorf("スパン = %v", span)
+	}
+	if got := collector.logs[2]["severityText"]; got != "WARN" {
+		t.Errorf("severityText = %v, want WARN", got)
+	}
+	for _, h := range collector.headers {
+		if h.Get("Authorization") != "Bearer test" || h.Get("Content-Type") != "application/json" {
+			t.Errorf("ヘッダー = %v", h)
+		}
+	}
+}
+
+func TestOTLPWriterRetriesUnavailableCollector(t *testing.T) {
+	collector := &otlpCollector{failures: 2}
+	clock := NewFakeClock(time.Now())
+	w := newTestOTLPWriter(t, collector, OTLPWriterOptions{
+		DisableSpans: true,
+		Retry: RetryPolicy{
+			MaxAttempts: 5,
+			Backoff:     ConstantBackoff{Delay: time.Second},
+			Clock:       clock,
+		},
+	})
+
+	if err := w.Write(&Entry{ID: "1", Timestamp: time.Now(), Level: INFO, Operation: "op"}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	logs, _, requests := collector.counts()
+	if logs != 1 || requests != 3 {
+		t.Errorf("ログレコード数 = %d, リクエスト数 = %d, want 1・3", logs, requests)
+	}
+	if got := len(clock.Sleeps()); got != 2 {
+		t.Errorf("待機回数 = %d, want 2", got)
+	}
+}
+
+func TestOTLPWriterBoundsBuffer(t *testing.T) {
+	collector := &otlpCollector{}
+	w := newTestOTLPWriter(t, collector, OTLPWriterOptions{
+		BatchSize:     100,
+		MaxBufferSize: 10,
+		DisableSpans:  true,
+	})
+
+	for i := 0; i < 25; i++ {
+		if err := w.Write(&Entry{ID: "x", Timestamp: time.Now(), Level: INFO, Operation: "op"}); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if got := w.Dropped(); got != 15 {
+		t.Errorf("破棄された件数 = %d, want 15", got)
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if logs, _, _ := collector.counts(); logs != 10 {
+		t.Errorf("ログレコード数 = %d, want 10", logs)
+	}
+
+	// 送信後はバッファに空きができる
+	if err := w.Write(&Entry{ID: "y", Timestamp: time.Now(), Level: INFO, Operation: "op"}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got := w.Dropped(); got != 15 {
+		t.Errorf("送信後に破棄された件数 = %d, want 15", got)
+	}
+}
+
+// newHangingOTLPWriter は応答しないコレクターに送信するOTLPライターを作成する
+func newHangingOTLPWriter(t *testing.T, opts OTLPWriterOptions) *OTLPWriter {
+	t.Helper()
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	opts.Endpoint = server.URL
+	opts.FlushInterval = time.Hour
+	opts.DisableSpans = true
+	w, err := NewOTLPWriter(opts)
+	if err != nil {
+		t.Fatalf("NewOTLPWriter: %v", err)
+	}
+	return w
+}
+
+func TestOTLPWriterCloseDeadline(t *testing.T) {
+	w := newHangingOTLPWriter(t, OTLPWriterOptions{BatchSize: 1, ShutdownTimeout: 100 * time.Millisecond})
+
+	// BatchSizeに達するとバックグラウンドの送信が始まり、応答を待ち続ける
+	for i := 0; i < 3; i++ {
+		if err := w.Write(&Entry{ID: "x", Timestamp: time.Now(), Level: INFO, Operation: "op"}); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	start := time.Now()
+	if err := w.Close(); err == nil {
+		t.Error("期限を過ぎたCloseがエラーを返しませんでした")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Closeに%vかかりました, want ShutdownTimeout付近", elapsed)
+	}
+	if got := w.Dropped(); got != 3 {
+		t.Errorf("破棄された件数 = %d, want 3", got)
+	}
+}
+
+func TestOTLPWriterFlushDeadline(t *testing.T) {
+	w := newHangingOTLPWriter(t, OTLPWriterOptions{BatchSize: 2, ShutdownTimeout: 100 * time.Millisecond})
+	t.Cleanup(func() { w.Close() })
+
+	w.mu.Lock()
+	for i := 0; i < 5; i++ {
+		w.logs = append(w.logs, otlpResourceLog{record: otlpLogRecord{SeverityText: "INFO"}})
+	}
+	w.mu.Unlock()
+
+	start := time.Now()
+	if err := w.Flush(); err == nil {
+		t.Error("期限を過ぎたFlushがエラーを返しませんでした")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Flushに%vかかりました, want ShutdownTimeout付近", elapsed)
+	}
+	// 送信中のバッチと残りのバッチをすべて破棄する
+	if got := w.Dropped(); got != 5 {
+		t.Errorf("破棄された件数 = %d, want 5", got)
+	}
+}
+
+func TestToOTLPAnyValueUint(t *testing.T) {
+	tests := []struct {
+		value      interface{}
+		wantInt    string
+		wantString string
+	}{
+		{uint(42), "42", ""},
+		{uint64(math.MaxInt64), "9223372036854775807", ""},
+		{uint64(math.MaxInt64) + 1, "", "9223372036854775808"},
+		{uint64(math.MaxUint64), "", "18446744073709551615"},
+	}
+	for _, tt := range tests {
+		got := toOTLPAnyValue(tt.value)
+		switch {
+		case tt.wantInt != "":
+			if got.IntValue == nil || *got.IntValue != tt.wantInt {
+				t.Errorf("%v: intValue = %v, want %s", tt.value, got.IntValue, tt.wantInt)
+			}
+		default:
+			// int64の範囲を超える値は桁を失わないよう文字列で送信する
+			if got.IntValue != nil || got.StringValue == nil || *got.StringValue != tt.wantString {
+				t.Errorf("%v: %+v, want stringValue %s", tt.value, got, tt.wantString)
+			}
+		}
+	}
+}
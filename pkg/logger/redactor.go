@@ -0,0 +1,367 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// DefaultRedactionText はマスク後の値として使われるデフォルトの文字列
+const DefaultRedactionText = "[REDACTED]"
+
+// maxRedactionDepth はネストしたマップ・スライスをたどる深さの上限（循環参照対策）
+const maxRedactionDepth = 32
+
+// DefaultRedactKeys は値を丸ごとマスクするデフォルトのキー名
+// キーは小文字化し区切り文字（_ - .）を除いた全体、または区切り・キャメルケースで分割した各語と比較される
+// 例: "access_token" "apiKey" "X-Authorization" "client_secret" は一致し、"max_tokens" は一致しない
+var DefaultRedactKeys = []string{
+	"password", "passwd", "pwd", "passphrase",
+	"secret", "token", "authorization", "cookie",
+	"apikey", "accesskey", "privatekey", "credential", "credentials",
+}
+
+// DefaultAllowedEnvVars はSystemInfoの環境変数のうち出力を許可するデフォルトの変数名
+var DefaultAllowedEnvVars = []string{
+	"SHELL", "TERM", "GOOS", "GOARCH", "CI",
+}
+
+// ValueRule は値に含まれる機密情報を正規表現で検出するルール
+type ValueRule struct {
+	Name    string         // ルール名（マスク後の文字列に含まれる）
+	Pattern *regexp.Regexp // 検出する正規表現（サブマッチがある場合は最初のサブマッチのみをマスク）
+	// Validate は一致した文字列を追加で検証する関数（nilの場合は一致したものをすべてマスク）
+	Validate func(match string) bool
+}
+
+// DefaultValueRules はデフォルトの値ルール（JWT・AWSキー・Bearerトークン・URLの認証情報・メールアドレス・クレジットカード番号）
+var DefaultValueRules = []ValueRule{
+	{Name: "jwt", Pattern: regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)},
+	{Name: "aws_access_key", Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{Name: "bearer", Pattern: regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`)},
+	{Name: "url_credentials", Pattern: regexp.MustCompile(`[A-Za-z][A-Za-z0-9+.-]*://([^/\s:@]+:[^/\s@]+)@`)},
+	{Name: "email", Pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+	{Name: "credit_card", Pattern: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), Validate: luhnValid},
+}
+
+// RedactorOptions はRedactorの設定です
+type RedactorOptions struct {
+	// Keys は値を丸ごとマスクするキー名（nilの場合はDefaultRedactKeys）
+	Keys []string
+	// ValueRules は値の中の機密情報を検出するルール（nilの場合はDefaultValueRules）
+	ValueRules []ValueRule
+	// AllowedEnvVars はSystemInfoの環境変数のうち出力を許可する変数名（nilの場合はDefaultAllowedEnvVars）
+	AllowedEnvVars []string
+	// Replacement はマスク後の文字列（空の場合は[REDACTED]）
+	Replacement string
+}
+
+// Redactor はエントリに含まれる秘密情報や個人情報をフォーマット前にマスクする
+// Context・Input・Output・ErrorInfo（Message・Context）を対象に、ネストしたマップとスライスもたどる
+// 呼び出し元から渡されたマップは変更せず、マスクした値はコピーに設定する
+// ロガーはデフォルトではマスクを行わないため、RedactableLogger.SetRedactorで設定して有効にする
+type Redactor struct {
+	keys        map[string]struct{}
+	valueRules  []ValueRule
+	allowedEnv  map[string]struct{}
+	replacement string
+}
+
+// NewRedactor は新しいRedactorを作成する
+func NewRedactor(opts RedactorOptions) *Redactor {
+	if opts.Keys == nil {
+		opts.Keys = DefaultRedactKeys
+	}
+	if opts.ValueRules == nil {
+		opts.ValueRules = DefaultValueRules
+	}
+	if opts.AllowedEnvVars == nil {
+		opts.AllowedEnvVars = DefaultAllowedEnvVars
+	}
+	if opts.Replacement == "" {
+		opts.Replacement = DefaultRedactionText
+	}
+
+	r := &Redactor{
+		keys:        make(map[string]struct{}, len(opts.Keys)),
+		valueRules:  opts.ValueRules,
+		allowedEnv:  make(map[string]struct{}, len(opts.AllowedEnvVars)),
+		replacement: opts.Replacement,
+	}
+	for _, key := range opts.Keys {
+		r.keys[normalizeRedactKey(key)] = struct{}{}
+	}
+	for _, name := range opts.AllowedEnvVars {
+		r.allowedEnv[strings.ToUpper(name)] = struct{}{}
+	}
+	return r
+}
+
+// DefaultRedactor はデフォルトのルールを持つRedactorを作成する
+func DefaultRedactor() *Redactor {
+	return NewRedactor(RedactorOptions{})
+}
+
+// Redact はエントリの機密情報をマスクし、マスクした件数を返す
+// 1件以上マスクした場合はMetadata["redactions"]に件数を記録する
+func (r *Redactor) Redact(entry *Entry) int {
+	if r == nil || entry == nil {
+		return 0
+	}
+
+	count := 0
+	entry.Context = r.redactMapField(entry.Context, &count)
+	entry.Input = r.redactMapField(entry.Input, &count)
+	entry.Output = r.redactMapField(entry.Output, &count)
+
+	if entry.Error != nil {
+		errorInfo := *entry.Error
+		errorInfo.Message = r.redactString(errorInfo.Message, &count)
+		errorInfo.Context = r.redactMapField(errorInfo.Context, &count)
+		entry.Error = &errorInfo
+	}
+
+	if env, ok := entry.SystemInfo["environment"]; ok {
+		systemInfo := make(map[string]interface{}, len(entry.SystemInfo))
+		for k, v := range entry.SystemInfo {
+			systemInfo[k] = v
+		}
+		systemInfo["environment"] = r.filterEnvironment(env, &count)
+		entry.SystemInfo = systemInfo
+	}
+
+	if count > 0 {
+		if entry.Metadata == nil {
+			entry.Metadata = make(map[string]interface{})
+		}
+		entry.Metadata["redactions"] = count
+	}
+	return count
+}
+
+// redactMapField はエントリのマップフィールドをマスクする
+func (r *Redactor) redactMapField(m map[string]interface{}, count *int) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	return r.redactMap(m, count, 0)
+}
+
+// redactMap はマップの各値をマスクしたコピーを返す
+func (r *Redactor) redactMap(m map[string]interface{}, count *int, depth int) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if r.matchKey(k) {
+			redacted[k] = r.replacement
+			*count++
+			continue
+		}
+		redacted[k] = r.redactValue(v, count, depth+1)
+	}
+	return redacted
+}
+
+// redactValue は値の種類に応じてマスクする
+func (r *Redactor) redactValue(v interface{}, count *int, depth int) interface{} {
+	if depth > maxRedactionDepth {
+		return v
+	}
+
+	switch t := v.(type) {
+	case string:
+		return r.redactString(t, count)
+	case map[string]interface{}:
+		return r.redactMap(t, count, depth)
+	case map[string]string:
+		redacted := make(map[string]string, len(t))
+		for k, s := range t {
+			if r.matchKey(k) {
+				redacted[k] = r.replacement
+				*count++
+				continue
+			}
+			redacted[k] = r.redactString(s, count)
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(t))
+		for i, item := range t {
+			redacted[i] = r.redactValue(item, count, depth+1)
+		}
+		return redacted
+	case []string:
+		redacted := make([]string, len(t))
+		for i, s := range t {
+			redacted[i] = r.redactString(s, count)
+		}
+		return redacted
+	case []map[string]interface{}:
+		redacted := make([]map[string]interface{}, len(t))
+		for i, m := range t {
+			redacted[i] = r.redactMap(m, count, depth+1)
+		}
+		return redacted
+	case *SystemInfo:
+		if t == nil {
+			return t
+		}
+		systemInfo := *t
+		systemInfo.Environment = r.filterEnvironment(t.Environment, count).(map[string]string)
+		return &systemInfo
+	default:
+		return v
+	}
+}
+
+// redactString は値ルールに一致した部分をマスクする
+// 正規表現にサブマッチがある場合は最初のサブマッチのみをマスクする
+func (r *Redactor) redactString(s string, count *int) string {
+	if s == "" {
+		return s
+	}
+	for _, rule := range r.valueRules {
+		if rule.Pattern == nil {
+			continue
+		}
+		matches := rule.Pattern.FindAllStringSubmatchIndex(s, -1)
+		if len(matches) == 0 {
+			continue
+		}
+
+		mask := r.replacement
+		if rule.Name != "" {
+			mask = "[REDACTED:" + rule.Name + "]"
+		}
+
+		var b strings.Builder
+		last := 0
+		for _, m := range matches {
+			start, end := m[0], m[1]
+			if len(m) >= 4 && m[2] >= 0 {
+				start, end = m[2], m[3]
+			}
+			if rule.Validate != nil && !rule.Validate(s[start:end]) {
+				continue
+			}
+			b.WriteString(s[last:start])
+			b.WriteString(mask)
+			last = end
+			*count++
+		}
+		b.WriteString(s[last:])
+		s = b.String()
+	}
+	return s
+}
+
+// filterEnvironment は許可リストにない環境変数を取り除く
+func (r *Redactor) filterEnvironment(env interface{}, count *int) interface{} {
+	switch t := env.(type) {
+	case map[string]string:
+		filtered := make(map[string]string, len(t))
+		for k, v := range t {
+			if _, ok := r.allowedEnv[strings.ToUpper(k)]; ok {
+				filtered[k] = v
+			} else {
+				*count++
+			}
+		}
+		return filtered
+	case map[string]interface{}:
+		filtered := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			if _, ok := r.allowedEnv[strings.ToUpper(k)]; ok {
+				filtered[k] = v
+			} else {
+				*count++
+			}
+		}
+		return filtered
+	default:
+		return env
+	}
+}
+
+// matchKey はキー名がマスク対象かどうかを判定する
+func (r *Redactor) matchKey(key string) bool {
+	if len(r.keys) == 0 {
+		return false
+	}
+	if _, ok := r.keys[normalizeRedactKey(key)]; ok {
+		return true
+	}
+	for _, word := range splitRedactKey(key) {
+		if _, ok := r.keys[word]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeRedactKey はキーを小文字化し区切り文字を取り除く
+func normalizeRedactKey(key string) string {
+	var b strings.Builder
+	b.Grow(len(key))
+	for _, c := range key {
+		if c == '_' || c == '-' || c == '.' || c == ' ' {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(c))
+	}
+	return b.String()
+}
+
+// splitRedactKey はキーを区切り文字とキャメルケースで分割し、小文字化した語のリストを返す
+func splitRedactKey(key string) []string {
+	var words []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, strings.ToLower(string(current)))
+			current = current[:0]
+		}
+	}
+
+	runes := []rune(key)
+	for i, c := range runes {
+		switch {
+		case c == '_' || c == '-' || c == '.' || c == ' ':
+			flush()
+		case unicode.IsUpper(c) && i > 0 && (unicode.IsLower(runes[i-1]) ||
+			(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))):
+			flush()
+			current = append(current, c)
+		default:
+			current = append(current, c)
+		}
+	}
+	flush()
+	return words
+}
+
+// luhnValid は数字列がLuhnチェックを満たすかどうかを判定する（クレジットカード番号の誤検出防止）
+func luhnValid(s string) bool {
+	sum := 0
+	digits := 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		if c < '0' || c > '9' {
+			return false
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+		double = !double
+	}
+	return digits >= 13 && sum%10 == 0
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package logger
+
+import "os"
+
+// defaultReopenSignals はInstallReopenHandlerがデフォルトで捕捉するシグナル（SIGHUPのない環境では捕捉しない）
+var defaultReopenSignals []os.Signal
@@ -0,0 +1,11 @@
+//go:build unix
+
+package logger
+
+import (
+	"os"
+	"syscall"
+)
+
+// defaultReopenSignals はInstallReopenHandlerがデフォルトで捕捉するシグナル
+var defaultReopenSignals = []os.Signal{syscall.SIGHUP}
@@ -0,0 +1,77 @@
+//go:build unix
+
+package logger
+
+import (
+	"errors"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// reopenCountWriter はReopenの呼び出し回数を記録するテスト用のWriter
+type reopenCountWriter struct {
+	memoryWriter
+	reopens atomic.Int32
+	err     error
+}
+
+func (w *reopenCountWriter) Reopen() error {
+	w.reopens.Add(1)
+	return w.err
+}
+
+func TestLoggerReopen(t *testing.T) {
+	direct := &reopenCountWriter{}
+	wrapped := &reopenCountWriter{}
+	failing := &reopenCountWriter{err: errors.New("reopen failed")}
+	async := NewAsyncWriter(wrapped, AsyncWriterOptions{})
+	defer async.Close()
+
+	l := New(DEBUG)
+	l.AddWriter(direct)
+	l.AddWriter(async)
+	l.AddWriter(failing)
+	// ReopenableWriterでないWriterは対象外
+	l.AddWriter(&memoryWriter{})
+
+	err := l.(ReopenableLogger).Reopen()
+	if !errors.Is(err, failing.err) {
+		t.Errorf("Reopen = %v, want %v", err, failing.err)
+	}
+	// 失敗したWriterがあっても残りのWriterは開き直す
+	for name, w := range map[string]*reopenCountWriter{"direct": direct, "wrapped": wrapped, "failing": failing} {
+		if got := w.reopens.Load(); got != 1 {
+			t.Errorf("%sのReopen回数 = %d, want 1", name, got)
+		}
+	}
+}
+
+func TestInstallReopenHandler(t *testing.T) {
+	w := &reopenCountWriter{}
+	l := New(DEBUG)
+	l.AddWriter(w)
+
+	stop := InstallReopenHandler(l)
+	defer stop()
+
+	// シグナルを省略するとSIGHUPで開き直す
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for w.reopens.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("SIGHUPで開き直されませんでした")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestInstallReopenHandlerNotReopenable(t *testing.T) {
+	// ReopenableLoggerを実装していないLoggerではシグナルを捕捉しない
+	stop := InstallReopenHandler(struct{ Logger }{})
+	stop()
+	stop()
+}
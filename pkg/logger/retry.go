@@ -0,0 +1,360 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Clock は時刻の取得と待機を抽象化する
+// テストではFakeClockに差し替えることで待機時間を決定的に検証できる
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock は実時間を使うClock
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// FakeClock はテスト用のClock
+// Afterは待機せずに即座に完了し、現在時刻を待機時間分だけ進めて待機時間を記録する
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	sleeps []time.Duration
+}
+
+// NewFakeClock は指定した時刻から開始するFakeClockを作成する
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// Now は現在時刻を取得する
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After は時刻をdだけ進め、即座に完了するチャネルを返す
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	c.sleeps = append(c.sleeps, d)
+
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+// Advance は時刻をdだけ進める
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Sleeps はこれまでに要求された待機時間を取得する
+func (c *FakeClock) Sleeps() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sleeps := make([]time.Duration, len(c.sleeps))
+	copy(sleeps, c.sleeps)
+	return sleeps
+}
+
+// BackoffStrategy はリトライ間の待機時間を決定する
+type BackoffStrategy interface {
+	// NextDelay はattempt回目（1始まり）の失敗後の待機時間を返す
+	// previousは直前の待機時間（初回は0）
+	NextDelay(attempt int, previous time.Duration) time.Duration
+}
+
+// ConstantBackoff は常に同じ時間待機する
+type ConstantBackoff struct {
+	Delay time.Duration
+}
+
+// NextDelay は待機時間を返す
+func (b ConstantBackoff) NextDelay(attempt int, previous time.Duration) time.Duration {
+	return b.Delay
+}
+
+// LinearBackoff は試行ごとに一定時間ずつ待機時間を増やす
+type LinearBackoff struct {
+	Initial   time.Duration // 初回の待機時間
+	Increment time.Duration // 試行ごとの増分
+	Max       time.Duration // 待機時間の上限（0の場合は上限なし）
+}
+
+// NextDelay は待機時間を返す
+func (b LinearBackoff) NextDelay(attempt int, previous time.Duration) time.Duration {
+	delay := b.Initial + b.Increment*time.Duration(attempt-1)
+	return capDelay(delay, b.Max)
+}
+
+// JitterMode は指数バックオフに加えるジッターの種類を表す
+type JitterMode int
+
+const (
+	// JitterNone はジッターを加えない
+	JitterNone JitterMode = iota
+	// JitterFull は0から計算値までの一様乱数を待機時間とする
+	JitterFull
+	// JitterEqual は計算値の半分に、0から半分までの一様乱数を加える
+	JitterEqual
+)
+
+// ExponentialBackoff は試行ごとに待機時間を指数的に増やす
+type ExponentialBackoff struct {
+	Initial    time.Duration  // 初回の待機時間
+	Multiplier float64        // 試行ごとの倍率（0の場合は2）
+	Max        time.Duration  // 待機時間の上限（0の場合は上限なし）
+	Jitter     JitterMode     // ジッターの種類
+	Rand       func() float64 // [0.0, 1.0)の乱数を返す関数（nilの場合はmath/rand/v2）
+}
+
+// NextDelay は待機時間を返す
+func (b ExponentialBackoff) NextDelay(attempt int, previous time.Duration) time.Duration {
+	multiplier := b.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	delay := float64(b.Initial)
+	for i := 1; i < attempt; i++ {
+		delay *= multiplier
+		if b.Max > 0 && delay >= float64(b.Max) {
+			delay = float64(b.Max)
+			break
+		}
+		// 上限がない場合も、time.Durationに変換できる範囲を超えたら増やさない
+		if delay >= float64(maxBackoffDelay) {
+			break
+		}
+	}
+	base := capDelay(floatDelay(delay), b.Max)
+
+	switch b.Jitter {
+	case JitterFull:
+		return floatDelay(randFloat(b.Rand) * float64(base))
+	case JitterEqual:
+		half := base / 2
+		return half + floatDelay(randFloat(b.Rand)*float64(base-half))
+	default:
+		return base
+	}
+}
+
+// DecorrelatedJitterBackoff は直前の待機時間をもとに乱数で待機時間を決定する
+// 待機時間は Base から 直前の待機時間×3 までの一様乱数（上限Max）となる
+type DecorrelatedJitterBackoff struct {
+	Base time.Duration  // 待機時間の下限（0の場合は100ms）
+	Max  time.Duration  // 待機時間の上限（0の場合は上限なし）
+	Rand func() float64 // [0.0, 1.0)の乱数を返す関数（nilの場合はmath/rand/v2）
+}
+
+// NextDelay は待機時間を返す
+func (b DecorrelatedJitterBackoff) NextDelay(attempt int, previous time.Duration) time.Duration {
+	base := b.Base
+	if base <= 0 {
+		// 下限が0だと直前の待機時間も0のままになり、待機せずにリトライし続ける
+		base = defaultDecorrelatedBase
+	}
+	if previous < base {
+		previous = base
+	}
+	upper := floatDelay(float64(previous) * 3)
+	delay := floatDelay(float64(base) + randFloat(b.Rand)*float64(upper-base))
+	return capDelay(delay, b.Max)
+}
+
+const (
+	// maxBackoffDelay はtime.Durationで表せる最大の待機時間
+	maxBackoffDelay = time.Duration(math.MaxInt64)
+	// defaultDecorrelatedBase はDecorrelatedJitterBackoffのBaseが0の場合の下限
+	defaultDecorrelatedBase = 100 * time.Millisecond
+)
+
+// floatDelay は浮動小数点数の待機時間をtime.Durationに変換する
+// 表せる範囲を超える場合は溢れて負の値にならないよう最大値にする
+func floatDelay(delay float64) time.Duration {
+	if delay >= float64(maxBackoffDelay) {
+		return maxBackoffDelay
+	}
+	return time.Duration(delay)
+}
+
+// capDelay は待機時間を上限で切り詰める
+func capDelay(delay, max time.Duration) time.Duration {
+	if delay < 0 {
+		return 0
+	}
+	if max > 0 && delay > max {
+		return max
+	}
+	return delay
+}
+
+// randFloat は乱数関数が指定されていればそれを、なければmath/rand/v2を使う
+func randFloat(fn func() float64) float64 {
+	if fn != nil {
+		return fn()
+	}
+	return rand.Float64()
+}
+
+// RetryPolicy はリトライの回数・待機時間・リトライ可否の判定方法を定義する
+type RetryPolicy struct {
+	// MaxAttempts は最大試行回数（0の場合は3）
+	MaxAttempts int
+	// Backoff はリトライ間の待機時間（nilの場合は100msからの指数バックオフ・フルジッター）
+	Backoff BackoffStrategy
+	// RetryableErrors はerrors.Isで一致した場合にリトライするエラー
+	RetryableErrors []error
+	// NonRetryableErrors はerrors.Isで一致した場合にリトライしないエラー
+	NonRetryableErrors []error
+	// Classifier は独自のリトライ可否判定（nilでない場合は他の判定より優先される）
+	Classifier func(err error) bool
+	// StopOnUnclassified はどの判定にも当てはまらないエラーでリトライを止める
+	StopOnUnclassified bool
+	// Clock は待機に使うClock（nilの場合は実時間）
+	Clock Clock
+}
+
+// retryableError はリトライ可否を自己申告するエラー
+type retryableError interface {
+	Retryable() bool
+}
+
+// IsRetryable はエラーがリトライ可能かどうかを判定する
+//
+// 判定の優先順位は次のとおり:
+//  1. Classifier
+//  2. context.Canceled / context.DeadlineExceeded（リトライしない）
+//  3. NonRetryableErrors（errors.Is）
+//  4. RetryableErrors（errors.Is）
+//  5. errors.Asで取り出した*ErrorInfoのRetryable
+//  6. errors.Asで取り出したRetryable() boolを実装するエラー
+//  7. StopOnUnclassifiedがfalseであればリトライする
+func (p RetryPolicy) IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if p.Classifier != nil {
+		return p.Classifier(err)
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	for _, target := range p.NonRetryableErrors {
+		if errors.Is(err, target) {
+			return false
+		}
+	}
+	for _, target := range p.RetryableErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	var errorInfo *ErrorInfo
+	if errors.As(err, &errorInfo) {
+		return errorInfo.Retryable
+	}
+
+	var re retryableError
+	if errors.As(err, &re) {
+		return re.Retryable()
+	}
+
+	return !p.StopOnUnclassified
+}
+
+// withDefaults はゼロ値のフィールドにデフォルト値を設定したポリシーを返す
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 3
+	}
+	if p.Backoff == nil {
+		p.Backoff = ExponentialBackoff{
+			Initial: 100 * time.Millisecond,
+			Max:     10 * time.Second,
+			Jitter:  JitterFull,
+		}
+	}
+	if p.Clock == nil {
+		p.Clock = realClock{}
+	}
+	return p
+}
+
+// ExecuteWithRetryContext は指定した関数をポリシーに従ってリトライ付きで実行する
+// 待機中にctxがキャンセルされた場合は直ちに中断し、ctx.Err()と最後のエラーを返す
+// リトライする各失敗はLogRetryで試行回数と次の待機時間とともに記録される
+func (rh *RetryHandler) ExecuteWithRetryContext(ctx context.Context, operation string, fn func(ctx context.Context) error, policy RetryPolicy, fields map[string]interface{}) error {
+	policy = policy.withDefaults()
+
+	var lastErr error
+	var delay time.Duration
+
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(err, lastErr)
+		}
+
+		rh.logger.Info(operation,
+			String("action", "ATTEMPT"),
+			Int("attempt", attempt),
+			Int("max_attempts", policy.MaxAttempts),
+			Any("context", fields))
+
+		err := fn(ctx)
+		if err == nil {
+			rh.logger.Info(operation,
+				String("action", "SUCCESS"),
+				Int("attempt", attempt),
+				Any("context", fields))
+			return nil
+		}
+
+		lastErr = err
+
+		if !policy.IsRetryable(err) {
+			rh.errorHandler.HandleError(err, fields,
+				WithErrorCode("NON_RETRYABLE_ERROR"),
+				WithRetryable(false),
+				WithResolution("リトライ対象外のエラーです"))
+			return err
+		}
+
+		if attempt == policy.MaxAttempts {
+			break
+		}
+
+		delay = policy.Backoff.NextDelay(attempt, delay)
+		rh.logger.LogRetry(operation, attempt, err, delay)
+
+		select {
+		case <-policy.Clock.After(delay):
+		case <-ctx.Done():
+			return errors.Join(ctx.Err(), lastErr)
+		}
+	}
+
+	rh.errorHandler.HandleError(lastErr, fields,
+		WithErrorCode("MAX_RETRIES_EXCEEDED"),
+		WithRetryable(true),
+		WithResolution("手動での対応が必要です"))
+
+	return lastErr
+}
@@ -0,0 +1,181 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fixedRand は常に同じ値を返す乱数関数を作成する
+func fixedRand(v float64) func() float64 {
+	return func() float64 { return v }
+}
+
+func TestConstantBackoff(t *testing.T) {
+	b := ConstantBackoff{Delay: 50 * time.Millisecond}
+	for attempt := 1; attempt <= 5; attempt++ {
+		if got := b.NextDelay(attempt, 0); got != 50*time.Millisecond {
+			t.Errorf("attempt=%d: 待機時間 = %v, want 50ms", attempt, got)
+		}
+	}
+}
+
+func TestLinearBackoff(t *testing.T) {
+	b := LinearBackoff{Initial: 100 * time.Millisecond, Increment: 50 * time.Millisecond, Max: 220 * time.Millisecond}
+	want := []time.Duration{100 * time.Millisecond, 150 * time.Millisecond, 200 * time.Millisecond, 220 * time.Millisecond}
+	for i, w := range want {
+		if got := b.NextDelay(i+1, 0); got != w {
+			t.Errorf("attempt=%d: 待機時間 = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	t.Run("ジッターなし", func(t *testing.T) {
+		b := ExponentialBackoff{Initial: 100 * time.Millisecond, Max: time.Second}
+		want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+		for i, w := range want {
+			if got := b.NextDelay(i+1, 0); got != w {
+				t.Errorf("attempt=%d: 待機時間 = %v, want %v", i+1, got, w)
+			}
+		}
+	})
+
+	t.Run("倍率の指定", func(t *testing.T) {
+		b := ExponentialBackoff{Initial: 10 * time.Millisecond, Multiplier: 3}
+		if got := b.NextDelay(3, 0); got != 90*time.Millisecond {
+			t.Errorf("待機時間 = %v, want 90ms", got)
+		}
+	})
+
+	t.Run("上限なしで溢れない", func(t *testing.T) {
+		b := ExponentialBackoff{Initial: time.Second}
+		prev := time.Duration(0)
+		for attempt := 1; attempt <= 200; attempt++ {
+			got := b.NextDelay(attempt, prev)
+			if got <= 0 || got < prev {
+				t.Fatalf("attempt=%d: 待機時間 = %v（前回 %v）", attempt, got, prev)
+			}
+			prev = got
+		}
+		if prev != maxBackoffDelay {
+			t.Errorf("最終的な待機時間 = %v, want %v", prev, maxBackoffDelay)
+		}
+	})
+
+	t.Run("フルジッター", func(t *testing.T) {
+		b := ExponentialBackoff{Initial: 100 * time.Millisecond, Jitter: JitterFull, Rand: fixedRand(0.25)}
+		if got := b.NextDelay(2, 0); got != 50*time.Millisecond {
+			t.Errorf("待機時間 = %v, want 50ms", got)
+		}
+	})
+
+	t.Run("イコールジッター", func(t *testing.T) {
+		b := ExponentialBackoff{Initial: 100 * time.Millisecond, Jitter: JitterEqual, Rand: fixedRand(0.5)}
+		if got := b.NextDelay(2, 0); got != 150*time.Millisecond {
+			t.Errorf("待機時間 = %v, want 150ms", got)
+		}
+	})
+}
+
+func TestDecorrelatedJitterBackoff(t *testing.T) {
+	t.Run("範囲", func(t *testing.T) {
+		low := DecorrelatedJitterBackoff{Base: 100 * time.Millisecond, Rand: fixedRand(0)}
+		if got := low.NextDelay(2, 400*time.Millisecond); got != 100*time.Millisecond {
+			t.Errorf("乱数0の待機時間 = %v, want 100ms", got)
+		}
+		high := DecorrelatedJitterBackoff{Base: 100 * time.Millisecond, Rand: fixedRand(0.5)}
+		if got := high.NextDelay(2, 400*time.Millisecond); got != 650*time.Millisecond {
+			t.Errorf("乱数0.5の待機時間 = %v, want 650ms", got)
+		}
+	})
+
+	t.Run("上限", func(t *testing.T) {
+		b := DecorrelatedJitterBackoff{Base: 100 * time.Millisecond, Max: 300 * time.Millisecond, Rand: fixedRand(0.99)}
+		if got := b.NextDelay(5, time.Second); got != 300*time.Millisecond {
+			t.Errorf("待機時間 = %v, want 300ms", got)
+		}
+	})
+
+	t.Run("Baseが0でも待機する", func(t *testing.T) {
+		b := DecorrelatedJitterBackoff{Rand: fixedRand(0.5)}
+		prev := time.Duration(0)
+		for attempt := 1; attempt <= 3; attempt++ {
+			prev = b.NextDelay(attempt, prev)
+			if prev < defaultDecorrelatedBase {
+				t.Fatalf("attempt=%d: 待機時間 = %v, want >= %v", attempt, prev, defaultDecorrelatedBase)
+			}
+		}
+	})
+
+	t.Run("溢れない", func(t *testing.T) {
+		b := DecorrelatedJitterBackoff{Base: time.Second, Rand: fixedRand(0.99)}
+		if got := b.NextDelay(100, maxBackoffDelay/2); got <= 0 {
+			t.Errorf("待機時間 = %v, want > 0", got)
+		}
+	})
+}
+
+func TestExecuteWithRetryContextUsesBackoff(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	rh := NewRetryHandler(New(FATAL))
+
+	calls := 0
+	err := rh.ExecuteWithRetryContext(context.Background(), "op", func(context.Context) error {
+		calls++
+		if calls < 4 {
+			return errors.New("temporary")
+		}
+		return nil
+	}, RetryPolicy{
+		MaxAttempts: 5,
+		Backoff:     LinearBackoff{Initial: 10 * time.Millisecond, Increment: 10 * time.Millisecond},
+		Clock:       clock,
+	}, nil)
+
+	if err != nil {
+		t.Fatalf("エラー = %v, want nil", err)
+	}
+	if calls != 4 {
+		t.Errorf("呼び出し回数 = %d, want 4", calls)
+	}
+	want := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond}
+	if got := clock.Sleeps(); !reflect.DeepEqual(got, want) {
+		t.Errorf("待機時間 = %v, want %v", got, want)
+	}
+}
+
+func TestExecuteWithRetryContextStopsOnNonRetryable(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	rh := NewRetryHandler(New(FATAL))
+	permanent := errors.New("permanent")
+
+	calls := 0
+	err := rh.ExecuteWithRetryContext(context.Background(), "op", func(context.Context) error {
+		calls++
+		return permanent
+	}, RetryPolicy{MaxAttempts: 5, NonRetryableErrors: []error{permanent}, Clock: clock}, nil)
+
+	if !errors.Is(err, permanent) {
+		t.Errorf("エラー = %v, want %v", err, permanent)
+	}
+	if calls != 1 || len(clock.Sleeps()) != 0 {
+		t.Errorf("呼び出し回数 = %d, 待機 = %v, want 1回・待機なし", calls, clock.Sleeps())
+	}
+}
+
+func TestExecuteWithRetryZeroAttempts(t *testing.T) {
+	rh := NewRetryHandler(New(FATAL))
+
+	calls := 0
+	err := rh.ExecuteWithRetry("op", func() error {
+		calls++
+		return errors.New("fail")
+	}, 0, time.Millisecond, nil)
+
+	if err != nil || calls != 0 {
+		t.Errorf("エラー = %v, 呼び出し回数 = %d, want nil・0回", err, calls)
+	}
+}
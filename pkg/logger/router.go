@@ -0,0 +1,428 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Predicate はエントリがルートの条件に一致するかどうかを判定する
+type Predicate func(entry *Entry) bool
+
+// LevelAtLeast は指定したレベル以上のエントリに一致する
+func LevelAtLeast(level LogLevel) Predicate {
+	return func(entry *Entry) bool {
+		return entry.Level >= level
+	}
+}
+
+// LevelBelow は指定したレベル未満のエントリに一致する
+func LevelBelow(level LogLevel) Predicate {
+	return func(entry *Entry) bool {
+		return entry.Level < level
+	}
+}
+
+// HasTag は指定したタグを持つエントリに一致する
+func HasTag(tag string) Predicate {
+	return func(entry *Entry) bool {
+		for _, t := range entry.Tags {
+			if t == tag {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// OperationIs は操作名がいずれかに一致するエントリに一致する
+func OperationIs(operations ...string) Predicate {
+	return func(entry *Entry) bool {
+		for _, op := range operations {
+			if entry.Operation == op {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// OperationHasPrefix は操作名が指定した接頭辞で始まるエントリに一致する
+func OperationHasPrefix(prefix string) Predicate {
+	return func(entry *Entry) bool {
+		return strings.HasPrefix(entry.Operation, prefix)
+	}
+}
+
+// ActionIs はアクションがいずれかに一致するエントリに一致する
+func ActionIs(actions ...ActionType) Predicate {
+	return func(entry *Entry) bool {
+		for _, action := range actions {
+			if entry.Action == action {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// HasField はContextに指定したキーを持つエントリに一致する
+func HasField(key string) Predicate {
+	return func(entry *Entry) bool {
+		_, ok := entry.Context[key]
+		return ok
+	}
+}
+
+// FieldEquals はContextの指定したキーの値が一致するエントリに一致する
+func FieldEquals(key string, value interface{}) Predicate {
+	return func(entry *Entry) bool {
+		v, ok := entry.Context[key]
+		if !ok {
+			return false
+		}
+		return reflect.DeepEqual(v, value)
+	}
+}
+
+// HasError はエラー情報を持つエントリに一致する
+func HasError() Predicate {
+	return func(entry *Entry) bool {
+		return entry.Error != nil
+	}
+}
+
+// And はすべての条件に一致するエントリに一致する
+func And(predicates ...Predicate) Predicate {
+	return func(entry *Entry) bool {
+		for _, p := range predicates {
+			if !p(entry) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or はいずれかの条件に一致するエントリに一致する
+func Or(predicates ...Predicate) Predicate {
+	return func(entry *Entry) bool {
+		for _, p := range predicates {
+			if p(entry) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not は条件に一致しないエントリに一致する
+func Not(predicate Predicate) Predicate {
+	return func(entry *Entry) bool {
+		return !predicate(entry)
+	}
+}
+
+// Route は条件に一致したエントリだけを書き込むWriter
+// RouteToで作成し、メソッドチェーンで条件を追加する
+//
+//	logger.RouteTo(vibeWriter).Named("vibe").Tagged("vibe")
+//	logger.RouteTo(errorFile).MinLevel(logger.ERROR)
+//	logger.RouteTo(console).When(logger.Not(logger.HasTag("audit")))
+//
+// 複数の条件はすべてを満たす場合に一致し、条件がない場合はすべてのエントリに一致する
+type Route struct {
+	name       string
+	writer     Writer
+	predicates []Predicate
+	queue      *AsyncWriterOptions // RoutingWriterで使う非同期キューの設定（nilの場合は同期的に書き込む）
+}
+
+// RouteTo はwriterへのルートを作成する
+func RouteTo(writer Writer) *Route {
+	return &Route{writer: writer}
+}
+
+// Named はルートの名前を設定する（エラーメッセージに使われる）
+func (r *Route) Named(name string) *Route {
+	r.name = name
+	return r
+}
+
+// When は条件を追加する
+func (r *Route) When(predicates ...Predicate) *Route {
+	r.predicates = append(r.predicates, predicates...)
+	return r
+}
+
+// MinLevel は指定したレベル以上のエントリに限定する
+func (r *Route) MinLevel(level LogLevel) *Route {
+	return r.When(LevelAtLeast(level))
+}
+
+// Tagged は指定したタグを持つエントリに限定する
+func (r *Route) Tagged(tag string) *Route {
+	return r.When(HasTag(tag))
+}
+
+// Operation は操作名がいずれかに一致するエントリに限定する
+func (r *Route) Operation(operations ...string) *Route {
+	return r.When(OperationIs(operations...))
+}
+
+// Field はContextの指定したキーの値が一致するエントリに限定する
+func (r *Route) Field(key string, value interface{}) *Route {
+	return r.When(FieldEquals(key, value))
+}
+
+// Queue はRoutingWriterがこのルートのWriterへ非同期キュー（AsyncWriter）を通して書き込むようにする
+// 設定しない場合は同期的に書き込む。ゼロ値の設定ではキューが満杯の場合に呼び出し元をブロックする（OverflowBlock）
+func (r *Route) Queue(opts AsyncWriterOptions) *Route {
+	r.queue = &opts
+	return r
+}
+
+// Matches はエントリがルートの条件に一致するかどうかを判定する
+func (r *Route) Matches(entry *Entry) bool {
+	for _, p := range r.predicates {
+		if !p(entry) {
+			return false
+		}
+	}
+	return true
+}
+
+// Write は条件に一致したエントリのみを書き込む
+func (r *Route) Write(entry *Entry) error {
+	if !r.Matches(entry) {
+		return nil
+	}
+	return r.writer.Write(entry)
+}
+
+// Close はルートのWriterを閉じる
+func (r *Route) Close() error {
+	return r.writer.Close()
+}
+
+// SetFormatter はルートのWriterにフォーマッターを設定する
+func (r *Route) SetFormatter(formatter Formatter) {
+	applyFormatter(r.writer, formatter)
+}
+
+// Flush はルートのWriterがFlushableWriterであればバッファを出力する
+func (r *Route) Flush() error {
+	if fw, ok := r.writer.(FlushableWriter); ok {
+		return fw.Flush()
+	}
+	return nil
+}
+
+// Reopen はルートのWriterがReopenableWriterであればファイルを開き直す
+func (r *Route) Reopen() error {
+	return reopenWriter(r.writer)
+}
+
+// routeBranch はRoutingWriterに追加されたルートと、その書き込み先
+type routeBranch struct {
+	route  *Route
+	target Writer // ルートのWriter、またはQueueを設定した場合はそれをラップしたAsyncWriter
+}
+
+// RoutingWriter はエントリを条件に一致するすべてのルートに振り分けるWriter
+// 各ルートへの書き込みは同期的に行い、あるルートのエラーやpanicは他のルートへの書き込みを妨げず、まとめて返される
+// 遅いWriterが他のルートを待たせないようにするには、そのルートにQueueを設定して非同期キューを通す
+// 同じWriterを複数のルートで使う場合、1件のエントリはそのWriterに1回だけ書き込まれ、Closeも1回だけ行われる
+type RoutingWriter struct {
+	mu       sync.RWMutex
+	branches []routeBranch
+	fallback Writer
+	targets  []Writer // 重複のない書き込み先の一覧（Flush・Closeで使う）
+}
+
+// NewRoutingWriter は新しいルーティングライターを作成する
+func NewRoutingWriter(routes ...*Route) *RoutingWriter {
+	rw := &RoutingWriter{}
+	for _, route := range routes {
+		rw.AddRoute(route)
+	}
+	return rw
+}
+
+// AddRoute はルートを追加する
+func (rw *RoutingWriter) AddRoute(route *Route) *RoutingWriter {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	name := routeName(route, len(rw.branches))
+	rw.branches = append(rw.branches, routeBranch{
+		route:  route,
+		target: rw.targetFor(route.writer, route.queue, "route "+name),
+	})
+	return rw
+}
+
+// SetFallback はどのルートにも一致しなかったエントリの書き込み先を設定する
+func (rw *RoutingWriter) SetFallback(writer Writer) *RoutingWriter {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	rw.fallback = rw.targetFor(writer, nil, "route fallback")
+	return rw
+}
+
+// targetFor はWriterの書き込み先を返す（rw.muを保持した状態で呼び出すこと）
+// 同じWriterの書き込み先が既にあれば共有し、queueが設定されていれば非同期キューでラップする
+func (rw *RoutingWriter) targetFor(writer Writer, queue *AsyncWriterOptions, name string) Writer {
+	for _, target := range rw.targets {
+		if sameWriter(target, writer) {
+			return target
+		}
+		if q, ok := target.(*AsyncWriter); ok && sameWriter(q.writer, writer) {
+			return target
+		}
+	}
+
+	target := writer
+	if queue != nil {
+		opts := *queue
+		if opts.ErrorHandler == nil {
+			opts.ErrorHandler = func(err error) {
+				fmt.Printf("RoutingWriter write error: %s: %v\n", name, err)
+			}
+		}
+		target = NewAsyncWriter(writer, opts)
+	}
+	rw.targets = append(rw.targets, target)
+	return target
+}
+
+// sameWriter は2つのWriterが同じものかどうかを判定する（比較できない型の場合は異なるものとする）
+func sameWriter(a, b Writer) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	return ta == tb && ta.Comparable() && a == b
+}
+
+// Write は条件に一致するすべてのルートにエントリを書き込む
+// 同じWriterを使う複数のルートに一致した場合、そのWriterには1回だけ書き込む
+func (rw *RoutingWriter) Write(entry *Entry) error {
+	rw.mu.RLock()
+	defer rw.mu.RUnlock()
+
+	var errs []error
+	var written []Writer
+	matched := false
+	for i, branch := range rw.branches {
+		if !branch.route.Matches(entry) {
+			continue
+		}
+		matched = true
+		if slices.ContainsFunc(written, func(w Writer) bool { return sameWriter(w, branch.target) }) {
+			continue
+		}
+		written = append(written, branch.target)
+		if err := safeWrite(branch.target, entry); err != nil {
+			errs = append(errs, fmt.Errorf("route %s: %w", routeName(branch.route, i), err))
+		}
+	}
+
+	if !matched && rw.fallback != nil {
+		if err := safeWrite(rw.fallback, entry); err != nil {
+			errs = append(errs, fmt.Errorf("route fallback: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Dropped はQueueを設定したルートのキューが溢れて破棄したエントリ数の合計を取得する
+func (rw *RoutingWriter) Dropped() uint64 {
+	rw.mu.RLock()
+	defer rw.mu.RUnlock()
+
+	var dropped uint64
+	for _, target := range rw.targets {
+		if q, ok := target.(*AsyncWriter); ok {
+			dropped += q.Dropped()
+		}
+	}
+	return dropped
+}
+
+// SetFormatter はすべてのルートのWriterにフォーマッターを設定する
+func (rw *RoutingWriter) SetFormatter(formatter Formatter) {
+	rw.mu.RLock()
+	defer rw.mu.RUnlock()
+
+	for _, target := range rw.targets {
+		applyFormatter(target, formatter)
+	}
+}
+
+// Flush はすべてのルートのキューに追加済みのエントリを書き込み、バッファを出力する
+func (rw *RoutingWriter) Flush() error {
+	rw.mu.RLock()
+	defer rw.mu.RUnlock()
+
+	var errs []error
+	for _, target := range rw.targets {
+		if fw, ok := target.(FlushableWriter); ok {
+			if err := fw.Flush(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Reopen はすべてのルートのWriterのファイルを開き直す
+func (rw *RoutingWriter) Reopen() error {
+	rw.mu.RLock()
+	defer rw.mu.RUnlock()
+
+	var errs []error
+	for _, target := range rw.targets {
+		if err := reopenWriter(target); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close はすべてのルートのWriterを閉じる（キューを設定したルートはキューを処理してから閉じる）
+// 複数のルートで共有しているWriterは1回だけ閉じる
+func (rw *RoutingWriter) Close() error {
+	rw.mu.RLock()
+	defer rw.mu.RUnlock()
+
+	var errs []error
+	for _, target := range rw.targets {
+		if err := target.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// routeName はエラーメッセージに使うルート名を返す
+func routeName(route *Route, index int) string {
+	if route.name != "" {
+		return route.name
+	}
+	return fmt.Sprintf("#%d", index)
+}
+
+// safeWrite はWriterのpanicをエラーに変換して書き込む
+func safeWrite(writer Writer, entry *Entry) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("書き込み中にpanicが発生しました: %v", r)
+		}
+	}()
+	return writer.Write(entry)
+}
@@ -0,0 +1,218 @@
+package logger
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// panicWriter は書き込みのたびにpanicするテスト用のWriter
+type panicWriter struct{}
+
+func (panicWriter) Write(*Entry) error { panic("broken writer") }
+func (panicWriter) Close() error       { return nil }
+
+func TestPredicates(t *testing.T) {
+	entry := &Entry{
+		Level:     WARN,
+		Action:    ActionError,
+		Operation: "db.query",
+		Tags:      []string{"db", "slow"},
+		Context:   map[string]interface{}{"table": "users", "rows": 3},
+		Error:     &ErrorInfo{Message: "timeout"},
+	}
+
+	tests := []struct {
+		name      string
+		predicate Predicate
+		want      bool
+	}{
+		{"LevelAtLeast一致", LevelAtLeast(WARN), true},
+		{"LevelAtLeast不一致", LevelAtLeast(ERROR), false},
+		{"LevelBelow一致", LevelBelow(ERROR), true},
+		{"LevelBelow不一致", LevelBelow(WARN), false},
+		{"HasTag一致", HasTag("slow"), true},
+		{"HasTag不一致", HasTag("audit"), false},
+		{"OperationIs一致", OperationIs("db.exec", "db.query"), true},
+		{"OperationIs不一致", OperationIs("db.exec"), false},
+		{"OperationHasPrefix一致", OperationHasPrefix("db."), true},
+		{"OperationHasPrefix不一致", OperationHasPrefix("http."), false},
+		{"ActionIs一致", ActionIs(ActionRetry, ActionError), true},
+		{"ActionIs不一致", ActionIs(ActionStart), false},
+		{"HasField一致", HasField("table"), true},
+		{"HasField不一致", HasField("user"), false},
+		{"FieldEquals一致", FieldEquals("rows", 3), true},
+		{"FieldEquals型違い", FieldEquals("rows", "3"), false},
+		{"HasError一致", HasError(), true},
+		{"And", And(LevelAtLeast(WARN), HasTag("db")), true},
+		{"And不一致", And(LevelAtLeast(WARN), HasTag("audit")), false},
+		{"Or", Or(HasTag("audit"), HasTag("db")), true},
+		{"Or不一致", Or(HasTag("audit"), LevelAtLeast(FATAL)), false},
+		{"Not", Not(HasTag("audit")), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.predicate(entry); got != tt.want {
+				t.Errorf("一致 = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if HasError()(&Entry{}) {
+		t.Error("エラーのないエントリにHasErrorが一致しました")
+	}
+}
+
+func TestRouteWritesMatchingEntries(t *testing.T) {
+	mem := &memoryWriter{}
+	route := RouteTo(mem).MinLevel(WARN).Tagged("db")
+
+	entries := []*Entry{
+		{Level: ERROR, Operation: "match", Tags: []string{"db"}},
+		{Level: INFO, Operation: "low", Tags: []string{"db"}},
+		{Level: ERROR, Operation: "untagged"},
+	}
+	for _, e := range entries {
+		if err := route.Write(e); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if got := mem.operations(); !equalStrings(got, []string{"match"}) {
+		t.Errorf("書き込まれた操作 = %v, want [match]", got)
+	}
+}
+
+func TestRoutingWriterRoutesAndFallsBack(t *testing.T) {
+	errorsOut, audit, fallback := &memoryWriter{}, &memoryWriter{}, &memoryWriter{}
+	rw := NewRoutingWriter(
+		RouteTo(errorsOut).MinLevel(ERROR),
+		RouteTo(audit).Tagged("audit"),
+	).SetFallback(fallback)
+
+	writes := []*Entry{
+		{Level: ERROR, Operation: "error"},
+		{Level: INFO, Operation: "audit", Tags: []string{"audit"}},
+		{Level: ERROR, Operation: "both", Tags: []string{"audit"}},
+		{Level: INFO, Operation: "other"},
+	}
+	for _, e := range writes {
+		if err := rw.Write(e); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	if got := errorsOut.operations(); !equalStrings(got, []string{"error", "both"}) {
+		t.Errorf("ERRORルート = %v", got)
+	}
+	if got := audit.operations(); !equalStrings(got, []string{"audit", "both"}) {
+		t.Errorf("auditルート = %v", got)
+	}
+	if got := fallback.operations(); !equalStrings(got, []string{"other"}) {
+		t.Errorf("フォールバック = %v", got)
+	}
+}
+
+func TestRoutingWriterIsolatesBranches(t *testing.T) {
+	healthy := &memoryWriter{}
+	failing := &memoryWriter{err: errors.New("disk full")}
+	rw := NewRoutingWriter(
+		RouteTo(panicWriter{}).Named("panic"),
+		RouteTo(failing).Named("failing"),
+		RouteTo(healthy).Named("healthy"),
+	)
+
+	err := rw.Write(testEntry("op"))
+	if err == nil {
+		t.Fatal("エラーが返されませんでした")
+	}
+	for _, want := range []string{"route panic", "route failing", "disk full"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("エラー %q に %q が含まれていません", err, want)
+		}
+	}
+	if got := healthy.operations(); !equalStrings(got, []string{"op"}) {
+		t.Errorf("他のルートへの書き込み = %v, want [op]", got)
+	}
+}
+
+func TestRoutingWriterWritesSynchronouslyByDefault(t *testing.T) {
+	mem := &memoryWriter{}
+	rw := NewRoutingWriter(RouteTo(mem))
+
+	mustWrite(t, rw, "1", "2")
+	// キューを設定していないため、Writeから戻った時点で書き込まれている
+	if got := mem.operations(); !equalStrings(got, []string{"1", "2"}) {
+		t.Errorf("書き込まれた操作 = %v", got)
+	}
+	if rw.Dropped() != 0 {
+		t.Errorf("破棄された件数 = %d, want 0", rw.Dropped())
+	}
+}
+
+func TestRoutingWriterQueuedRouteDoesNotBlockOthers(t *testing.T) {
+	slow := newBlockingWriter()
+	fast := &memoryWriter{}
+	rw := NewRoutingWriter(
+		RouteTo(slow).Queue(AsyncWriterOptions{QueueSize: 10}),
+		RouteTo(fast),
+	)
+
+	done := make(chan error, 1)
+	go func() {
+		for _, op := range []string{"1", "2", "3"} {
+			if err := rw.Write(testEntry(op)); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("キューを通したルートの書き込みが他のルートを待たせました")
+	}
+	if got := fast.operations(); !equalStrings(got, []string{"1", "2", "3"}) {
+		t.Errorf("他のルートへの書き込み = %v", got)
+	}
+
+	close(slow.release)
+	if err := rw.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got := slow.operations(); !equalStrings(got, []string{"1", "2", "3"}) {
+		t.Errorf("キューを通したルートへの書き込み = %v", got)
+	}
+	if err := rw.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if slow.closeCount() != 1 {
+		t.Errorf("Closeの回数 = %d, want 1", slow.closeCount())
+	}
+}
+
+func TestRoutingWriterSharedWriter(t *testing.T) {
+	shared := &memoryWriter{}
+	rw := NewRoutingWriter(
+		RouteTo(shared).MinLevel(ERROR),
+		RouteTo(shared).Tagged("audit"),
+	).SetFallback(shared)
+
+	if err := rw.Write(&Entry{Level: ERROR, Operation: "both", Tags: []string{"audit"}}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got := shared.operations(); !equalStrings(got, []string{"both"}) {
+		t.Errorf("書き込まれた操作 = %v, want 1回だけ書き込む", got)
+	}
+
+	if err := rw.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if shared.closeCount() != 1 {
+		t.Errorf("Closeの回数 = %d, want 1", shared.closeCount())
+	}
+}
@@ -0,0 +1,361 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SamplingMode はサンプリングの方式を表す
+type SamplingMode int
+
+const (
+	// SampleFirstN はウィンドウごとに最初のFirst件を残し、以降はThereafter件に1件だけを残す
+	SampleFirstN SamplingMode = iota
+	// SampleTokenBucket はトークンバケットで1秒あたりRate件（バーストBurst件）まで残す
+	SampleTokenBucket
+	// SampleProbabilistic はProbabilityの確率でエントリを残す
+	SampleProbabilistic
+)
+
+// String はSamplingModeを文字列に変換する
+func (m SamplingMode) String() string {
+	switch m {
+	case SampleFirstN:
+		return "FIRST_N"
+	case SampleTokenBucket:
+		return "TOKEN_BUCKET"
+	case SampleProbabilistic:
+		return "PROBABILISTIC"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// SamplingOptions はSamplingWriterの設定です
+type SamplingOptions struct {
+	Mode SamplingMode // サンプリングの方式
+	// Tick は集計ウィンドウの長さ（0の場合は1秒）
+	// ウィンドウが閉じると、そのウィンドウで破棄した件数を要約エントリとして出力する
+	Tick time.Duration
+	// Levels はサンプリングの対象とするレベル（nilの場合はDEBUGとINFO、その他のレベルは常に出力）
+	Levels []LogLevel
+
+	First      int // SampleFirstN: ウィンドウごとに必ず残す件数（0の場合は100）
+	Thereafter int // SampleFirstN: First件以降に何件に1件を残すか（0の場合は以降すべて破棄）
+
+	Rate  float64 // SampleTokenBucket: 1秒あたりに補充されるトークン数（0の場合は10）
+	Burst int     // SampleTokenBucket: バケットの容量（0の場合はRateの切り上げ）
+
+	Probability float64        // SampleProbabilistic: エントリを残す確率（0の場合は0.1）
+	Rand        func() float64 // SampleProbabilistic: [0.0, 1.0)の乱数を返す関数（nilの場合はmath/rand/v2）
+
+	Clock        Clock       // 時刻の取得に使うClock（nilの場合は実時間）
+	ErrorHandler func(error) // バックグラウンドでの要約エントリの書き込みエラーの通知先（nilの場合は標準出力）
+}
+
+// SamplingStats はSamplingWriterの統計情報です
+type SamplingStats struct {
+	Sampled    uint64 // 書き込んだエントリ数
+	Suppressed uint64 // サンプリングにより破棄したエントリ数
+	Summaries  uint64 // 出力した要約エントリ数
+}
+
+// samplingKey はサンプリングの集計単位（レベルと操作名の組）
+type samplingKey struct {
+	level     LogLevel
+	operation string
+}
+
+// samplingCounter は集計単位ごとの状態
+// ウィンドウの切り替えと件数の集計はアトミック操作のみで行い、トークンバケットのみ集計単位ごとのロックを使う
+type samplingCounter struct {
+	resetAt    atomic.Int64 // 現在のウィンドウが閉じる時刻（UnixNano）
+	count      atomic.Uint64
+	suppressed atomic.Uint64
+
+	bucketMu   sync.Mutex
+	tokens     float64
+	lastRefill time.Time
+}
+
+// SamplingWriter は任意のWriterをラップし、レベルと操作名ごとにエントリをサンプリングする
+// 集計単位はレベルと操作名の組であり、操作名の種類が非常に多い場合はその数だけ状態を保持する
+//
+// 破棄した件数は "suppressed N entries for op X" の要約エントリ（ActionSkip）として書き込む
+// 要約はウィンドウが閉じた後に同じ集計単位の次のエントリが来たとき、バックグラウンドの定期確認、
+// またはFlush・Closeの呼び出し時に書き込まれるため、エントリが途絶えた集計単位の件数も失われない
+type SamplingWriter struct {
+	writer   Writer
+	opts     SamplingOptions
+	levels   [FATAL + 1]bool
+	counters sync.Map // samplingKey -> *samplingCounter
+
+	sampled    atomic.Uint64
+	suppressed atomic.Uint64
+	summaries  atomic.Uint64
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewSamplingWriter は新しいサンプリングライターを作成し、閉じたウィンドウの要約を定期的に書き込むゴルーチンを開始する
+func NewSamplingWriter(writer Writer, opts SamplingOptions) *SamplingWriter {
+	if opts.Tick <= 0 {
+		opts.Tick = time.Second
+	}
+	if opts.Levels == nil {
+		opts.Levels = []LogLevel{DEBUG, INFO}
+	}
+	if opts.First <= 0 {
+		opts.First = 100
+	}
+	if opts.Rate <= 0 {
+		opts.Rate = 10
+	}
+	if opts.Burst <= 0 {
+		opts.Burst = int(opts.Rate)
+		if float64(opts.Burst) < opts.Rate {
+			opts.Burst++
+		}
+	}
+	if opts.Probability <= 0 {
+		opts.Probability = 0.1
+	}
+	if opts.Clock == nil {
+		opts.Clock = realClock{}
+	}
+
+	sw := &SamplingWriter{
+		writer: writer,
+		opts:   opts,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	for _, level := range opts.Levels {
+		if level >= DEBUG && level <= FATAL {
+			sw.levels[level] = true
+		}
+	}
+
+	go sw.run()
+	return sw
+}
+
+// Write はサンプリングの判定を行い、残すエントリのみを書き込む
+func (w *SamplingWriter) Write(entry *Entry) error {
+	if entry.Level < DEBUG || entry.Level > FATAL || !w.levels[entry.Level] {
+		w.sampled.Add(1)
+		return w.writer.Write(entry)
+	}
+
+	key := samplingKey{level: entry.Level, operation: entry.Operation}
+	counter := w.counter(key)
+	now := w.opts.Clock.Now()
+
+	// ウィンドウが閉じていれば切り替え、破棄した件数を要約として出力する
+	var summaryErr error
+	if suppressed, rolled := counter.roll(now.UnixNano(), int64(w.opts.Tick)); rolled && suppressed > 0 {
+		summaryErr = w.writeSummary(key, suppressed, now)
+	}
+
+	if !w.keep(counter, now) {
+		counter.suppressed.Add(1)
+		w.suppressed.Add(1)
+		return summaryErr
+	}
+
+	w.sampled.Add(1)
+	return errors.Join(summaryErr, w.writer.Write(entry))
+}
+
+// counter は集計単位の状態を取得する（存在しない場合は作成する）
+func (w *SamplingWriter) counter(key samplingKey) *samplingCounter {
+	if c, ok := w.counters.Load(key); ok {
+		return c.(*samplingCounter)
+	}
+	c, _ := w.counters.LoadOrStore(key, &samplingCounter{})
+	return c.(*samplingCounter)
+}
+
+// keep はエントリを残すかどうかを判定する
+func (w *SamplingWriter) keep(counter *samplingCounter, now time.Time) bool {
+	switch w.opts.Mode {
+	case SampleTokenBucket:
+		return counter.take(now, w.opts.Rate, float64(w.opts.Burst))
+	case SampleProbabilistic:
+		return randFloat(w.opts.Rand) < w.opts.Probability
+	default:
+		n := counter.count.Add(1)
+		first := uint64(w.opts.First)
+		if n <= first {
+			return true
+		}
+		return w.opts.Thereafter > 0 && (n-first)%uint64(w.opts.Thereafter) == 0
+	}
+}
+
+// roll はウィンドウが閉じていれば新しいウィンドウを開始し、閉じたウィンドウで破棄した件数を返す
+// 複数のゴルーチンが同時に呼び出した場合も、切り替えを行うのは1つだけとなる
+//
+// 件数は0で上書きせず、切り替え前に読み取った件数だけをアトミックに差し引くため、
+// 切り替え中に数えられた新しいウィンドウの件数は失われない
+func (c *samplingCounter) roll(now, tick int64) (uint64, bool) {
+	resetAt := c.resetAt.Load()
+	if now < resetAt {
+		return 0, false
+	}
+	count := c.count.Load()
+	if !c.resetAt.CompareAndSwap(resetAt, now+tick) {
+		return 0, false
+	}
+	c.count.Add(^(count - 1))
+	return c.suppressed.Swap(0), true
+}
+
+// take はトークンバケットからトークンを1つ取り出す
+func (c *samplingCounter) take(now time.Time, rate, burst float64) bool {
+	c.bucketMu.Lock()
+	defer c.bucketMu.Unlock()
+
+	if c.lastRefill.IsZero() {
+		c.tokens = burst
+	} else if elapsed := now.Sub(c.lastRefill).Seconds(); elapsed > 0 {
+		c.tokens += elapsed * rate
+		if c.tokens > burst {
+			c.tokens = burst
+		}
+	}
+	c.lastRefill = now
+
+	if c.tokens < 1 {
+		return false
+	}
+	c.tokens--
+	return true
+}
+
+// writeSummary は破棄した件数の要約エントリを書き込む
+func (w *SamplingWriter) writeSummary(key samplingKey, suppressed uint64, now time.Time) error {
+	w.summaries.Add(1)
+	return w.writer.Write(&Entry{
+		ID:        uuid.New().String(),
+		Timestamp: now,
+		Level:     key.level,
+		Action:    ActionSkip,
+		Operation: key.operation,
+		Context: map[string]interface{}{
+			"summary":       fmt.Sprintf("suppressed %d entries for op %s", suppressed, key.operation),
+			"suppressed":    suppressed,
+			"sampling_mode": w.opts.Mode.String(),
+			"window":        w.opts.Tick.String(),
+		},
+		Metadata: map[string]interface{}{
+			"sampling_summary": true,
+		},
+	})
+}
+
+// flushSummaries はすべての集計単位で破棄した件数を要約エントリとして書き込む
+func (w *SamplingWriter) flushSummaries() error {
+	now := w.opts.Clock.Now()
+	var errs []error
+	w.counters.Range(func(k, v interface{}) bool {
+		if suppressed := v.(*samplingCounter).suppressed.Swap(0); suppressed > 0 {
+			if err := w.writeSummary(k.(samplingKey), suppressed, now); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return true
+	})
+	return errors.Join(errs...)
+}
+
+// rollSummaries はウィンドウが閉じた集計単位を切り替え、破棄した件数を要約エントリとして書き込む
+func (w *SamplingWriter) rollSummaries() error {
+	now := w.opts.Clock.Now()
+	var errs []error
+	w.counters.Range(func(k, v interface{}) bool {
+		if suppressed, rolled := v.(*samplingCounter).roll(now.UnixNano(), int64(w.opts.Tick)); rolled && suppressed > 0 {
+			if err := w.writeSummary(k.(samplingKey), suppressed, now); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return true
+	})
+	return errors.Join(errs...)
+}
+
+// run は閉じたウィンドウを定期的に確認し、要約エントリを書き込む
+func (w *SamplingWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.opts.Tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.rollSummaries(); err != nil {
+				w.handleError(err)
+			}
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// handleError はバックグラウンドでの書き込みエラーを通知する
+func (w *SamplingWriter) handleError(err error) {
+	if w.opts.ErrorHandler != nil {
+		w.opts.ErrorHandler(err)
+		return
+	}
+	fmt.Printf("SamplingWriter write error: %v\n", err)
+}
+
+// Flush は未出力の要約エントリを書き込み、ラップしたWriterがFlushableWriterであればその内容も出力する
+func (w *SamplingWriter) Flush() error {
+	err := w.flushSummaries()
+	if f, ok := w.writer.(FlushableWriter); ok {
+		err = errors.Join(err, f.Flush())
+	}
+	return err
+}
+
+// Reopen はラップしたWriterがReopenableWriterであればファイルを開き直す
+func (w *SamplingWriter) Reopen() error {
+	return reopenWriter(w.writer)
+}
+
+// SetFormatter はラップしたWriterがFormattableWriterであればフォーマッターを設定する
+func (w *SamplingWriter) SetFormatter(formatter Formatter) {
+	applyFormatter(w.writer, formatter)
+}
+
+// Stats は統計情報を取得する
+func (w *SamplingWriter) Stats() SamplingStats {
+	return SamplingStats{
+		Sampled:    w.sampled.Load(),
+		Suppressed: w.suppressed.Load(),
+		Summaries:  w.summaries.Load(),
+	}
+}
+
+// Close はバックグラウンドのゴルーチンを停止し、未出力の要約エントリを書き込んでからラップしたWriterを閉じる
+func (w *SamplingWriter) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.stop)
+		<-w.done
+
+		err := w.flushSummaries()
+		w.closeErr = errors.Join(err, w.writer.Close())
+	})
+	return w.closeErr
+}
@@ -0,0 +1,297 @@
+package logger
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestSampler はFakeClockを使うSamplingWriterを作成する
+// バックグラウンドの定期確認が実時間で動かないよう、Tickは十分に長くしてrollSummariesを直接呼び出す
+func newTestSampler(t *testing.T, opts SamplingOptions) (*SamplingWriter, *memoryWriter, *FakeClock) {
+	t.Helper()
+	mem := &memoryWriter{}
+	clock := NewFakeClock(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	opts.Clock = clock
+	if opts.Tick == 0 {
+		opts.Tick = time.Hour
+	}
+	sw := NewSamplingWriter(mem, opts)
+	t.Cleanup(func() { _ = sw.Close() })
+	return sw, mem, clock
+}
+
+// summaries は書き込まれた要約エントリの破棄件数を操作名ごとに返す
+func summaries(mem *memoryWriter) map[string]uint64 {
+	got := make(map[string]uint64)
+	for _, e := range mem.written() {
+		if e.Action == ActionSkip {
+			got[e.Operation] += e.Context["suppressed"].(uint64)
+		}
+	}
+	return got
+}
+
+// sampledOperations は要約エントリを除いて書き込まれた操作名を返す
+func sampledOperations(mem *memoryWriter) []string {
+	var ops []string
+	for _, e := range mem.written() {
+		if e.Action != ActionSkip {
+			ops = append(ops, e.Operation)
+		}
+	}
+	return ops
+}
+
+// writeSeq は操作名opのINFOエントリをn件書き込み、Contextに連番を設定する
+func writeSeq(t *testing.T, w Writer, op string, n int) {
+	t.Helper()
+	for i := 1; i <= n; i++ {
+		e := testEntry(op)
+		e.Context = map[string]interface{}{"seq": i}
+		if err := w.Write(e); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+}
+
+func TestSamplingWriterFirstN(t *testing.T) {
+	sw, mem, _ := newTestSampler(t, SamplingOptions{First: 2, Thereafter: 3})
+
+	writeSeq(t, sw, "op", 10)
+
+	var seqs []int
+	for _, e := range mem.written() {
+		seqs = append(seqs, e.Context["seq"].(int))
+	}
+	// 最初の2件と、以降3件に1件（5件目と8件目）を残す
+	if want := []int{1, 2, 5, 8}; !equalInts(seqs, want) {
+		t.Errorf("残したエントリ = %v, want %v", seqs, want)
+	}
+	if got := sw.Stats(); got.Sampled != 4 || got.Suppressed != 6 {
+		t.Errorf("統計 = %+v, want Sampled 4, Suppressed 6", got)
+	}
+}
+
+func TestSamplingWriterRollsWindow(t *testing.T) {
+	sw, mem, clock := newTestSampler(t, SamplingOptions{First: 2, Tick: time.Minute})
+
+	writeSeq(t, sw, "op", 5)
+	// ウィンドウが閉じるまでは要約を出力しない
+	clock.Advance(59 * time.Second)
+	if err := sw.rollSummaries(); err != nil {
+		t.Fatalf("rollSummaries: %v", err)
+	}
+	if got := summaries(mem); len(got) != 0 {
+		t.Fatalf("ウィンドウ内で要約が出力されました: %v", got)
+	}
+
+	// ウィンドウが閉じた後の次のエントリで要約を出力し、件数を数え直す
+	clock.Advance(time.Second)
+	writeSeq(t, sw, "op", 3)
+	if got := summaries(mem); got["op"] != 3 {
+		t.Errorf("要約の件数 = %v, want op: 3", got)
+	}
+	if got := sampledOperations(mem); len(got) != 4 {
+		t.Errorf("残した件数 = %d, want 4（各ウィンドウで2件）", len(got))
+	}
+}
+
+func TestSamplingWriterRollSummaries(t *testing.T) {
+	sw, mem, clock := newTestSampler(t, SamplingOptions{First: 1, Tick: time.Minute})
+
+	writeSeq(t, sw, "a", 4)
+	writeSeq(t, sw, "b", 2)
+	writeSeq(t, sw, "c", 1)
+
+	// エントリが途絶えた集計単位も、定期確認で要約が出力される
+	clock.Advance(time.Minute)
+	if err := sw.rollSummaries(); err != nil {
+		t.Fatalf("rollSummaries: %v", err)
+	}
+	got := summaries(mem)
+	if len(got) != 2 || got["a"] != 3 || got["b"] != 1 {
+		t.Errorf("要約の件数 = %v, want a: 3, b: 1", got)
+	}
+	for _, e := range mem.written() {
+		if e.Action == ActionSkip && (e.Metadata["sampling_summary"] != true || !e.Timestamp.Equal(clock.Now())) {
+			t.Errorf("要約エントリ = %+v", e)
+		}
+	}
+
+	// 出力済みの件数は再度出力しない
+	clock.Advance(time.Minute)
+	if err := sw.rollSummaries(); err != nil {
+		t.Fatalf("rollSummaries: %v", err)
+	}
+	if s := sw.Stats().Summaries; s != 2 {
+		t.Errorf("要約の数 = %d, want 2", s)
+	}
+}
+
+func TestSamplingWriterFlushAndCloseWriteSummaries(t *testing.T) {
+	sw, mem, _ := newTestSampler(t, SamplingOptions{First: 1})
+
+	writeSeq(t, sw, "op", 3)
+	if err := sw.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got := summaries(mem); got["op"] != 2 {
+		t.Errorf("Flush後の要約 = %v, want op: 2", got)
+	}
+
+	writeSeq(t, sw, "op", 2)
+	if err := sw.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if got := summaries(mem); got["op"] != 4 {
+		t.Errorf("Close後の要約の合計 = %v, want op: 4", got)
+	}
+	if mem.closeCount() != 1 {
+		t.Errorf("Closeの回数 = %d, want 1", mem.closeCount())
+	}
+}
+
+func TestSamplingWriterTokenBucket(t *testing.T) {
+	sw, mem, clock := newTestSampler(t, SamplingOptions{Mode: SampleTokenBucket, Rate: 2, Burst: 3})
+
+	// 最初はバケットが満杯
+	writeSeq(t, sw, "op", 5)
+	if got := len(mem.written()); got != 3 {
+		t.Fatalf("残した件数 = %d, want 3（Burst）", got)
+	}
+
+	// 0.5秒で1トークン補充される
+	clock.Advance(500 * time.Millisecond)
+	writeSeq(t, sw, "op", 2)
+	if got := len(mem.written()); got != 4 {
+		t.Errorf("補充後に残した件数 = %d, want 4", got)
+	}
+
+	// 長時間空いてもBurstを超えて補充されない
+	clock.Advance(time.Minute)
+	writeSeq(t, sw, "op", 5)
+	if got := len(mem.written()); got != 7 {
+		t.Errorf("補充後に残した件数 = %d, want 7", got)
+	}
+	if got := sw.Stats(); got.Sampled != 7 || got.Suppressed != 5 {
+		t.Errorf("統計 = %+v, want Sampled 7, Suppressed 5", got)
+	}
+}
+
+func TestSamplingWriterProbabilistic(t *testing.T) {
+	values := []float64{0.1, 0.6, 0.29, 0.3, 0.9}
+	var i int
+	sw, mem, _ := newTestSampler(t, SamplingOptions{
+		Mode:        SampleProbabilistic,
+		Probability: 0.3,
+		Rand: func() float64 {
+			v := values[i%len(values)]
+			i++
+			return v
+		},
+	})
+
+	writeSeq(t, sw, "op", 5)
+	var seqs []int
+	for _, e := range mem.written() {
+		seqs = append(seqs, e.Context["seq"].(int))
+	}
+	if want := []int{1, 3}; !equalInts(seqs, want) {
+		t.Errorf("残したエントリ = %v, want %v", seqs, want)
+	}
+}
+
+func TestSamplingWriterPassesOtherLevels(t *testing.T) {
+	sw, mem, _ := newTestSampler(t, SamplingOptions{First: 1})
+
+	for i := 0; i < 3; i++ {
+		if err := sw.Write(&Entry{Level: WARN, Operation: "warn"}); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	writeSeq(t, sw, "info", 3)
+	writeSeq(t, sw, "other", 1)
+
+	// WARNはサンプリングせず、INFOは操作名ごとに数える
+	if got := sampledOperations(mem); !equalStrings(got, []string{"warn", "warn", "warn", "info", "other"}) {
+		t.Errorf("書き込まれた操作 = %v", got)
+	}
+}
+
+func TestSamplingCounterRoll(t *testing.T) {
+	var c samplingCounter
+	c.resetAt.Store(100)
+	c.count.Store(7)
+	c.suppressed.Store(4)
+
+	if _, rolled := c.roll(99, 10); rolled {
+		t.Fatal("ウィンドウが閉じる前に切り替わりました")
+	}
+	suppressed, rolled := c.roll(100, 10)
+	if !rolled || suppressed != 4 {
+		t.Fatalf("roll = (%d, %v), want (4, true)", suppressed, rolled)
+	}
+	if got := c.count.Load(); got != 0 {
+		t.Errorf("切り替え後の件数 = %d, want 0", got)
+	}
+	if got := c.resetAt.Load(); got != 110 {
+		t.Errorf("次のウィンドウが閉じる時刻 = %d, want 110", got)
+	}
+
+	// 件数0のウィンドウを切り替えても件数は0のまま
+	if _, rolled := c.roll(110, 10); !rolled || c.count.Load() != 0 {
+		t.Errorf("件数0での切り替え後の件数 = %d, want 0", c.count.Load())
+	}
+}
+
+func TestSamplingWriterConcurrentRoll(t *testing.T) {
+	const (
+		goroutines = 8
+		perG       = 500
+	)
+	sw, mem, clock := newTestSampler(t, SamplingOptions{First: 10, Thereafter: 5, Tick: time.Millisecond})
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perG; i++ {
+				if i%50 == 0 {
+					clock.Advance(time.Millisecond)
+				}
+				_ = sw.Write(testEntry("op"))
+			}
+		}()
+	}
+	wg.Wait()
+	if err := sw.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	stats := sw.Stats()
+	if total := stats.Sampled + stats.Suppressed; total != goroutines*perG {
+		t.Errorf("Sampled + Suppressed = %d, want %d", total, goroutines*perG)
+	}
+	if got := summaries(mem)["op"]; got != stats.Suppressed {
+		t.Errorf("要約の件数の合計 = %d, want %d", got, stats.Suppressed)
+	}
+	// 切り替えで差し引きすぎると件数がラップアラウンドする
+	c, _ := sw.counters.Load(samplingKey{level: INFO, operation: "op"})
+	if count := c.(*samplingCounter).count.Load(); count > goroutines*perG {
+		t.Errorf("ウィンドウの件数 = %d, want <= %d", count, goroutines*perG)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
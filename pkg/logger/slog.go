@@ -0,0 +1,364 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"sort"
+)
+
+// SlogHandlerOptions はslog.Handlerアダプターの設定です
+type SlogHandlerOptions struct {
+	TraceIDKey string // トレースIDとして扱う属性のキー（空の場合はtrace_id）
+	SpanIDKey  string // スパンIDとして扱う属性のキー（空の場合はspan_id）
+	TagsKey    string // タグとして扱う属性のキー（空の場合はtags）
+}
+
+// slogHandler はvibe Loggerを出力先とするslog.Handler
+type slogHandler struct {
+	logger Logger
+	opts   SlogHandlerOptions
+	attrs  map[string]interface{} // WithAttrsで追加された属性（グループはネストしたマップ）
+	groups []string               // WithGroupで開かれているグループ
+}
+
+// NewSlogHandler はvibe Loggerに出力するslog.Handlerを作成する
+// slog.Recordの属性とグループはエントリのContextに、trace_id・span_id・tags属性は
+// エントリのトレース情報とタグに変換される
+func NewSlogHandler(logger Logger, opts *SlogHandlerOptions) slog.Handler {
+	h := &slogHandler{
+		logger: logger,
+		attrs:  make(map[string]interface{}),
+	}
+	if opts != nil {
+		h.opts = *opts
+	}
+	if h.opts.TraceIDKey == "" {
+		h.opts.TraceIDKey = "trace_id"
+	}
+	if h.opts.SpanIDKey == "" {
+		h.opts.SpanIDKey = "span_id"
+	}
+	if h.opts.TagsKey == "" {
+		h.opts.TagsKey = "tags"
+	}
+	return h
+}
+
+// NewSlogLogger はvibe Loggerに出力する*slog.Loggerを作成する
+func NewSlogLogger(logger Logger) *slog.Logger {
+	return slog.New(NewSlogHandler(logger, nil))
+}
+
+// Enabled はvibe Loggerのレベル設定に従って出力の可否を返す
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return fromSlogLevel(level) >= h.logger.GetLevel()
+}
+
+// Handle はslog.Recordをvibe Loggerのエントリとして出力する
+func (h *slogHandler) Handle(ctx context.Context, record slog.Record) error {
+	attrs := cloneAttrMap(h.attrs)
+	record.Attrs(func(attr slog.Attr) bool {
+		insertSlogAttr(attrs, h.groups, attr)
+		return true
+	})
+
+	logger := h.logger
+	if ctx != nil {
+		logger = logger.WithContext(ctx)
+	}
+
+	// トレース情報とタグはトップレベルの属性からのみ取り出す
+	if traceID, ok := attrs[h.opts.TraceIDKey].(string); ok {
+		logger = logger.WithTraceID(traceID)
+		delete(attrs, h.opts.TraceIDKey)
+	}
+	if spanID, ok := attrs[h.opts.SpanIDKey].(string); ok {
+		logger = logger.WithSpanID(spanID)
+		delete(attrs, h.opts.SpanIDKey)
+	}
+	if tags, ok := attrs[h.opts.TagsKey]; ok {
+		switch t := tags.(type) {
+		case []string:
+			logger = logger.WithTags(t)
+			delete(attrs, h.opts.TagsKey)
+		case string:
+			logger = logger.WithTag(t)
+			delete(attrs, h.opts.TagsKey)
+		}
+	}
+
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]Field, 0, len(keys)+1)
+	for _, k := range keys {
+		fields = append(fields, Any(k, attrs[k]))
+	}
+	if record.PC != 0 {
+		fields = append(fields, callerField(record.PC))
+	}
+
+	switch fromSlogLevel(record.Level) {
+	case DEBUG:
+		logger.Debug(record.Message, fields...)
+	case INFO:
+		logger.Info(record.Message, fields...)
+	case WARN:
+		logger.Warn(record.Message, fields...)
+	default:
+		logger.Error(record.Message, fields...)
+	}
+	return nil
+}
+
+// WithAttrs は属性を追加したハンドラーを返す
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	clone := h.clone()
+	for _, attr := range attrs {
+		insertSlogAttr(clone.attrs, clone.groups, attr)
+	}
+	return clone
+}
+
+// WithGroup はグループを開いたハンドラーを返す
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := h.clone()
+	clone.groups = append(clone.groups, name)
+	return clone
+}
+
+// clone はハンドラーのコピーを作成する
+func (h *slogHandler) clone() *slogHandler {
+	groups := make([]string, len(h.groups))
+	copy(groups, h.groups)
+	return &slogHandler{
+		logger: h.logger,
+		opts:   h.opts,
+		attrs:  cloneAttrMap(h.attrs),
+		groups: groups,
+	}
+}
+
+// cloneAttrMap はネストしたマップを含めて属性マップをコピーする
+func cloneAttrMap(m map[string]interface{}) map[string]interface{} {
+	clone := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		if nested, ok := v.(map[string]interface{}); ok {
+			clone[k] = cloneAttrMap(nested)
+		} else {
+			clone[k] = v
+		}
+	}
+	return clone
+}
+
+// insertSlogAttr はグループのパスに従って属性をマップに追加する
+func insertSlogAttr(m map[string]interface{}, groups []string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+
+	target := m
+	for _, g := range groups {
+		nested, ok := target[g].(map[string]interface{})
+		if !ok {
+			nested = make(map[string]interface{})
+			target[g] = nested
+		}
+		target = nested
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		groupAttrs := attr.Value.Group()
+		if len(groupAttrs) == 0 {
+			return
+		}
+		// キーのないグループは親にインライン展開する
+		var path []string
+		if attr.Key != "" {
+			path = []string{attr.Key}
+		}
+		for _, ga := range groupAttrs {
+			insertSlogAttr(target, path, ga)
+		}
+		return
+	}
+
+	target[attr.Key] = slogValueToInterface(attr.Value)
+}
+
+// slogValueToInterface はslog.ValueをGoの値に変換する
+func slogValueToInterface(v slog.Value) interface{} {
+	switch v.Kind() {
+	case slog.KindString:
+		return v.String()
+	case slog.KindInt64:
+		return v.Int64()
+	case slog.KindUint64:
+		return v.Uint64()
+	case slog.KindFloat64:
+		return v.Float64()
+	case slog.KindBool:
+		return v.Bool()
+	case slog.KindDuration:
+		return v.Duration()
+	case slog.KindTime:
+		return v.Time()
+	case slog.KindGroup:
+		m := make(map[string]interface{})
+		for _, attr := range v.Group() {
+			insertSlogAttr(m, nil, attr)
+		}
+		return m
+	default:
+		return v.Any()
+	}
+}
+
+// fromSlogLevel はslog.LevelをLogLevelに変換する
+func fromSlogLevel(level slog.Level) LogLevel {
+	switch {
+	case level < slog.LevelInfo:
+		return DEBUG
+	case level < slog.LevelWarn:
+		return INFO
+	case level < slog.LevelError:
+		return WARN
+	default:
+		return ERROR
+	}
+}
+
+// toSlogLevel はLogLevelをslog.Levelに変換する
+// FATALはslog.LevelErrorより上位のレベルとして表現する
+func toSlogLevel(level LogLevel) slog.Level {
+	switch level {
+	case DEBUG:
+		return slog.LevelDebug
+	case INFO:
+		return slog.LevelInfo
+	case WARN:
+		return slog.LevelWarn
+	case ERROR:
+		return slog.LevelError
+	default:
+		return slog.LevelError + 4
+	}
+}
+
+// callerField はエントリの呼び出し元情報をプログラムカウンタから設定するフィールドを作成する
+func callerField(pc uintptr) Field {
+	return Field{Key: "caller", Value: entryField(func(e *Entry) {
+		frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		if frame.File == "" {
+			return
+		}
+		e.Metadata["caller"] = fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		if frame.Function != "" {
+			e.Metadata["function"] = frame.Function
+		}
+	})}
+}
+
+// SlogWriter はエントリを任意のslog.Handlerに転送するWriter
+type SlogWriter struct {
+	handler slog.Handler
+}
+
+// NewSlogWriter はエントリをslog.Handlerに転送するライターを作成する
+// エントリのAction・トレース情報・タグ・Context・入出力・エラー情報はslogの属性に変換される
+func NewSlogWriter(handler slog.Handler) *SlogWriter {
+	return &SlogWriter{handler: handler}
+}
+
+// Write はエントリをslog.Recordに変換してハンドラーに渡す
+func (w *SlogWriter) Write(entry *Entry) error {
+	ctx := context.Background()
+	level := toSlogLevel(entry.Level)
+	if !w.handler.Enabled(ctx, level) {
+		return nil
+	}
+
+	record := slog.NewRecord(entry.Timestamp, level, entry.Operation, 0)
+	record.AddAttrs(entryToSlogAttrs(entry)...)
+	return w.handler.Handle(ctx, record)
+}
+
+// Close はライターを閉じる
+func (w *SlogWriter) Close() error {
+	return nil
+}
+
+// entryToSlogAttrs はエントリをslogの属性に変換する
+func entryToSlogAttrs(entry *Entry) []slog.Attr {
+	attrs := make([]slog.Attr, 0, len(entry.Context)+10)
+
+	if entry.Action != "" {
+		attrs = append(attrs, slog.String("action", string(entry.Action)))
+	}
+	if entry.TraceID != "" {
+		attrs = append(attrs, slog.String("trace_id", entry.TraceID))
+	}
+	if entry.SpanID != "" {
+		attrs = append(attrs, slog.String("span_id", entry.SpanID))
+	}
+	if entry.ParentID != "" {
+		attrs = append(attrs, slog.String("parent_id", entry.ParentID))
+	}
+	if entry.Duration > 0 {
+		attrs = append(attrs, slog.Duration("duration", entry.Duration))
+	}
+	if len(entry.Tags) > 0 {
+		attrs = append(attrs, slog.Any("tags", entry.Tags))
+	}
+
+	keys := make([]string, 0, len(entry.Context))
+	for k := range entry.Context {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		attrs = append(attrs, slog.Any(k, entry.Context[k]))
+	}
+
+	if len(entry.Input) > 0 {
+		attrs = append(attrs, slog.Any("input", entry.Input))
+	}
+	if len(entry.Output) > 0 {
+		attrs = append(attrs, slog.Any("output", entry.Output))
+	}
+	if entry.Error != nil {
+		errAttrs := []any{
+			slog.String("message", entry.Error.Message),
+			slog.String("type", entry.Error.Type),
+			slog.Bool("retryable", entry.Error.Retryable),
+		}
+		if entry.Error.Code != "" {
+			errAttrs = append(errAttrs, slog.String("code", entry.Error.Code))
+		}
+		if entry.Error.Resolution != "" {
+			errAttrs = append(errAttrs, slog.String("resolution", entry.Error.Resolution))
+		}
+		if entry.Error.Stack != "" {
+			errAttrs = append(errAttrs, slog.String("stack", entry.Error.Stack))
+		}
+		attrs = append(attrs, slog.Group("error", errAttrs...))
+	}
+	if caller, ok := entry.Metadata["caller"]; ok {
+		attrs = append(attrs, slog.Any("caller", caller))
+	}
+
+	return attrs
+}
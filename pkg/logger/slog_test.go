@@ -0,0 +1,202 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newSlogTestLogger はvibe Loggerに出力する*slog.Loggerと、エントリの記録先を作成する
+func newSlogTestLogger(level LogLevel) (*slog.Logger, *memoryWriter) {
+	mem := &memoryWriter{}
+	l := New(level)
+	l.AddWriter(mem)
+	return NewSlogLogger(l), mem
+}
+
+func TestSlogHandlerAttrsAndGroups(t *testing.T) {
+	sl, mem := newSlogTestLogger(DEBUG)
+
+	sl.Info("request",
+		"count", 3,
+		slog.Group("http", "method", "GET", slog.Group("route", "path", "/users")),
+		slog.Group("", "inline", true), // キーのないグループは親に展開する
+		slog.Group("empty"),            // 空のグループは出力しない
+		slog.Attr{},                    // 空の属性は出力しない
+	)
+
+	e := lastEntry(t, mem)
+	if e.Operation != "request" || e.Level != INFO {
+		t.Errorf("エントリ = %s %s, want INFO request", e.Level, e.Operation)
+	}
+	httpGroup, _ := e.Context["http"].(map[string]interface{})
+	route, _ := httpGroup["route"].(map[string]interface{})
+	if e.Context["count"] != int64(3) || httpGroup["method"] != "GET" || route["path"] != "/users" || e.Context["inline"] != true {
+		t.Errorf("Context = %v", e.Context)
+	}
+	if _, ok := e.Context["empty"]; ok {
+		t.Errorf("空のグループが出力されました: %v", e.Context)
+	}
+	if caller, _ := e.Metadata["caller"].(string); !strings.Contains(caller, "slog_test.go") {
+		t.Errorf("caller = %q, want slogの呼び出し元", caller)
+	}
+}
+
+func TestSlogHandlerWithAttrsAndWithGroup(t *testing.T) {
+	sl, mem := newSlogTestLogger(DEBUG)
+
+	base := sl.With("service", "api")
+	scoped := base.WithGroup("req").With("id", 7)
+	scoped.Info("handled", "path", "/x")
+
+	e := lastEntry(t, mem)
+	req, _ := e.Context["req"].(map[string]interface{})
+	if e.Context["service"] != "api" || req["id"] != int64(7) || req["path"] != "/x" {
+		t.Errorf("Context = %v", e.Context)
+	}
+
+	// WithAttrs・WithGroupは元のハンドラーに影響しない
+	base.Info("base", "path", "/y")
+	e = lastEntry(t, mem)
+	if _, ok := e.Context["req"]; ok || e.Context["path"] != "/y" {
+		t.Errorf("元のハンドラーのContext = %v", e.Context)
+	}
+}
+
+func TestSlogHandlerTraceAndTags(t *testing.T) {
+	sl, mem := newSlogTestLogger(DEBUG)
+
+	sl.With("trace_id", "trace-1").Info("op", "span_id", "span-1", "tags", []string{"a", "b"}, slog.Group("g", "trace_id", "nested"))
+
+	e := lastEntry(t, mem)
+	if e.TraceID != "trace-1" || e.SpanID != "span-1" || !equalStrings(e.Tags, []string{"a", "b"}) {
+		t.Errorf("トレース情報 = (%q, %q, %v)", e.TraceID, e.SpanID, e.Tags)
+	}
+	// トップレベルの属性のみ変換し、グループ内の同名の属性はContextに残す
+	g, _ := e.Context["g"].(map[string]interface{})
+	if _, ok := e.Context["trace_id"]; ok || g["trace_id"] != "nested" {
+		t.Errorf("Context = %v", e.Context)
+	}
+}
+
+func TestSlogHandlerLevels(t *testing.T) {
+	sl, mem := newSlogTestLogger(WARN)
+
+	if sl.Enabled(context.Background(), slog.LevelInfo) || !sl.Enabled(context.Background(), slog.LevelWarn) {
+		t.Error("Enabledがvibe Loggerのレベル設定に従っていません")
+	}
+	sl.Info("dropped")
+	sl.Log(context.Background(), slog.LevelWarn+2, "warn")
+	sl.Log(context.Background(), slog.LevelError+4, "error")
+
+	var got []string
+	for _, e := range mem.written() {
+		got = append(got, e.Level.String()+":"+e.Operation)
+	}
+	if !equalStrings(got, []string{"WARN:warn", "ERROR:error"}) {
+		t.Errorf("エントリ = %v", got)
+	}
+}
+
+// decodeSlogJSON はslog.JSONHandlerの出力を1行ずつデコードする
+func decodeSlogJSON(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("%v: %s", err, line)
+		}
+		records = append(records, m)
+	}
+	return records
+}
+
+func TestSlogWriterForwardsEntries(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	// 転送先のハンドラーのWithAttrs・WithGroupはそのまま適用される
+	w := NewSlogWriter(handler.WithAttrs([]slog.Attr{slog.String("app", "demo")}).WithGroup("vibe"))
+
+	err := w.Write(&Entry{
+		Timestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Level:     ERROR,
+		Action:    ActionError,
+		Operation: "fetch",
+		TraceID:   "trace-1",
+		SpanID:    "span-1",
+		Duration:  time.Second,
+		Tags:      []string{"api"},
+		Context:   map[string]interface{}{"user_id": 42},
+		Input:     map[string]interface{}{"id": 1},
+		Error:     &ErrorInfo{Message: "timeout", Type: "net.Error", Retryable: true},
+		Metadata:  map[string]interface{}{"caller": "main.go:10"},
+	})
+	if err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	records := decodeSlogJSON(t, &buf)
+	if len(records) != 1 {
+		t.Fatalf("レコード = %d件, want 1件", len(records))
+	}
+	r := records[0]
+	vibe, _ := r["vibe"].(map[string]interface{})
+	errGroup, _ := vibe["error"].(map[string]interface{})
+	input, _ := vibe["input"].(map[string]interface{})
+	if r["msg"] != "fetch" || r["level"] != "ERROR" || r["app"] != "demo" {
+		t.Errorf("レコード = %v", r)
+	}
+	if vibe["action"] != "ERROR" || vibe["trace_id"] != "trace-1" || vibe["span_id"] != "span-1" ||
+		vibe["user_id"] != float64(42) || vibe["caller"] != "main.go:10" || input["id"] != float64(1) {
+		t.Errorf("属性 = %v", vibe)
+	}
+	if errGroup["message"] != "timeout" || errGroup["retryable"] != true {
+		t.Errorf("error = %v", errGroup)
+	}
+}
+
+func TestSlogWriterLevels(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewSlogWriter(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	for _, level := range []LogLevel{INFO, WARN, FATAL} {
+		if err := w.Write(&Entry{Level: level, Operation: level.String()}); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	// ハンドラーで無効なレベルは転送せず、FATALはERRORより上位のレベルになる
+	var got []string
+	for _, r := range decodeSlogJSON(t, &buf) {
+		got = append(got, r["level"].(string)+":"+r["msg"].(string))
+	}
+	if !equalStrings(got, []string{"WARN:WARN", "ERROR+4:FATAL"}) {
+		t.Errorf("レコード = %v", got)
+	}
+}
+
+func TestSlogRoundTrip(t *testing.T) {
+	// vibe Logger → SlogWriter → slog.Handler の経路で、トラッカーのイベントがslogのパイプラインに流れる
+	var buf bytes.Buffer
+	l := New(DEBUG)
+	l.AddWriter(NewSlogWriter(slog.NewJSONHandler(&buf, nil)))
+
+	op := l.StartOperation("sync", nil)
+	op.Complete(nil)
+
+	records := decodeSlogJSON(t, &buf)
+	if len(records) != 2 || records[0]["action"] != "START" || records[1]["action"] != "COMPLETE" {
+		t.Fatalf("レコード = %v", records)
+	}
+	if records[1]["trace_id"] != op.TraceID || records[1]["span_id"] != op.SpanID {
+		t.Errorf("トレース情報 = %v, want %s %s", records[1], op.TraceID, op.SpanID)
+	}
+}
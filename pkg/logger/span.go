@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// newTraceID は新しいトレースID（32桁の16進数）を生成する
+func newTraceID() string {
+	return randomHex(16)
+}
+
+// newSpanID は新しいスパンID（16桁の16進数）を生成する
+func newSpanID() string {
+	return randomHex(8)
+}
+
+// randomHex は指定したバイト数の乱数を16進数文字列で返す
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		// 乱数の取得に失敗した場合はUUIDから生成する
+		id := uuid.New()
+		copy(b, id[:])
+	}
+	return hex.EncodeToString(b)
+}
+
+// currentSpan はロガーに設定されたトレースIDとスパンIDを取得する
+// vibeLogger以外のロガーの場合は空文字列を返す
+func currentSpan(logger Logger) (traceID, spanID string) {
+	vl, ok := logger.(*vibeLogger)
+	if !ok {
+		return "", ""
+	}
+
+	vl.mu.RLock()
+	defer vl.mu.RUnlock()
+
+	traceID, spanID = vl.traceID, vl.spanID
+	if traceID == "" {
+		traceID = TraceIDFromContext(vl.context)
+	}
+	if spanID == "" {
+		spanID = SpanIDFromContext(vl.context)
+	}
+	return traceID, spanID
+}
+
+// newOperationTracker はスパン情報を割り当てた新しいトラッカーを作成する
+// traceIDが空の場合は新しいトレースを開始する
+// トラッカーのLoggerはトレースID・スパンID・親IDでスコープされる
+func newOperationTracker(logger Logger, operation string, input map[string]interface{}, traceID, parentID string) *OperationTracker {
+	if traceID == "" {
+		traceID = newTraceID()
+	}
+
+	tracker := &OperationTracker{
+		ID:        uuid.New().String(),
+		Operation: operation,
+		StartTime: time.Now(),
+		Input:     input,
+		Context:   make(map[string]interface{}),
+		TraceID:   traceID,
+		SpanID:    newSpanID(),
+		ParentID:  parentID,
+	}
+	tracker.Logger = scopeLogger(logger, tracker.TraceID, tracker.SpanID, tracker.ParentID)
+
+	return tracker
+}
+
+// scopeLogger はトレースID・スパンID・親IDを設定したロガーを返す
+func scopeLogger(logger Logger, traceID, spanID, parentID string) Logger {
+	if vl, ok := logger.(*vibeLogger); ok {
+		vl.mu.Lock()
+		defer vl.mu.Unlock()
+
+		scoped := vl.clone()
+		scoped.traceID = traceID
+		scoped.spanID = spanID
+		scoped.parentID = parentID
+		return scoped
+	}
+	return logger.WithTraceID(traceID).WithSpanID(spanID).WithParentID(parentID)
+}
+
+// spanField はエントリのトレースID・スパンID・親IDをトラッカーのものに設定するフィールドを作成する
+func spanField(tracker *OperationTracker) Field {
+	return Field{Key: "span", Value: entryField(func(e *Entry) {
+		e.TraceID = tracker.TraceID
+		e.SpanID = tracker.SpanID
+		e.ParentID = tracker.ParentID
+	})}
+}
@@ -0,0 +1,158 @@
+package logger
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+)
+
+// newSpanLogger はメモリに記録するロガーを作成する
+func newSpanLogger() (Logger, *memoryWriter) {
+	mem := &memoryWriter{}
+	l := New(DEBUG)
+	l.AddWriter(mem)
+	return l, mem
+}
+
+// lastEntry は最後に書き込まれたエントリを返す
+func lastEntry(t *testing.T, mem *memoryWriter) *Entry {
+	t.Helper()
+	entries := mem.written()
+	if len(entries) == 0 {
+		t.Fatal("エントリが書き込まれていません")
+	}
+	return entries[len(entries)-1]
+}
+
+// assertSpan はエントリのトレースID・スパンID・親IDを確認する
+func assertSpan(t *testing.T, e *Entry, traceID, spanID, parentID string) {
+	t.Helper()
+	if e.TraceID != traceID || e.SpanID != spanID || e.ParentID != parentID {
+		t.Errorf("%sのスパン = (%q, %q, %q), want (%q, %q, %q)", e.Operation, e.TraceID, e.SpanID, e.ParentID, traceID, spanID, parentID)
+	}
+}
+
+func TestSpanIDGeneration(t *testing.T) {
+	tests := []struct {
+		name string
+		gen  func() string
+		size int
+	}{
+		{"トレースID", newTraceID, 16},
+		{"スパンID", newSpanID, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen := make(map[string]bool)
+			for i := 0; i < 100; i++ {
+				id := tt.gen()
+				if b, err := hex.DecodeString(id); err != nil || len(b) != tt.size {
+					t.Fatalf("ID = %q, want %dバイトの16進数", id, tt.size)
+				}
+				if seen[id] {
+					t.Fatalf("IDが重複しました: %s", id)
+				}
+				seen[id] = true
+			}
+		})
+	}
+}
+
+func TestStartOperationStartsTrace(t *testing.T) {
+	l, mem := newSpanLogger()
+
+	op := l.StartOperation("root", nil)
+	if op.TraceID == "" || op.SpanID == "" || op.ParentID != "" {
+		t.Fatalf("ルートのスパン = (%q, %q, %q), want 新しいトレースで親なし", op.TraceID, op.SpanID, op.ParentID)
+	}
+	assertSpan(t, lastEntry(t, mem), op.TraceID, op.SpanID, "")
+
+	op.Complete(nil)
+	assertSpan(t, lastEntry(t, mem), op.TraceID, op.SpanID, "")
+
+	// 別の操作は別のトレースになる
+	if other := l.StartOperation("other", nil); other.TraceID == op.TraceID {
+		t.Error("ルートの操作ごとに新しいトレースIDが生成されていません")
+	}
+}
+
+func TestStartOperationInheritsTrace(t *testing.T) {
+	tests := []struct {
+		name       string
+		scope      func(Logger) Logger
+		wantTrace  string
+		wantParent string
+	}{
+		{"WithTraceID", func(l Logger) Logger { return l.WithTraceID("trace-1") }, "trace-1", ""},
+		{"WithSpanID", func(l Logger) Logger { return l.WithTraceID("trace-1").WithSpanID("span-1") }, "trace-1", "span-1"},
+		{"コンテキスト", func(l Logger) Logger {
+			ctx := ContextWithSpanID(ContextWithTraceID(context.Background(), "trace-2"), "span-2")
+			return l.WithContext(ctx)
+		}, "trace-2", "span-2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, mem := newSpanLogger()
+
+			op := tt.scope(l).StartOperation("op", nil)
+			if op.TraceID != tt.wantTrace || op.ParentID != tt.wantParent {
+				t.Errorf("スパン = (%q, 親 %q), want (%q, 親 %q)", op.TraceID, op.ParentID, tt.wantTrace, tt.wantParent)
+			}
+			assertSpan(t, lastEntry(t, mem), tt.wantTrace, op.SpanID, tt.wantParent)
+		})
+	}
+}
+
+func TestSubOperationParentLinkage(t *testing.T) {
+	l, mem := newSpanLogger()
+
+	root := l.StartOperation("root", nil)
+	child := root.CreateSubOperation("child", nil)
+	grandchild := child.CreateSubOperation("grandchild", nil)
+
+	if child.TraceID != root.TraceID || child.ParentID != root.SpanID || child.Parent() != root {
+		t.Errorf("子のスパン = (%q, 親 %q), want (%q, 親 %q)", child.TraceID, child.ParentID, root.TraceID, root.SpanID)
+	}
+	if grandchild.TraceID != root.TraceID || grandchild.ParentID != child.SpanID || grandchild.Parent() != child {
+		t.Errorf("孫のスパン = (%q, 親 %q), want (%q, 親 %q)", grandchild.TraceID, grandchild.ParentID, root.TraceID, child.SpanID)
+	}
+	assertSpan(t, lastEntry(t, mem), root.TraceID, grandchild.SpanID, child.SpanID)
+
+	grandchild.Complete(nil)
+	assertSpan(t, lastEntry(t, mem), root.TraceID, grandchild.SpanID, child.SpanID)
+	child.Error(errTrial, "")
+	assertSpan(t, lastEntry(t, mem), root.TraceID, child.SpanID, root.SpanID)
+}
+
+func TestTrackerLoggerIsScoped(t *testing.T) {
+	l, mem := newSpanLogger()
+	op := l.StartOperation("root", nil)
+
+	// 操作の中で出力したエントリにはトラッカーのスパンが付与される
+	op.Logger.Info("inside")
+	assertSpan(t, lastEntry(t, mem), op.TraceID, op.SpanID, "")
+
+	// トラッカーのLoggerで開始した操作は子スパンになる
+	nested := op.Logger.StartOperation("nested", nil)
+	if nested.TraceID != op.TraceID || nested.ParentID != op.SpanID {
+		t.Errorf("入れ子の操作のスパン = (%q, 親 %q), want (%q, 親 %q)", nested.TraceID, nested.ParentID, op.TraceID, op.SpanID)
+	}
+
+	// 元のロガーにはスパンが設定されない
+	l.Info("outside")
+	assertSpan(t, lastEntry(t, mem), "", "", "")
+}
+
+func TestBatchOperationSpans(t *testing.T) {
+	l, mem := newSpanLogger()
+
+	bt := NewBatchOperationTracker(l.WithTraceID("trace-1"), "import")
+	op := bt.AddOperation("row", nil)
+	if bt.TraceID != "trace-1" || op.TraceID != "trace-1" || op.ParentID != bt.SpanID {
+		t.Errorf("バッチ = %q, 操作 = (%q, 親 %q), want trace-1で親はバッチのスパン %q", bt.TraceID, op.TraceID, op.ParentID, bt.SpanID)
+	}
+	assertSpan(t, lastEntry(t, mem), "trace-1", op.SpanID, bt.SpanID)
+
+	bt.Complete(nil)
+	assertSpan(t, lastEntry(t, mem), "trace-1", bt.SpanID, "")
+}
@@ -5,29 +5,121 @@ package logger
 import (
 	"context"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// OperationStatus は操作の状態を表します。
+type OperationStatus int
+
+const (
+	// OperationPending は実行中（未完了）の状態です。
+	OperationPending OperationStatus = iota
+	// OperationCompleted は正常に完了した状態です。
+	OperationCompleted
+	// OperationFailed はエラーで終了した状態です。
+	OperationFailed
+	// OperationSkipped は実行されずにスキップされた状態です。
+	OperationSkipped
+	// OperationRetried はリトライ待ちの状態です。CompleteまたはErrorで最終状態に遷移します。
+	OperationRetried
+)
+
+// String はOperationStatusを文字列に変換します。
+func (s OperationStatus) String() string {
+	switch s {
+	case OperationPending:
+		return "PENDING"
+	case OperationCompleted:
+		return "COMPLETED"
+	case OperationFailed:
+		return "FAILED"
+	case OperationSkipped:
+		return "SKIPPED"
+	case OperationRetried:
+		return "RETRIED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// isTerminal は最終状態かどうかを返します。
+func (s OperationStatus) isTerminal() bool {
+	return s == OperationCompleted || s == OperationFailed || s == OperationSkipped
+}
+
 // Complete は操作の完了を記録します。
 // 操作の実行結果をLoggerに渡し、完了状態として記録します。
 func (t *OperationTracker) Complete(output map[string]interface{}) {
+	t.finish(OperationCompleted)
 	t.Logger.CompleteOperation(t, output)
 }
 
 // Error は操作のエラーを記録します。
 // 発生したエラーと、そのエラーに対する対処方法をLoggerに渡します。
 func (t *OperationTracker) Error(err error, resolution string) {
+	t.finish(OperationFailed)
 	t.Logger.ErrorOperation(t, err, resolution)
 }
 
+// Skip は操作をスキップしたことを記録します。
+// 実行されなかった理由とともにSKIP状態として記録します。
+func (t *OperationTracker) Skip(reason string) {
+	duration := t.finish(OperationSkipped)
+	t.Logger.Info(t.Operation,
+		actionField(ActionSkip),
+		String("operation_id", t.ID),
+		String("reason", reason),
+		durationField(duration))
+}
+
+// Retry は操作がリトライされることを記録します。
+// 状態をRETRIEDとし、試行回数と次のリトライまでの時間をLoggerに渡します。
+// 既に最終状態の操作に対しては状態を変更しません。
+func (t *OperationTracker) Retry(err error, attempt int, nextRetryIn time.Duration) {
+	t.mu.Lock()
+	if !t.status.isTerminal() {
+		t.status = OperationRetried
+	}
+	t.mu.Unlock()
+
+	t.Logger.LogRetry(t.Operation, attempt, err, nextRetryIn)
+}
+
+// Status は操作の現在の状態を取得します。
+func (t *OperationTracker) Status() OperationStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status
+}
+
 // GetDuration は操作の経過時間を取得します。
-// 操作開始時刻からの経過時間を計算して返します。
+// 操作が最終状態に達している場合はその時点までの時間を、それ以外は開始時刻からの経過時間を返します。
 func (t *OperationTracker) GetDuration() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.status.isTerminal() {
+		return t.duration
+	}
 	return time.Since(t.StartTime)
 }
 
+// finish は操作を最終状態に遷移させ、所要時間を返します。
+// 既に最終状態の場合は状態を変更せず、記録済みの所要時間を返します。
+func (t *OperationTracker) finish(status OperationStatus) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.status.isTerminal() {
+		t.status = status
+		t.duration = time.Since(t.StartTime)
+	}
+	return t.duration
+}
+
 // AddContext は操作にコンテキスト情報を追加します。
 // 操作に関連する追加情報をキーと値のペアで保存します。
 // Contextマップがnilの場合は初期化します。
@@ -39,33 +131,32 @@ func (t *OperationTracker) AddContext(key string, value interface{}) {
 }
 
 // CreateSubOperation は子操作を作成します。
-// 親操作から派生した子操作を作成し、親のコンテキストを継承します。
-// 子操作の開始ログを記録し、親子関係を管理します。
+// 親操作から派生した子操作を作成し、親のコンテキストとトレースIDを継承します。
+// 子操作は親のスパンIDをParentIDとして持ち、開始ログを記録します。
 func (t *OperationTracker) CreateSubOperation(operation string, input map[string]interface{}) *OperationTracker {
-	subTracker := &OperationTracker{
-		ID:        uuid.New().String(),
-		Operation: operation,
-		StartTime: time.Now(),
-		Input:     input,
-		Context:   make(map[string]interface{}),
-		Logger:    t.Logger,
-		parent:    t,
-	}
+	subTracker := newOperationTracker(t.Logger, operation, input, t.TraceID, t.SpanID)
+	subTracker.parent = t
 
 	// 親のコンテキストを継承
 	for k, v := range t.Context {
 		subTracker.Context[k] = v
 	}
 
-	t.Logger.Info(operation,
-		String("action", string(ActionStart)),
+	subTracker.Logger.Info(operation,
+		actionField(ActionStart),
 		String("operation_id", subTracker.ID),
-		String("parent_id", t.ID),
-		Any("input", input))
+		String("parent_operation_id", t.ID),
+		inputField(input))
 
 	return subTracker
 }
 
+// Parent は親操作のトラッカーを取得します。
+// ルートの操作の場合はnilを返します。
+func (t *OperationTracker) Parent() *OperationTracker {
+	return t.parent
+}
+
 // VibeTracker はバイブコーディングセッション専用のトラッカーです。
 // 一般的なOperationTrackerを組み込み、セッション管理と
 // 問題領域、プログラミングステップに特化した機能を提供します。
@@ -81,14 +172,11 @@ type VibeTracker struct {
 // セッションID、問題領域、プログラミングステップを設定し、
 // 環境情報のスナップショットを自動的に記録します。
 func NewVibeTracker(logger Logger, sessionID, problemDomain, programmingStep string) *VibeTracker {
-	baseTracker := &OperationTracker{
-		ID:        uuid.New().String(),
-		Operation: fmt.Sprintf("vibe_coding_%s", programmingStep),
-		StartTime: time.Now(),
-		Input:     make(map[string]interface{}),
-		Context:   make(map[string]interface{}),
-		Logger:    logger,
-	}
+	traceID, parentID := currentSpan(logger)
+	baseTracker := newOperationTracker(logger,
+		fmt.Sprintf("vibe_coding_%s", programmingStep),
+		make(map[string]interface{}),
+		traceID, parentID)
 
 	vt := &VibeTracker{
 		OperationTracker: baseTracker,
@@ -274,88 +362,173 @@ func (vt *VibeTracker) LogSessionSummary(accomplishments []string, challenges []
 
 // BatchOperationTracker は複数の操作を一括で追跡します。
 // 関連する複数の操作をグループ化し、バッチ全体の結果を管理します。
+// AddOperationと各操作のComplete/Errorは複数のゴルーチンから並行して呼び出せます。
 type BatchOperationTracker struct {
 	ID         string                 // バッチの一意識別子
 	BatchName  string                 // バッチの名前
 	Operations []*OperationTracker    // バッチに含まれる操作のスライス
 	StartTime  time.Time              // バッチの開始時刻
-	Logger     Logger                 // ログ出力用のLoggerインスタンス
+	Logger     Logger                 // ログ出力用のLoggerインスタンス（バッチのスパンでスコープされる）
 	Context    map[string]interface{} // バッチ全体のコンテキスト情報
+	TraceID    string                 // バッチのトレースID
+	SpanID     string                 // バッチのスパンID（各操作のParentIDとなる）
+
+	mu sync.Mutex // Operationsの追加と集計を保護する
+}
+
+// BatchStats はバッチに含まれる操作の集計結果です。
+type BatchStats struct {
+	Total              int                      // 操作の総数
+	Completed          int                      // 完了した操作の数
+	Failed             int                      // 失敗した操作の数
+	Skipped            int                      // スキップされた操作の数
+	Retried            int                      // リトライ待ちのまま終了していない操作の数
+	Pending            int                      // 実行中の操作の数
+	FailedOperationIDs []string                 // 失敗した操作のID
+	Durations          map[string]time.Duration // 終了した操作ごとの所要時間（操作IDがキー）
+	LatencyP50         time.Duration            // 完了・失敗した操作の所要時間の中央値
+	LatencyP95         time.Duration            // 完了・失敗した操作の所要時間の95パーセンタイル
+	LatencyMax         time.Duration            // 完了・失敗した操作の所要時間の最大値
 }
 
 // NewBatchOperationTracker は新しいバッチ操作トラッカーを作成します。
 // バッチ名とLoggerを指定し、初期化されたバッチトラッカーを返します。
+// バッチ自身も1つのスパンとなり、追加された操作はその子スパンとなります。
 func NewBatchOperationTracker(logger Logger, batchName string) *BatchOperationTracker {
+	traceID, parentID := currentSpan(logger)
+	if traceID == "" {
+		traceID = newTraceID()
+	}
+	spanID := newSpanID()
+
 	return &BatchOperationTracker{
 		ID:         uuid.New().String(),
 		BatchName:  batchName,
 		Operations: make([]*OperationTracker, 0),
 		StartTime:  time.Now(),
-		Logger:     logger,
+		Logger:     scopeLogger(logger, traceID, spanID, parentID),
 		Context:    make(map[string]interface{}),
+		TraceID:    traceID,
+		SpanID:     spanID,
 	}
 }
 
 // AddOperation はバッチに操作を追加します。
 // 新しい操作を作成し、バッチのコンテキストを継承し、操作リストに追加します。
 func (bt *BatchOperationTracker) AddOperation(operation string, input map[string]interface{}) *OperationTracker {
-	tracker := &OperationTracker{
-		ID:        uuid.New().String(),
-		Operation: operation,
-		StartTime: time.Now(),
-		Input:     input,
-		Context:   make(map[string]interface{}),
-		Logger:    bt.Logger,
-	}
+	tracker := newOperationTracker(bt.Logger, operation, input, bt.TraceID, bt.SpanID)
 
+	bt.mu.Lock()
 	// バッチのコンテキストを継承
 	for k, v := range bt.Context {
 		tracker.Context[k] = v
 	}
 
 	bt.Operations = append(bt.Operations, tracker)
+	bt.mu.Unlock()
 
-	bt.Logger.Info(operation,
-		String("action", string(ActionStart)),
+	tracker.Logger.Info(operation,
+		actionField(ActionStart),
 		String("operation_id", tracker.ID),
 		String("batch_id", bt.ID),
 		String("batch_name", bt.BatchName),
-		Any("input", input))
+		inputField(input))
 
 	return tracker
 }
 
+// Stats はバッチに含まれる操作の状態を集計します。
+func (bt *BatchOperationTracker) Stats() BatchStats {
+	bt.mu.Lock()
+	operations := make([]*OperationTracker, len(bt.Operations))
+	copy(operations, bt.Operations)
+	bt.mu.Unlock()
+
+	stats := BatchStats{
+		Total:              len(operations),
+		FailedOperationIDs: make([]string, 0),
+		Durations:          make(map[string]time.Duration),
+	}
+
+	latencies := make([]time.Duration, 0, len(operations))
+	for _, op := range operations {
+		op.mu.Lock()
+		status, duration := op.status, op.duration
+		op.mu.Unlock()
+
+		switch status {
+		case OperationCompleted:
+			stats.Completed++
+		case OperationFailed:
+			stats.Failed++
+			stats.FailedOperationIDs = append(stats.FailedOperationIDs, op.ID)
+		case OperationSkipped:
+			stats.Skipped++
+		case OperationRetried:
+			stats.Retried++
+		default:
+			stats.Pending++
+		}
+
+		if status.isTerminal() {
+			stats.Durations[op.ID] = duration
+		}
+		if status == OperationCompleted || status == OperationFailed {
+			latencies = append(latencies, duration)
+		}
+	}
+
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		stats.LatencyP50 = percentile(latencies, 50)
+		stats.LatencyP95 = percentile(latencies, 95)
+		stats.LatencyMax = latencies[len(latencies)-1]
+	}
+
+	return stats
+}
+
 // Complete はバッチ操作の完了を記録します。
 // バッチ全体の結果、統計情報、サマリーを記録します。
 func (bt *BatchOperationTracker) Complete(summary map[string]interface{}) {
 	duration := time.Since(bt.StartTime)
+	batchStats := bt.Stats()
+
+	operationDurations := make(map[string]interface{}, len(batchStats.Durations))
+	for id, d := range batchStats.Durations {
+		operationDurations[id] = d.Milliseconds()
+	}
 
 	stats := map[string]interface{}{
-		"total_operations":     len(bt.Operations),
-		"completed_operations": bt.countCompletedOperations(),
-		"failed_operations":    bt.countFailedOperations(),
+		"total_operations":     batchStats.Total,
+		"completed_operations": batchStats.Completed,
+		"failed_operations":    batchStats.Failed,
+		"skipped_operations":   batchStats.Skipped,
+		"retried_operations":   batchStats.Retried,
+		"pending_operations":   batchStats.Pending,
+		"failed_operation_ids": batchStats.FailedOperationIDs,
+		"operation_durations":  operationDurations,
+		"latency_p50_ms":       batchStats.LatencyP50.Milliseconds(),
+		"latency_p95_ms":       batchStats.LatencyP95.Milliseconds(),
+		"latency_max_ms":       batchStats.LatencyMax.Milliseconds(),
 		"total_duration":       duration,
 	}
 
 	bt.Logger.Info(bt.BatchName,
-		String("action", string(ActionComplete)),
+		actionField(ActionComplete),
 		String("batch_id", bt.ID),
 		Any("summary", summary),
-		Any("stats", stats))
+		Any("stats", stats),
+		durationField(duration))
 }
 
-// countCompletedOperations は完了した操作の数を数えます。
-// 現在の実装ではすべての操作を完了とみなします。
-func (bt *BatchOperationTracker) countCompletedOperations() int {
-	// TODO: 実際の実装では、各操作の状態を追跡する必要があります
-	return len(bt.Operations)
-}
-
-// countFailedOperations は失敗した操作の数を数えます。
-// 現在の実装では失敗した操作はないとみなします。
-func (bt *BatchOperationTracker) countFailedOperations() int {
-	// TODO: 実際の実装では、各操作の状態を追跡する必要があります
-	return 0
+// percentile はソート済みの所要時間から最近順位法でパーセンタイルを求めます。
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
 }
 
 // LogOperationMetrics は操作メトリクスを記録します。
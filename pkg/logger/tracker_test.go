@@ -0,0 +1,164 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestBatch はメモリに記録するロガーでバッチトラッカーを作成する
+func newTestBatch() (*BatchOperationTracker, *memoryWriter) {
+	mem := &memoryWriter{}
+	l := New(DEBUG)
+	l.AddWriter(mem)
+	return NewBatchOperationTracker(l, "import"), mem
+}
+
+// setFinished は操作を指定した状態と所要時間で終了したことにする
+func setFinished(op *OperationTracker, status OperationStatus, duration time.Duration) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	op.status = status
+	op.duration = duration
+}
+
+func TestOperationTrackerTerminalState(t *testing.T) {
+	bt, _ := newTestBatch()
+
+	completed := bt.AddOperation("completed", nil)
+	completed.Complete(nil)
+	// 最終状態になった後のErrorやRetryでは状態を変えない
+	completed.Error(errors.New("late"), "")
+	completed.Retry(errors.New("late"), 1, time.Second)
+	if got := completed.Status(); got != OperationCompleted {
+		t.Errorf("完了後の状態 = %s, want COMPLETED", got)
+	}
+
+	retried := bt.AddOperation("retried", nil)
+	retried.Retry(errors.New("timeout"), 1, time.Second)
+	if got := retried.Status(); got != OperationRetried {
+		t.Fatalf("リトライ後の状態 = %s, want RETRIED", got)
+	}
+	retried.Error(errors.New("timeout"), "give up")
+	if got := retried.Status(); got != OperationFailed {
+		t.Errorf("リトライ後に失敗した状態 = %s, want FAILED", got)
+	}
+
+	if got := bt.AddOperation("pending", nil).Status(); got != OperationPending {
+		t.Errorf("開始直後の状態 = %s, want PENDING", got)
+	}
+}
+
+func TestBatchOperationTrackerStats(t *testing.T) {
+	bt, _ := newTestBatch()
+
+	bt.AddOperation("ok1", nil).Complete(nil)
+	bt.AddOperation("ok2", nil).Complete(nil)
+	failed := bt.AddOperation("ng", nil)
+	failed.Error(errors.New("boom"), "retry later")
+	bt.AddOperation("skip", nil).Skip("cached")
+	bt.AddOperation("retry", nil).Retry(errors.New("timeout"), 1, time.Second)
+	bt.AddOperation("pending", nil)
+
+	stats := bt.Stats()
+	want := BatchStats{Total: 6, Completed: 2, Failed: 1, Skipped: 1, Retried: 1, Pending: 1}
+	if stats.Total != want.Total || stats.Completed != want.Completed || stats.Failed != want.Failed ||
+		stats.Skipped != want.Skipped || stats.Retried != want.Retried || stats.Pending != want.Pending {
+		t.Errorf("集計 = %+v", stats)
+	}
+	if !equalStrings(stats.FailedOperationIDs, []string{failed.ID}) {
+		t.Errorf("失敗した操作 = %v, want [%s]", stats.FailedOperationIDs, failed.ID)
+	}
+	// 所要時間は最終状態の操作のみ
+	if len(stats.Durations) != 4 {
+		t.Errorf("所要時間を記録した操作 = %d件, want 4件", len(stats.Durations))
+	}
+}
+
+func TestBatchOperationTrackerLatency(t *testing.T) {
+	bt, _ := newTestBatch()
+
+	// 1msから20msまでの所要時間の操作と、パーセンタイルの対象外のスキップした操作
+	for i := 1; i <= 20; i++ {
+		status := OperationCompleted
+		if i%5 == 0 {
+			status = OperationFailed
+		}
+		setFinished(bt.AddOperation(fmt.Sprintf("op%d", i), nil), status, time.Duration(i)*time.Millisecond)
+	}
+	setFinished(bt.AddOperation("skip", nil), OperationSkipped, time.Hour)
+
+	stats := bt.Stats()
+	if stats.LatencyP50 != 10*time.Millisecond || stats.LatencyP95 != 19*time.Millisecond || stats.LatencyMax != 20*time.Millisecond {
+		t.Errorf("p50 = %v, p95 = %v, max = %v, want 10ms, 19ms, 20ms", stats.LatencyP50, stats.LatencyP95, stats.LatencyMax)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := []time.Duration{1, 2, 3, 4}
+	tests := []struct {
+		p    int
+		want time.Duration
+	}{
+		{0, 1}, {25, 1}, {50, 2}, {51, 3}, {95, 4}, {100, 4},
+	}
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(%d) = %d, want %d", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestBatchOperationTrackerConcurrent(t *testing.T) {
+	bt, _ := newTestBatch()
+
+	// ワーカーのゴルーチンから並行して操作を追加・終了する
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			op := bt.AddOperation("work", nil)
+			if i%2 == 0 {
+				op.Complete(nil)
+			} else {
+				op.Error(errors.New("boom"), "")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	stats := bt.Stats()
+	if stats.Total != 50 || stats.Completed != 25 || stats.Failed != 25 || len(stats.FailedOperationIDs) != 25 {
+		t.Errorf("集計 = total %d, completed %d, failed %d, failed ids %d", stats.Total, stats.Completed, stats.Failed, len(stats.FailedOperationIDs))
+	}
+}
+
+func TestBatchOperationTrackerCompleteEntry(t *testing.T) {
+	bt, mem := newTestBatch()
+
+	ok := bt.AddOperation("ok", nil)
+	setFinished(ok, OperationCompleted, 30*time.Millisecond)
+	ng := bt.AddOperation("ng", nil)
+	setFinished(ng, OperationFailed, 10*time.Millisecond)
+	bt.Complete(map[string]interface{}{"source": "csv"})
+
+	entries := mem.written()
+	last := entries[len(entries)-1]
+	if last.Action != ActionComplete || last.Operation != "import" {
+		t.Fatalf("最後のエントリ = %s %s, want COMPLETE import", last.Action, last.Operation)
+	}
+	stats, _ := last.Context["stats"].(map[string]interface{})
+	if stats["completed_operations"] != 1 || stats["failed_operations"] != 1 || stats["latency_max_ms"] != int64(30) {
+		t.Errorf("stats = %v", stats)
+	}
+	if ids, _ := stats["failed_operation_ids"].([]string); !equalStrings(ids, []string{ng.ID}) {
+		t.Errorf("failed_operation_ids = %v", stats["failed_operation_ids"])
+	}
+	durations, _ := stats["operation_durations"].(map[string]interface{})
+	if durations[ok.ID] != int64(30) || durations[ng.ID] != int64(10) {
+		t.Errorf("operation_durations = %v", durations)
+	}
+}